@@ -0,0 +1,253 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mcasperson/OctoterraWizard/internal/hash"
+)
+
+// Phase identifies a step of the migration that modifies the source or destination space
+type Phase string
+
+const (
+	PhaseExtractSecrets     Phase = "ExtractSecrets"
+	PhaseStepTemplates      Phase = "StepTemplates"
+	PhaseSpaceExport        Phase = "SpaceExport"
+	PhaseProjectExport      Phase = "ProjectExport"
+	PhaseStartSpaceExport   Phase = "StartSpaceExport"
+	PhaseStartProjectExport Phase = "StartProjectExport"
+)
+
+// Phases lists the phases in the order they are run by the wizard
+var Phases = []Phase{
+	PhaseExtractSecrets,
+	PhaseStepTemplates,
+	PhaseSpaceExport,
+	PhaseProjectExport,
+	PhaseStartSpaceExport,
+	PhaseStartProjectExport,
+}
+
+// TaskSuccess is the task state reported by Octopus for a successful task
+const TaskSuccess = "Success"
+
+// TaskRecord captures the last runbook task run for a project
+type TaskRecord struct {
+	TaskId  string    `json:"taskId,omitempty"`
+	Result  string    `json:"result,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// ProjectRecord captures the serialize and deploy runbook tasks for a project
+type ProjectRecord struct {
+	ProjectName string     `json:"projectName"`
+	Serialize   TaskRecord `json:"serialize"`
+	Deploy      TaskRecord `json:"deploy"`
+}
+
+// Journal is the persisted record of a migration from a source space. It is safe for concurrent use.
+type Journal struct {
+	Server           string                    `json:"server"`
+	Space            string                    `json:"space"`
+	DestinationSpace string                    `json:"destinationSpace"`
+	CompletedPhases  map[Phase]time.Time       `json:"completedPhases"`
+	Projects         map[string]*ProjectRecord `json:"projects"`
+
+	path  string
+	mutex sync.Mutex
+}
+
+// Load reads the journal for the source space from the user config directory. An empty journal is returned if no
+// journal exists, or if the existing journal was recorded against a different destination space.
+func Load(server string, space string, destinationSpace string) (*Journal, error) {
+	configDir, err := os.UserConfigDir()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to find the user config directory"), err)
+	}
+
+	return LoadFrom(filepath.Join(configDir, "octoterrawiz", "checkpoints"), server, space, destinationSpace)
+}
+
+// LoadFrom reads the journal for the source space from the supplied directory
+func LoadFrom(dir string, server string, space string, destinationSpace string) (*Journal, error) {
+	journal := &Journal{
+		Server:           server,
+		Space:            space,
+		DestinationSpace: destinationSpace,
+		CompletedPhases:  map[Phase]time.Time{},
+		Projects:         map[string]*ProjectRecord{},
+		path:             filepath.Join(dir, hash.Sha256Hash(server+"/"+space)+".json"),
+	}
+
+	content, err := os.ReadFile(journal.path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to read the checkpoint file "+journal.path), err)
+	}
+
+	existing := Journal{}
+	if err := json.Unmarshal(content, &existing); err != nil {
+		return nil, errors.Join(errors.New("failed to parse the checkpoint file "+journal.path), err)
+	}
+
+	// A journal for another destination says nothing about the state of this destination
+	if existing.DestinationSpace != destinationSpace {
+		return journal, nil
+	}
+
+	if existing.CompletedPhases != nil {
+		journal.CompletedPhases = existing.CompletedPhases
+	}
+
+	if existing.Projects != nil {
+		journal.Projects = existing.Projects
+	}
+
+	return journal, nil
+}
+
+// HasProgress returns true if any phase or project was recorded
+func (j *Journal) HasProgress() bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return len(j.CompletedPhases) != 0 || len(j.Projects) != 0
+}
+
+// IsComplete returns true if the final phase was completed
+func (j *Journal) IsComplete() bool {
+	_, incomplete := j.NextPhase()
+	return !incomplete
+}
+
+// NextPhase returns the phase after the last completed phase, and false if the final phase is complete.
+// Phases before the last completed phase are not returned even if they are incomplete, because the
+// wizard allows phases like extracting secrets to be skipped.
+func (j *Journal) NextPhase() (Phase, bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	next := 0
+	for index, phase := range Phases {
+		if _, ok := j.CompletedPhases[phase]; ok {
+			next = index + 1
+		}
+	}
+
+	if next >= len(Phases) {
+		return "", false
+	}
+
+	return Phases[next], true
+}
+
+// IsPhaseComplete returns true if the phase was completed
+func (j *Journal) IsPhaseComplete(phase Phase) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	_, ok := j.CompletedPhases[phase]
+	return ok
+}
+
+// CompletePhase records the phase as complete and saves the journal
+func (j *Journal) CompletePhase(phase Phase) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.CompletedPhases[phase] = time.Now()
+	return j.save()
+}
+
+// RecordSerializeTask records the result of the serialize runbook for a project and saves the journal.
+// The result is empty while the task is running.
+func (j *Journal) RecordSerializeTask(projectId string, projectName string, taskId string, result string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	record := j.getProjectRecord(projectId, projectName)
+	record.Serialize = TaskRecord{TaskId: taskId, Result: result, Updated: time.Now()}
+	return j.save()
+}
+
+// RecordDeployTask records the result of the deploy runbook for a project and saves the journal.
+// The result is empty while the task is running.
+func (j *Journal) RecordDeployTask(projectId string, projectName string, taskId string, result string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	record := j.getProjectRecord(projectId, projectName)
+	record.Deploy = TaskRecord{TaskId: taskId, Result: result, Updated: time.Now()}
+	return j.save()
+}
+
+// IsProjectMigrated returns true if the deploy runbook for the project completed successfully
+func (j *Journal) IsProjectMigrated(projectId string) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	record, ok := j.Projects[projectId]
+	return ok && record.Deploy.Result == TaskSuccess
+}
+
+// Reset clears all progress and deletes the saved journal
+func (j *Journal) Reset() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.CompletedPhases = map[Phase]time.Time{}
+	j.Projects = map[string]*ProjectRecord{}
+
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Join(errors.New("failed to delete the checkpoint file "+j.path), err)
+	}
+
+	return nil
+}
+
+func (j *Journal) getProjectRecord(projectId string, projectName string) *ProjectRecord {
+	record, ok := j.Projects[projectId]
+
+	if !ok {
+		record = &ProjectRecord{}
+		j.Projects[projectId] = record
+	}
+
+	record.ProjectName = projectName
+	return record
+}
+
+// save writes the journal to disk. The caller must hold the mutex.
+func (j *Journal) save() error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return errors.Join(errors.New("failed to create the checkpoint directory"), err)
+	}
+
+	content, err := json.MarshalIndent(j, "", "  ")
+
+	if err != nil {
+		return errors.Join(errors.New("failed to serialize the checkpoint file"), err)
+	}
+
+	// Write to a temporary file first so a crash mid write does not corrupt the journal
+	tempPath := j.path + ".tmp"
+	if err := os.WriteFile(tempPath, content, 0600); err != nil {
+		return errors.Join(errors.New("failed to write the checkpoint file "+tempPath), err)
+	}
+
+	if err := os.Rename(tempPath, j.path); err != nil {
+		return errors.Join(errors.New("failed to write the checkpoint file "+j.path), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package checkpoint
+
+import (
+	"testing"
+)
+
+func TestJournalPersistsProgress(t *testing.T) {
+	dir := t.TempDir()
+
+	journal, err := LoadFrom(dir, "http://source", "Spaces-1", "Spaces-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if journal.HasProgress() {
+		t.Fatal("a new journal must not have progress")
+	}
+
+	// The extract secrets phase is skipped when there is no database access
+	if err := journal.CompletePhase(PhaseStepTemplates); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := journal.RecordDeployTask("Projects-1", "Project 1", "ServerTasks-1", TaskSuccess); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := journal.RecordDeployTask("Projects-2", "Project 2", "ServerTasks-2", "Failed"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadFrom(dir, "http://source", "Spaces-1", "Spaces-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if phase, ok := reloaded.NextPhase(); !ok || phase != PhaseSpaceExport {
+		t.Fatalf("expected the next phase to be %s, got %s", PhaseSpaceExport, phase)
+	}
+
+	if !reloaded.IsProjectMigrated("Projects-1") {
+		t.Fatal("Projects-1 must be migrated")
+	}
+
+	if reloaded.IsProjectMigrated("Projects-2") {
+		t.Fatal("Projects-2 must not be migrated")
+	}
+}
+
+func TestJournalIgnoresOtherDestinations(t *testing.T) {
+	dir := t.TempDir()
+
+	journal, err := LoadFrom(dir, "http://source", "Spaces-1", "Spaces-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := journal.CompletePhase(PhaseExtractSecrets); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := LoadFrom(dir, "http://source", "Spaces-1", "Spaces-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.HasProgress() {
+		t.Fatal("a journal for another destination must be ignored")
+	}
+}
+
+func TestJournalReset(t *testing.T) {
+	dir := t.TempDir()
+
+	journal, err := LoadFrom(dir, "http://source", "Spaces-1", "Spaces-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, phase := range Phases {
+		if err := journal.CompletePhase(phase); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !journal.IsComplete() {
+		t.Fatal("the journal must be complete")
+	}
+
+	if err := journal.Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadFrom(dir, "http://source", "Spaces-1", "Spaces-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded.HasProgress() {
+		t.Fatal("a reset journal must not have progress")
+	}
+}
@@ -0,0 +1,497 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the version of the migration file written by this version of the wizard
+const CurrentVersion = 1
+
+// EnvPrefix marks a secret value that is read from an environment variable, e.g. "env:OCTOTERRAWIZ_SOURCE_API_KEY"
+const EnvPrefix = "env:"
+
+// FilePrefix marks a secret value that is read from a file, e.g. "file:/run/secrets/source_api_key"
+const FilePrefix = "file:"
+
+// MigrationConfig is the versioned representation of state.State saved to and loaded from a migration file
+type MigrationConfig struct {
+	Version     int                    `json:"version" yaml:"version"`
+	Source      OctopusServerConfig    `json:"source" yaml:"source"`
+	Destination OctopusServerConfig    `json:"destination" yaml:"destination"`
+	Backend     BackendConfig          `json:"backend" yaml:"backend"`
+	Database    DatabaseConfig         `json:"database" yaml:"database"`
+	Options     AdvancedOptionConfig   `json:"options" yaml:"options"`
+	Projects    ProjectSelectionConfig `json:"projects" yaml:"projects"`
+}
+
+type OctopusServerConfig struct {
+	Server         string `json:"server,omitempty" yaml:"server,omitempty"`
+	ServerExternal string `json:"serverExternal,omitempty" yaml:"serverExternal,omitempty"`
+	ApiKey         string `json:"apiKey,omitempty" yaml:"apiKey,omitempty"`
+	SpaceId        string `json:"spaceId,omitempty" yaml:"spaceId,omitempty"`
+}
+
+type BackendConfig struct {
+	Type            string                `json:"type,omitempty" yaml:"type,omitempty"`
+	CreateIfMissing *bool                 `json:"createIfMissing,omitempty" yaml:"createIfMissing,omitempty"`
+	Aws             AwsBackendConfig      `json:"aws" yaml:"aws"`
+	Azure           AzureBackendConfig    `json:"azure" yaml:"azure"`
+	Google          GoogleBackendConfig   `json:"google" yaml:"google"`
+	Http            HttpBackendConfig     `json:"http" yaml:"http"`
+	Postgres        PostgresBackendConfig `json:"postgres" yaml:"postgres"`
+}
+
+type AwsBackendConfig struct {
+	AccessKey            string `json:"accessKey,omitempty" yaml:"accessKey,omitempty"`
+	SecretKey            string `json:"secretKey,omitempty" yaml:"secretKey,omitempty"`
+	Bucket               string `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	Region               string `json:"region,omitempty" yaml:"region,omitempty"`
+	Endpoint             string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	UsePathStyle         *bool  `json:"usePathStyle,omitempty" yaml:"usePathStyle,omitempty"`
+	CaBundle             string `json:"caBundle,omitempty" yaml:"caBundle,omitempty"`
+	Profile              string `json:"profile,omitempty" yaml:"profile,omitempty"`
+	RoleArn              string `json:"roleArn,omitempty" yaml:"roleArn,omitempty"`
+	RoleSessionName      string `json:"roleSessionName,omitempty" yaml:"roleSessionName,omitempty"`
+	ExternalId           string `json:"externalId,omitempty" yaml:"externalId,omitempty"`
+	WebIdentityTokenFile string `json:"webIdentityTokenFile,omitempty" yaml:"webIdentityTokenFile,omitempty"`
+}
+
+type AzureBackendConfig struct {
+	ResourceGroup       string `json:"resourceGroup,omitempty" yaml:"resourceGroup,omitempty"`
+	StorageAccount      string `json:"storageAccount,omitempty" yaml:"storageAccount,omitempty"`
+	Container           string `json:"container,omitempty" yaml:"container,omitempty"`
+	SubscriptionId      string `json:"subscriptionId,omitempty" yaml:"subscriptionId,omitempty"`
+	TenantId            string `json:"tenantId,omitempty" yaml:"tenantId,omitempty"`
+	ApplicationId       string `json:"applicationId,omitempty" yaml:"applicationId,omitempty"`
+	Password            string `json:"password,omitempty" yaml:"password,omitempty"`
+	AuthType            string `json:"authType,omitempty" yaml:"authType,omitempty"`
+	CertificatePath     string `json:"certificatePath,omitempty" yaml:"certificatePath,omitempty"`
+	CertificatePassword string `json:"certificatePassword,omitempty" yaml:"certificatePassword,omitempty"`
+	FederatedTokenFile  string `json:"federatedTokenFile,omitempty" yaml:"federatedTokenFile,omitempty"`
+	Location            string `json:"location,omitempty" yaml:"location,omitempty"`
+}
+
+type GoogleBackendConfig struct {
+	Bucket      string `json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	Credentials string `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+}
+
+type HttpBackendConfig struct {
+	Address  string `json:"address,omitempty" yaml:"address,omitempty"`
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+type PostgresBackendConfig struct {
+	ConnectionString string `json:"connectionString,omitempty" yaml:"connectionString,omitempty"`
+}
+
+type DatabaseConfig struct {
+	Server    string `json:"server,omitempty" yaml:"server,omitempty"`
+	Port      string `json:"port,omitempty" yaml:"port,omitempty"`
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+	User      string `json:"user,omitempty" yaml:"user,omitempty"`
+	Password  string `json:"password,omitempty" yaml:"password,omitempty"`
+	MasterKey string `json:"masterKey,omitempty" yaml:"masterKey,omitempty"`
+	// AuthType is one of the sensitivevariables.DatabaseAuthTypes
+	AuthType               string `json:"authType,omitempty" yaml:"authType,omitempty"`
+	Instance               string `json:"instance,omitempty" yaml:"instance,omitempty"`
+	Encrypt                string `json:"encrypt,omitempty" yaml:"encrypt,omitempty"`
+	TrustServerCertificate *bool  `json:"trustServerCertificate,omitempty" yaml:"trustServerCertificate,omitempty"`
+	AzureTenantId          string `json:"azureTenantId,omitempty" yaml:"azureTenantId,omitempty"`
+	AzureClientId          string `json:"azureClientId,omitempty" yaml:"azureClientId,omitempty"`
+	// SecretsFile is the encrypted file that sensitive values are saved to instead of the source space
+	SecretsFile       string `json:"secretsFile,omitempty" yaml:"secretsFile,omitempty"`
+	SecretsPassphrase string `json:"secretsPassphrase,omitempty" yaml:"secretsPassphrase,omitempty"`
+	ContinueOnError   *bool  `json:"continueOnError,omitempty" yaml:"continueOnError,omitempty"`
+}
+
+// AdvancedOptionConfig uses pointers so an option missing from the file leaves the existing value untouched
+type AdvancedOptionConfig struct {
+	PromptForDelete               *bool `json:"promptForDelete,omitempty" yaml:"promptForDelete,omitempty"`
+	UseContainerImages            *bool `json:"useContainerImages,omitempty" yaml:"useContainerImages,omitempty"`
+	ExcludeAllLibraryVariableSets *bool `json:"excludeAllLibraryVariableSets,omitempty" yaml:"excludeAllLibraryVariableSets,omitempty"`
+	EnableVariableSpreading       *bool `json:"enableVariableSpreading,omitempty" yaml:"enableVariableSpreading,omitempty"`
+	EnableProjectRenaming         *bool `json:"enableProjectRenaming,omitempty" yaml:"enableProjectRenaming,omitempty"`
+	MaxConcurrentRunbooks         int   `json:"maxConcurrentRunbooks,omitempty" yaml:"maxConcurrentRunbooks,omitempty"`
+	TaskTimeoutMinutes            int   `json:"taskTimeoutMinutes,omitempty" yaml:"taskTimeoutMinutes,omitempty"`
+}
+
+// ProjectSelectionConfig defines the projects that are migrated. A project is migrated if it matches any include
+// rule (or there are no include rules), and does not match any exclude rule.
+type ProjectSelectionConfig struct {
+	Include ProjectRuleConfig `json:"include" yaml:"include"`
+	Exclude ProjectRuleConfig `json:"exclude" yaml:"exclude"`
+}
+
+type ProjectRuleConfig struct {
+	Groups      []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	NamePattern string   `json:"namePattern,omitempty" yaml:"namePattern,omitempty"`
+	Ids         []string `json:"ids,omitempty" yaml:"ids,omitempty"`
+}
+
+// Load reads a migration file. Files with a .json extension are parsed as JSON, and everything else as YAML.
+func Load(path string) (MigrationConfig, error) {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return MigrationConfig{}, errors.Join(errors.New("failed to read the migration file "+path), err)
+	}
+
+	config := MigrationConfig{}
+	if isJson(path) {
+		err = json.Unmarshal(content, &config)
+	} else {
+		err = yaml.Unmarshal(content, &config)
+	}
+
+	if err != nil {
+		return MigrationConfig{}, errors.Join(errors.New("failed to parse the migration file "+path), err)
+	}
+
+	if config.Version == 0 {
+		return MigrationConfig{}, errors.New("the migration file " + path + " does not define a version")
+	}
+
+	if config.Version > CurrentVersion {
+		return MigrationConfig{}, fmt.Errorf("the migration file %s has version %d, but this version of the wizard only supports up to version %d", path, config.Version, CurrentVersion)
+	}
+
+	return config, nil
+}
+
+// Save writes a migration file. Files with a .json extension are written as JSON, and everything else as YAML.
+func Save(path string, config MigrationConfig) error {
+	content, err := Marshal(path, config)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return errors.Join(errors.New("failed to write the migration file "+path), err)
+	}
+
+	return nil
+}
+
+// Marshal serializes the config in the format implied by the path extension
+func Marshal(path string, config MigrationConfig) ([]byte, error) {
+	var content []byte
+	var err error
+	if isJson(path) {
+		content, err = json.MarshalIndent(config, "", "  ")
+	} else {
+		content, err = yaml.Marshal(config)
+	}
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to serialize the migration file"), err)
+	}
+
+	return content, nil
+}
+
+// FromState builds a config from the state. Secrets are never written in plain text. Instead, they reference the
+// environment variables that the wizard reads by default, so the file can be checked in and reused.
+func FromState(state state.State) MigrationConfig {
+	return MigrationConfig{
+		Version: CurrentVersion,
+		Source: OctopusServerConfig{
+			Server:         state.Server,
+			ServerExternal: state.ServerExternal,
+			ApiKey:         secretReference("OCTOTERRAWIZ_SOURCE_API_KEY", state.ApiKey),
+			SpaceId:        state.Space,
+		},
+		Destination: OctopusServerConfig{
+			Server:         state.DestinationServer,
+			ServerExternal: state.DestinationServerExternal,
+			ApiKey:         secretReference("OCTOTERRAWIZ_DESTINATION_API_KEY", state.DestinationApiKey),
+			SpaceId:        state.DestinationSpace,
+		},
+		Backend: BackendConfig{
+			Type:            state.BackendType,
+			CreateIfMissing: &state.CreateBackendStorage,
+			Aws: AwsBackendConfig{
+				AccessKey:            state.AwsAccessKey,
+				SecretKey:            secretReference("AWS_SECRET_ACCESS_KEY", state.AwsSecretKey),
+				Bucket:               state.AwsS3Bucket,
+				Region:               state.AwsS3BucketRegion,
+				Endpoint:             state.AwsS3Endpoint,
+				UsePathStyle:         &state.AwsS3UsePathStyle,
+				CaBundle:             state.AwsCaBundle,
+				Profile:              state.AwsProfile,
+				RoleArn:              state.AwsRoleArn,
+				RoleSessionName:      state.AwsRoleSessionName,
+				ExternalId:           state.AwsRoleExternalId,
+				WebIdentityTokenFile: state.AwsWebIdentityTokenFile,
+			},
+			Azure: AzureBackendConfig{
+				ResourceGroup:       state.AzureResourceGroupName,
+				StorageAccount:      state.AzureStorageAccountName,
+				Container:           state.AzureContainerName,
+				SubscriptionId:      state.AzureSubscriptionId,
+				TenantId:            state.AzureTenantId,
+				ApplicationId:       state.AzureApplicationId,
+				Password:            secretReference("AZURE_CLIENT_SECRET", state.AzurePassword),
+				AuthType:            state.AzureAuthType,
+				CertificatePath:     state.AzureCertificatePath,
+				CertificatePassword: secretReference("AZURE_CLIENT_CERTIFICATE_PASSWORD", state.AzureCertificatePassword),
+				FederatedTokenFile:  state.AzureFederatedTokenFile,
+				Location:            state.AzureLocation,
+			},
+			Google: GoogleBackendConfig{
+				Bucket:      state.GoogleCloudBucket,
+				Credentials: secretReference("GOOGLE_CREDENTIALS", state.GoogleCloudCredentials),
+			},
+			Http: HttpBackendConfig{
+				Address:  state.HttpAddress,
+				Username: state.HttpUsername,
+				Password: secretReference("TF_HTTP_PASSWORD", state.HttpPassword),
+			},
+			Postgres: PostgresBackendConfig{
+				ConnectionString: secretReference("PG_CONN_STR", state.PostgresConnectionString),
+			},
+		},
+		Database: DatabaseConfig{
+			Server:                 state.DatabaseServer,
+			Port:                   state.DatabasePort,
+			Name:                   state.DatabaseName,
+			User:                   state.DatabaseUser,
+			Password:               secretReference("OCTOTERRAWIZ_DATABASE_PASS", state.DatabasePass),
+			MasterKey:              secretReference("OCTOTERRAWIZ_DATABASE_MASTERKEY", state.DatabaseMasterKey),
+			AuthType:               state.DatabaseAuthType,
+			Instance:               state.DatabaseInstance,
+			Encrypt:                state.DatabaseEncrypt,
+			TrustServerCertificate: &state.DatabaseTrustServerCertificate,
+			AzureTenantId:          state.DatabaseAzureTenantId,
+			AzureClientId:          state.DatabaseAzureClientId,
+			SecretsFile:            state.SecretsFile,
+			SecretsPassphrase:      secretReference("OCTOTERRAWIZ_SECRETS_PASSPHRASE", state.SecretsPassphrase),
+			ContinueOnError:        &state.ContinueOnExtractionError,
+		},
+		Options: AdvancedOptionConfig{
+			PromptForDelete:               &state.PromptForDelete,
+			UseContainerImages:            &state.UseContainerImages,
+			ExcludeAllLibraryVariableSets: &state.ExcludeAllLibraryVariableSets,
+			EnableVariableSpreading:       &state.EnableVariableSpreading,
+			EnableProjectRenaming:         &state.EnableProjectRenaming,
+			MaxConcurrentRunbooks:         state.MaxConcurrentRunbooks,
+			TaskTimeoutMinutes:            state.TaskTimeoutMinutes,
+		},
+		Projects: ProjectSelectionConfig{
+			Include: ProjectRuleConfig{
+				Groups:      state.IncludeProjectGroups,
+				NamePattern: state.IncludeProjectNamePattern,
+				Ids:         state.IncludeProjectIds,
+			},
+			Exclude: ProjectRuleConfig{
+				Groups:      state.ExcludeProjectGroups,
+				NamePattern: state.ExcludeProjectNamePattern,
+				Ids:         state.ExcludeProjectIds,
+			},
+		},
+	}
+}
+
+// Apply overlays the config onto an existing state. Empty values in the config leave the existing value in place,
+// and secret references are resolved.
+func (c MigrationConfig) Apply(existing state.State) (state.State, error) {
+	newState := existing
+	var err error
+
+	newState.Server = valueOrDefault(c.Source.Server, existing.Server)
+	newState.ServerExternal = valueOrDefault(c.Source.ServerExternal, existing.ServerExternal)
+	newState.Space = valueOrDefault(c.Source.SpaceId, existing.Space)
+	newState.DestinationServer = valueOrDefault(c.Destination.Server, existing.DestinationServer)
+	newState.DestinationServerExternal = valueOrDefault(c.Destination.ServerExternal, existing.DestinationServerExternal)
+	newState.DestinationSpace = valueOrDefault(c.Destination.SpaceId, existing.DestinationSpace)
+	newState.BackendType = valueOrDefault(c.Backend.Type, existing.BackendType)
+	newState.AwsAccessKey = valueOrDefault(c.Backend.Aws.AccessKey, existing.AwsAccessKey)
+	newState.AwsS3Bucket = valueOrDefault(c.Backend.Aws.Bucket, existing.AwsS3Bucket)
+	newState.AwsS3BucketRegion = valueOrDefault(c.Backend.Aws.Region, existing.AwsS3BucketRegion)
+	newState.AwsS3Endpoint = valueOrDefault(c.Backend.Aws.Endpoint, existing.AwsS3Endpoint)
+	newState.AwsS3UsePathStyle = boolOrDefault(c.Backend.Aws.UsePathStyle, existing.AwsS3UsePathStyle)
+	newState.AwsCaBundle = valueOrDefault(c.Backend.Aws.CaBundle, existing.AwsCaBundle)
+	newState.AwsProfile = valueOrDefault(c.Backend.Aws.Profile, existing.AwsProfile)
+	newState.AwsRoleArn = valueOrDefault(c.Backend.Aws.RoleArn, existing.AwsRoleArn)
+	newState.AwsRoleSessionName = valueOrDefault(c.Backend.Aws.RoleSessionName, existing.AwsRoleSessionName)
+	newState.AwsRoleExternalId = valueOrDefault(c.Backend.Aws.ExternalId, existing.AwsRoleExternalId)
+	newState.AwsWebIdentityTokenFile = valueOrDefault(c.Backend.Aws.WebIdentityTokenFile, existing.AwsWebIdentityTokenFile)
+	newState.AzureResourceGroupName = valueOrDefault(c.Backend.Azure.ResourceGroup, existing.AzureResourceGroupName)
+	newState.AzureStorageAccountName = valueOrDefault(c.Backend.Azure.StorageAccount, existing.AzureStorageAccountName)
+	newState.AzureContainerName = valueOrDefault(c.Backend.Azure.Container, existing.AzureContainerName)
+	newState.AzureSubscriptionId = valueOrDefault(c.Backend.Azure.SubscriptionId, existing.AzureSubscriptionId)
+	newState.AzureTenantId = valueOrDefault(c.Backend.Azure.TenantId, existing.AzureTenantId)
+	newState.AzureApplicationId = valueOrDefault(c.Backend.Azure.ApplicationId, existing.AzureApplicationId)
+	newState.AzureAuthType = valueOrDefault(c.Backend.Azure.AuthType, existing.AzureAuthType)
+	newState.AzureCertificatePath = valueOrDefault(c.Backend.Azure.CertificatePath, existing.AzureCertificatePath)
+	newState.AzureFederatedTokenFile = valueOrDefault(c.Backend.Azure.FederatedTokenFile, existing.AzureFederatedTokenFile)
+	newState.AzureLocation = valueOrDefault(c.Backend.Azure.Location, existing.AzureLocation)
+	newState.CreateBackendStorage = boolOrDefault(c.Backend.CreateIfMissing, existing.CreateBackendStorage)
+	newState.GoogleCloudBucket = valueOrDefault(c.Backend.Google.Bucket, existing.GoogleCloudBucket)
+	newState.HttpAddress = valueOrDefault(c.Backend.Http.Address, existing.HttpAddress)
+	newState.HttpUsername = valueOrDefault(c.Backend.Http.Username, existing.HttpUsername)
+	newState.DatabaseServer = valueOrDefault(c.Database.Server, existing.DatabaseServer)
+	newState.DatabasePort = valueOrDefault(c.Database.Port, existing.DatabasePort)
+	newState.DatabaseName = valueOrDefault(c.Database.Name, existing.DatabaseName)
+	newState.DatabaseUser = valueOrDefault(c.Database.User, existing.DatabaseUser)
+	newState.DatabaseAuthType = valueOrDefault(c.Database.AuthType, existing.DatabaseAuthType)
+	newState.DatabaseInstance = valueOrDefault(c.Database.Instance, existing.DatabaseInstance)
+	newState.DatabaseEncrypt = valueOrDefault(c.Database.Encrypt, existing.DatabaseEncrypt)
+	newState.DatabaseTrustServerCertificate = boolOrDefault(c.Database.TrustServerCertificate, existing.DatabaseTrustServerCertificate)
+	newState.DatabaseAzureTenantId = valueOrDefault(c.Database.AzureTenantId, existing.DatabaseAzureTenantId)
+	newState.DatabaseAzureClientId = valueOrDefault(c.Database.AzureClientId, existing.DatabaseAzureClientId)
+	newState.SecretsFile = valueOrDefault(c.Database.SecretsFile, existing.SecretsFile)
+	newState.ContinueOnExtractionError = boolOrDefault(c.Database.ContinueOnError, existing.ContinueOnExtractionError)
+
+	if newState.ApiKey, err = resolveSecretOrDefault(c.Source.ApiKey, existing.ApiKey); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve source.apiKey"), err)
+	}
+
+	if newState.DestinationApiKey, err = resolveSecretOrDefault(c.Destination.ApiKey, existing.DestinationApiKey); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve destination.apiKey"), err)
+	}
+
+	if newState.AwsSecretKey, err = resolveSecretOrDefault(c.Backend.Aws.SecretKey, existing.AwsSecretKey); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve backend.aws.secretKey"), err)
+	}
+
+	if newState.AzurePassword, err = resolveSecretOrDefault(c.Backend.Azure.Password, existing.AzurePassword); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve backend.azure.password"), err)
+	}
+
+	if newState.AzureCertificatePassword, err = resolveSecretOrDefault(c.Backend.Azure.CertificatePassword, existing.AzureCertificatePassword); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve backend.azure.certificatePassword"), err)
+	}
+
+	if newState.GoogleCloudCredentials, err = resolveSecretOrDefault(c.Backend.Google.Credentials, existing.GoogleCloudCredentials); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve backend.google.credentials"), err)
+	}
+
+	if newState.HttpPassword, err = resolveSecretOrDefault(c.Backend.Http.Password, existing.HttpPassword); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve backend.http.password"), err)
+	}
+
+	if newState.PostgresConnectionString, err = resolveSecretOrDefault(c.Backend.Postgres.ConnectionString, existing.PostgresConnectionString); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve backend.postgres.connectionString"), err)
+	}
+
+	if newState.DatabasePass, err = resolveSecretOrDefault(c.Database.Password, existing.DatabasePass); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve database.password"), err)
+	}
+
+	if newState.DatabaseMasterKey, err = resolveSecretOrDefault(c.Database.MasterKey, existing.DatabaseMasterKey); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve database.masterKey"), err)
+	}
+
+	if newState.SecretsPassphrase, err = resolveSecretOrDefault(c.Database.SecretsPassphrase, existing.SecretsPassphrase); err != nil {
+		return state.State{}, errors.Join(errors.New("failed to resolve database.secretsPassphrase"), err)
+	}
+
+	newState.PromptForDelete = boolOrDefault(c.Options.PromptForDelete, existing.PromptForDelete)
+	newState.UseContainerImages = boolOrDefault(c.Options.UseContainerImages, existing.UseContainerImages)
+	newState.ExcludeAllLibraryVariableSets = boolOrDefault(c.Options.ExcludeAllLibraryVariableSets, existing.ExcludeAllLibraryVariableSets)
+	newState.EnableVariableSpreading = boolOrDefault(c.Options.EnableVariableSpreading, existing.EnableVariableSpreading)
+	newState.EnableProjectRenaming = boolOrDefault(c.Options.EnableProjectRenaming, existing.EnableProjectRenaming)
+	newState.MaxConcurrentRunbooks = intOrDefault(c.Options.MaxConcurrentRunbooks, existing.MaxConcurrentRunbooks)
+	newState.TaskTimeoutMinutes = intOrDefault(c.Options.TaskTimeoutMinutes, existing.TaskTimeoutMinutes)
+	newState.IncludeProjectGroups = listOrDefault(c.Projects.Include.Groups, existing.IncludeProjectGroups)
+	newState.ExcludeProjectGroups = listOrDefault(c.Projects.Exclude.Groups, existing.ExcludeProjectGroups)
+	newState.IncludeProjectNamePattern = valueOrDefault(c.Projects.Include.NamePattern, existing.IncludeProjectNamePattern)
+	newState.ExcludeProjectNamePattern = valueOrDefault(c.Projects.Exclude.NamePattern, existing.ExcludeProjectNamePattern)
+	newState.IncludeProjectIds = listOrDefault(c.Projects.Include.Ids, existing.IncludeProjectIds)
+	newState.ExcludeProjectIds = listOrDefault(c.Projects.Exclude.Ids, existing.ExcludeProjectIds)
+
+	return newState, nil
+}
+
+// ResolveSecret returns the value of a secret, reading it from an environment variable or file if the value is
+// a reference. Any other value is returned as is.
+func ResolveSecret(value string) (string, error) {
+	if strings.HasPrefix(value, EnvPrefix) {
+		name := strings.TrimPrefix(value, EnvPrefix)
+		secret, ok := os.LookupEnv(name)
+
+		if !ok {
+			return "", errors.New("the environment variable " + name + " is not defined")
+		}
+
+		return secret, nil
+	}
+
+	if strings.HasPrefix(value, FilePrefix) {
+		path := strings.TrimPrefix(value, FilePrefix)
+		secret, err := os.ReadFile(path)
+
+		if err != nil {
+			return "", errors.Join(errors.New("failed to read the secret file "+path), err)
+		}
+
+		return strings.TrimSpace(string(secret)), nil
+	}
+
+	return value, nil
+}
+
+func resolveSecretOrDefault(value string, defaultValue string) (string, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	return ResolveSecret(value)
+}
+
+// secretReference returns an environment variable reference for a secret, or an empty string if the secret is
+// not set, so unused secrets do not generate references that fail to resolve
+func secretReference(envVar string, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return EnvPrefix + envVar
+}
+
+func valueOrDefault(value string, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+func intOrDefault(value int, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+func listOrDefault(value []string, defaultValue []string) []string {
+	if len(value) == 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+func boolOrDefault(value *bool, defaultValue bool) bool {
+	if value == nil {
+		return defaultValue
+	}
+
+	return *value
+}
+
+func isJson(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".json"
+}
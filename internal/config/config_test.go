@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Setenv("OCTOTERRAWIZ_SOURCE_API_KEY", "API-SOURCE")
+	t.Setenv("OCTOTERRAWIZ_DESTINATION_API_KEY", "API-DESTINATION")
+	t.Setenv("TF_HTTP_PASSWORD", "http-password")
+	t.Setenv("AZURE_CLIENT_CERTIFICATE_PASSWORD", "certificate-password")
+	t.Setenv("OCTOTERRAWIZ_SECRETS_PASSPHRASE", "secrets-passphrase")
+
+	original := state.State{
+		BackendType:                    "AWS S3",
+		Server:                         "http://source",
+		ApiKey:                         "API-SOURCE",
+		Space:                          "Spaces-1",
+		DestinationServer:              "http://destination",
+		DestinationApiKey:              "API-DESTINATION",
+		DestinationSpace:               "Spaces-2",
+		AwsS3Bucket:                    "bucket",
+		AwsS3BucketRegion:              "us-east-1",
+		AwsS3Endpoint:                  "https://minio.example.com:9000",
+		AwsS3UsePathStyle:              true,
+		AwsCaBundle:                    "/etc/ssl/certs/minio.pem",
+		AwsProfile:                     "migration",
+		AwsRoleArn:                     "arn:aws:iam::123456789012:role/terraform-state",
+		AwsRoleSessionName:             "octoterrawiz",
+		AwsRoleExternalId:              "external-id",
+		AzureAuthType:                  "Certificate",
+		AzureCertificatePath:           "/etc/ssl/private/terraform.pfx",
+		AzureCertificatePassword:       "certificate-password",
+		AzureLocation:                  "australiaeast",
+		CreateBackendStorage:           true,
+		HttpAddress:                    "https://state.example.com",
+		HttpUsername:                   "terraform",
+		HttpPassword:                   "http-password",
+		ExcludeAllLibraryVariableSets:  true,
+		IncludeProjectGroups:           []string{"Websites"},
+		ExcludeProjectNamePattern:      "^Legacy",
+		MaxConcurrentRunbooks:          5,
+		TaskTimeoutMinutes:             30,
+		DatabaseAuthType:               "AzureServicePrincipal",
+		DatabaseInstance:               "SQLEXPRESS",
+		DatabaseEncrypt:                "strict",
+		DatabaseTrustServerCertificate: true,
+		DatabaseAzureTenantId:          "tenant",
+		DatabaseAzureClientId:          "client",
+		SecretsFile:                    "/secure/secrets.json",
+		SecretsPassphrase:              "secrets-passphrase",
+		ContinueOnExtractionError:      true,
+	}
+
+	for _, fileName := range []string{"migration.yaml", "migration.json"} {
+		path := filepath.Join(t.TempDir(), fileName)
+
+		if err := Save(path, FromState(original)); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Contains(string(content), "API-SOURCE") || strings.Contains(string(content), "http-password") || strings.Contains(string(content), "certificate-password") || strings.Contains(string(content), "secrets-passphrase") {
+			t.Fatalf("%s must not contain the plain text API key", fileName)
+		}
+
+		config, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := config.Apply(state.State{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(loaded, original) {
+			t.Fatalf("%s did not round trip the state: %+v", fileName, loaded)
+		}
+	}
+}
+
+func TestApplyKeepsExistingValues(t *testing.T) {
+	config := MigrationConfig{
+		Version: CurrentVersion,
+		Source:  OctopusServerConfig{SpaceId: "Spaces-2"},
+	}
+
+	newState, err := config.Apply(state.State{Server: "http://source", Space: "Spaces-1", PromptForDelete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newState.Server != "http://source" || newState.Space != "Spaces-2" || !newState.PromptForDelete {
+		t.Fatalf("unexpected state %+v", newState)
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("OCTOTERRAWIZ_TEST_SECRET", "from-env")
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"env:OCTOTERRAWIZ_TEST_SECRET": "from-env",
+		"file:" + secretFile:           "from-file",
+		"plain":                        "plain",
+	}
+
+	for input, expected := range tests {
+		value, err := ResolveSecret(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if value != expected {
+			t.Fatalf("expected %s to resolve to %s, got %s", input, expected, value)
+		}
+	}
+
+	if _, err := ResolveSecret("env:OCTOTERRAWIZ_UNDEFINED_SECRET"); err == nil {
+		t.Fatal("expected an error for an undefined environment variable")
+	}
+}
+
+func TestLoadRejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migration.yaml")
+	if err := os.WriteFile(path, []byte("version: 99\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected an error for an unsupported version")
+	}
+}
@@ -0,0 +1,101 @@
+package dependencies
+
+import (
+	"slices"
+	"strings"
+)
+
+// CycleError is returned when the dependencies can not be ordered because they form a cycle
+type CycleError struct {
+	// Nodes lists the nodes that form a cycle, each depending on the next, and the last depending on the first
+	Nodes []string
+	// Blocked lists all the nodes that could not be ordered, which includes the nodes that depend on the cycle
+	Blocked []string
+}
+
+func (e CycleError) Error() string {
+	return "the dependencies between " + strings.Join(e.Nodes, ", ") + " form a cycle"
+}
+
+// Waves sorts the nodes into waves, where every node depends only on nodes in earlier waves. The dependencies map
+// a node to the nodes it depends on. Dependencies on nodes that are not in the list are ignored, as they are assumed
+// to have been processed already. Nodes within a wave keep the order they had in the list.
+// A CycleError is returned if the dependencies form a cycle.
+func Waves(nodes []string, dependencies map[string][]string) ([][]string, error) {
+	remaining := slices.Clone(nodes)
+	complete := map[string]bool{}
+	waves := [][]string{}
+
+	for len(remaining) != 0 {
+		wave := []string{}
+		blocked := []string{}
+
+		for _, node := range remaining {
+			if isReady(node, nodes, dependencies, complete) {
+				wave = append(wave, node)
+			} else {
+				blocked = append(blocked, node)
+			}
+		}
+
+		if len(wave) == 0 {
+			return nil, CycleError{Nodes: findCycle(blocked, nodes, dependencies), Blocked: blocked}
+		}
+
+		for _, node := range wave {
+			complete[node] = true
+		}
+
+		waves = append(waves, wave)
+		remaining = blocked
+	}
+
+	return waves, nil
+}
+
+func isReady(node string, nodes []string, dependencies map[string][]string, complete map[string]bool) bool {
+	for _, dependency := range dependencies[node] {
+		if dependency == node || !slices.Contains(nodes, dependency) {
+			continue
+		}
+
+		if !complete[dependency] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findCycle returns a cycle between the blocked nodes. Every blocked node depends on another blocked node, so
+// following those dependencies from any blocked node must eventually revisit a node, and the nodes visited since
+// the first visit to that node form the cycle.
+func findCycle(blocked []string, nodes []string, dependencies map[string][]string) []string {
+	path := []string{}
+	visited := map[string]int{}
+	node := blocked[0]
+
+	for {
+		if index, ok := visited[node]; ok {
+			return path[index:]
+		}
+
+		visited[node] = len(path)
+		path = append(path, node)
+
+		next := ""
+		for _, dependency := range dependencies[node] {
+			if dependency != node && slices.Contains(nodes, dependency) && slices.Contains(blocked, dependency) {
+				next = dependency
+				break
+			}
+		}
+
+		if next == "" {
+			// Not expected, as a node is only blocked by a dependency that is also blocked
+			return path
+		}
+
+		node = next
+	}
+}
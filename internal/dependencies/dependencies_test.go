@@ -0,0 +1,89 @@
+package dependencies
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWaves(t *testing.T) {
+	waves, err := Waves(
+		[]string{"Orchestrator", "Web", "Release Train", "Api", "Database"},
+		map[string][]string{
+			"Release Train": {"Orchestrator"},
+			"Orchestrator":  {"Web", "Api"},
+			"Web":           {"Database"},
+			// Projects that are not being migrated are ignored
+			"Api": {"Unselected"},
+		})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{{"Api", "Database"}, {"Web"}, {"Orchestrator"}, {"Release Train"}}
+	if !reflect.DeepEqual(waves, expected) {
+		t.Fatalf("expected %v, got %v", expected, waves)
+	}
+}
+
+func TestWavesWithoutDependencies(t *testing.T) {
+	waves, err := Waves([]string{"Web", "Api"}, map[string][]string{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(waves, [][]string{{"Web", "Api"}}) {
+		t.Fatalf("expected a single wave, got %v", waves)
+	}
+}
+
+func TestWavesDetectsCycles(t *testing.T) {
+	_, err := Waves(
+		[]string{"Web", "Api", "Orchestrator", "Database"},
+		map[string][]string{
+			"Web":          {"Api"},
+			"Api":          {"Web"},
+			"Orchestrator": {"Web"},
+		})
+
+	var cycleError CycleError
+	if !errors.As(err, &cycleError) {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(cycleError.Nodes, []string{"Web", "Api"}) {
+		t.Fatalf("unexpected nodes in the cycle error %v", cycleError.Nodes)
+	}
+
+	if !reflect.DeepEqual(cycleError.Blocked, []string{"Web", "Api", "Orchestrator"}) {
+		t.Fatalf("unexpected blocked nodes in the cycle error %v", cycleError.Blocked)
+	}
+}
+
+func TestWavesExcludesDownstreamNodesFromCycles(t *testing.T) {
+	_, err := Waves(
+		[]string{"Release Train", "Orchestrator", "Web", "Api", "Database"},
+		map[string][]string{
+			// The release train and orchestrator depend on the cycle, but are not part of it
+			"Release Train": {"Orchestrator"},
+			"Orchestrator":  {"Web"},
+			"Web":           {"Api"},
+			"Api":           {"Database"},
+			"Database":      {"Web"},
+		})
+
+	var cycleError CycleError
+	if !errors.As(err, &cycleError) {
+		t.Fatalf("expected a cycle error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(cycleError.Nodes, []string{"Web", "Api", "Database"}) {
+		t.Fatalf("unexpected nodes in the cycle error %v", cycleError.Nodes)
+	}
+
+	if err.Error() != "the dependencies between Web, Api, Database form a cycle" {
+		t.Fatalf("unexpected error message %s", err.Error())
+	}
+}
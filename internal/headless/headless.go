@@ -0,0 +1,542 @@
+package headless
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
+	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
+	"github.com/mcasperson/OctoterraWizard/internal/logutil"
+	"github.com/mcasperson/OctoterraWizard/internal/sensitivevariables"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/steps"
+	"github.com/mcasperson/OctoterraWizard/internal/terraformstate"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
+)
+
+// Run performs the same sequence of steps as the wizard without displaying the GUI.
+// Progress is written to stdout, and the first failure is returned as an error.
+// When resume is true, phases and projects recorded as complete in the checkpoint journal are skipped.
+func Run(state state.State, runbookEnvironment string, resume bool) error {
+	state.BackendType = steps.NormalizeBackendType(state.BackendType)
+
+	if err := validate(state); err != nil {
+		return err
+	}
+
+	journal, err := steps.LoadJournal(state)
+
+	if err != nil {
+		return errors.Join(errors.New("failed to load the migration checkpoints"), err)
+	}
+
+	if !resume {
+		if err := journal.Reset(); err != nil {
+			return err
+		}
+	}
+
+	if journal.IsPhaseComplete(checkpoint.PhaseExtractSecrets) {
+		fmt.Println("🔵 Skipping the extraction of sensitive values, which was completed previously.")
+		if state.SecretsFile != "" {
+			fmt.Println("🔵 The sensitive values were saved to " + state.SecretsFile + ". The migrated resources will not have sensitive values unless the file was uploaded with --upload-secrets.")
+		}
+	} else if state.DatabaseServer != "" {
+		if err := extractSecrets(state); err != nil {
+			return err
+		}
+
+		// The runbooks read the sensitive values from Octopus, so the migration stops until the file is uploaded
+		if state.SecretsFile != "" {
+			fmt.Println("🟢 The sensitive values have been saved to " + state.SecretsFile + ". Upload them with --upload-secrets source " +
+				"or --upload-secrets destination, and then run the migration again with --resume to continue.")
+			return nil
+		}
+	} else {
+		fmt.Println("🔵 No database server was defined, so sensitive values will not be extracted.")
+	}
+
+	if state.EnableVariableSpreading {
+		fmt.Println("🔵 Spreading sensitive variables.")
+		spreadStep := steps.SpreadVariablesStep{BaseStep: steps.BaseStep{State: state}}
+		if err := spreadStep.Execute(); err != nil {
+			return errors.Join(errors.New("failed to spread sensitive variables"), err)
+		}
+
+		skippedProjects, err := spreadStep.SkippedProjects()
+		if err != nil {
+			return errors.Join(errors.New("failed to find the version controlled projects that were not spread"), err)
+		}
+
+		for _, skippedProject := range skippedProjects {
+			fmt.Println("🔵 " + skippedProject.String())
+		}
+
+		// The values that can not be spread are only missing if they were not extracted from the database
+		if state.DatabaseServer == "" {
+			skippedValues, err := spreadStep.SkippedValues()
+			if err != nil {
+				return errors.Join(errors.New("failed to find the sensitive values that can not be spread"), err)
+			}
+
+			for _, skippedValue := range skippedValues {
+				fmt.Println("🔵 " + skippedValue.String())
+			}
+		}
+	}
+
+	if journal.IsPhaseComplete(checkpoint.PhaseStepTemplates) {
+		fmt.Println("🔵 Skipping the installation of step templates, which was completed previously.")
+	} else {
+		fmt.Println("🔵 Installing step templates.")
+		if message, err := (steps.StepTemplateStep{BaseStep: steps.BaseStep{State: state}}).Execute(); err != nil {
+			fmt.Println(message)
+			return err
+		}
+	}
+
+	if journal.IsPhaseComplete(checkpoint.PhaseSpaceExport) {
+		fmt.Println("🔵 Skipping the creation of the Octoterra Space Management project, which was completed previously.")
+	} else if err := createSpaceManagementProject(state); err != nil {
+		return err
+	}
+
+	if journal.IsPhaseComplete(checkpoint.PhaseProjectExport) {
+		fmt.Println("🔵 Skipping adding runbooks to projects, which was completed previously.")
+	} else if err := createProjectRunbooks(state); err != nil {
+		return err
+	}
+
+	environment, err := getRunbookEnvironment(state, runbookEnvironment)
+
+	if err != nil {
+		return err
+	}
+
+	if journal.IsPhaseComplete(checkpoint.PhaseStartSpaceExport) {
+		fmt.Println("🔵 Skipping the space runbooks, which completed previously.")
+	} else if err := runSpaceRunbooks(state, environment); err != nil {
+		return err
+	}
+
+	// Projects that were migrated previously are skipped by the step itself
+	if err := runProjectRunbooks(state, environment); err != nil {
+		return err
+	}
+
+	fmt.Println("🟢 The migration completed successfully.")
+	return nil
+}
+
+// validate checks terraform is installed and the server and backend details are correct
+func validate(state state.State) error {
+	fmt.Println("🔵 Testing Terraform installation.")
+	if !(steps.TestTerraformStep{}).Execute() {
+		return errors.New("terraform does not appear to be installed")
+	}
+
+	fmt.Println("🔵 Validating the source server.")
+	if err := validators.ValidateSourceCreds(state); err != nil {
+		return errors.Join(errors.New("failed to validate the source server details"), err)
+	}
+
+	fmt.Println("🔵 Validating the destination server.")
+	if err := validators.ValidateDestinationCreds(state); err != nil {
+		return errors.Join(errors.New("failed to validate the destination server details"), err)
+	}
+
+	if err := validateBackend(state); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Plan validates the settings and prints the changes the migration would make to the source space
+// before any runbooks are created. Nothing is modified.
+func Plan(state state.State) error {
+	state.BackendType = steps.NormalizeBackendType(state.BackendType)
+	// The plan must not modify anything, so missing state storage is reported rather than created
+	state.CreateBackendStorage = false
+
+	if err := validate(state); err != nil {
+		return err
+	}
+
+	report, err := (steps.PlanChangesStep{BaseStep: steps.BaseStep{State: state}}).Execute(func(message string) {
+		fmt.Println(message)
+	})
+
+	if err != nil {
+		return errors.Join(errors.New("failed to generate the change report"), err)
+	}
+
+	fmt.Println(report.String())
+	fmt.Println("🟢 Generated the change report. No changes have been made.")
+	return nil
+}
+
+func validateBackend(state state.State) error {
+	if state.BackendType == steps.AwsS3 {
+		fmt.Println("🔵 Validating the AWS credentials and S3 bucket.")
+		if err := validators.ValidateAWS(state); err != nil {
+			return errors.Join(errors.New("failed to validate the AWS credentials"), err)
+		}
+
+		if state.CreateBackendStorage {
+			exists, err := validators.S3BucketExists(state)
+			if err != nil {
+				return errors.Join(errors.New("failed to check if the S3 bucket "+state.AwsS3Bucket+" exists"), err)
+			}
+
+			if !exists {
+				fmt.Println("🔵 Creating the S3 bucket " + state.AwsS3Bucket + ".")
+				if err := validators.CreateS3Bucket(state); err != nil {
+					return errors.Join(errors.New("failed to create the S3 bucket "+state.AwsS3Bucket), err)
+				}
+			}
+		}
+
+		if err := validators.TestS3Bucket(state); err != nil {
+			return errors.Join(errors.New("failed to connect to the S3 bucket "+state.AwsS3Bucket), err)
+		}
+
+		return nil
+	}
+
+	if state.BackendType == steps.GoogleCloudStorage {
+		fmt.Println("🔵 Validating the Google Cloud credentials and storage bucket.")
+		if err := validators.TestGoogleCloudBucket(state); err != nil {
+			return errors.Join(errors.New("failed to connect to the Google Cloud Storage bucket "+state.GoogleCloudBucket), err)
+		}
+
+		return nil
+	}
+
+	if state.BackendType == steps.HttpBackend {
+		fmt.Println("🔵 Validating the HTTP backend address and credentials.")
+		if err := validators.TestHttpBackend(state); err != nil {
+			return errors.Join(errors.New("failed to connect to the HTTP backend "+state.HttpAddress), err)
+		}
+
+		return nil
+	}
+
+	if state.BackendType == steps.PostgreSQL {
+		fmt.Println("🔵 Validating the PostgreSQL connection string.")
+		if err := validators.TestPostgresBackend(state); err != nil {
+			return errors.Join(errors.New("failed to connect to the PostgreSQL database"), err)
+		}
+
+		return nil
+	}
+
+	if state.CreateBackendStorage {
+		missing, err := validators.MissingAzureResources(state)
+		if err != nil {
+			return errors.Join(errors.New("failed to check if the Azure storage account exists"), err)
+		}
+
+		if len(missing) != 0 {
+			fmt.Println("🔵 Creating the Azure " + strings.Join(missing, ", ") + ".")
+			if err := validators.CreateAzureStorage(state); err != nil {
+				return errors.Join(errors.New("failed to create the Azure storage account"), err)
+			}
+		}
+	}
+
+	fmt.Println("🔵 Validating the Azure credentials and storage account.")
+	exists, err := validators.AzureContainerExists(state)
+
+	if err != nil {
+		return errors.Join(errors.New("failed to validate the Azure credentials"), err)
+	}
+
+	if !exists {
+		return errors.New("failed to find the Azure storage container " + state.AzureContainerName)
+	}
+
+	rgExists, err := validators.AzureResourceGroupExists(state)
+
+	if err != nil {
+		return errors.Join(errors.New("failed to validate the Azure credentials"), err)
+	}
+
+	if !rgExists {
+		return errors.New("failed to find the Azure resource group " + state.AzureResourceGroupName)
+	}
+
+	return nil
+}
+
+func extractSecrets(state state.State) error {
+	fmt.Println("🔵 Extracting sensitive values.")
+
+	var extractError error = nil
+	steps.ExtractSecrets{BaseStep: steps.BaseStep{State: state}}.Execute(
+		func() {},
+		func(result sensitivevariables.Result) {
+			fmt.Println("🟢 Sensitive values have been extracted.")
+			fmt.Print(result.String())
+		},
+		func(err error) {
+			extractError = errors.Join(errors.New("failed to extract the sensitive values"), err)
+		})
+
+	return extractError
+}
+
+// UploadSecrets saves the values in the encrypted secrets file to the library variable set read by the migration
+// runbooks in the source or destination space
+func UploadSecrets(state state.State, target string) error {
+	if state.SecretsFile == "" {
+		return errors.New("the secrets file must be defined to upload the sensitive values")
+	}
+
+	fmt.Println("🔵 Uploading the secrets file " + state.SecretsFile + " to the " + target + " space.")
+	if err := steps.UploadSecretsFile(state, target); err != nil {
+		return errors.Join(errors.New("failed to upload the secrets file"), err)
+	}
+
+	fmt.Println("🟢 The secrets file has been uploaded.")
+	return nil
+}
+
+// UnspreadVariables restores the names and scopes of the sensitive variables spread by a previous migration
+func UnspreadVariables(state state.State) error {
+	fmt.Println("🔵 Restoring the spread sensitive variables.")
+	report, err := (steps.SpreadVariablesStep{BaseStep: steps.BaseStep{State: state}}).ExecuteUnspread()
+
+	if err != nil {
+		return errors.Join(errors.New("failed to restore the spread sensitive variables"), err)
+	}
+
+	fmt.Print(report.String())
+
+	if len(report.Failures()) != 0 {
+		return fmt.Errorf("%d sensitive variables could not be restored", len(report.Failures()))
+	}
+
+	fmt.Println("🟢 The spread sensitive variables have been restored.")
+	return nil
+}
+
+func createSpaceManagementProject(state state.State) error {
+	fmt.Println("🔵 Creating the Octoterra Space Management project.")
+
+	var spaceExportError error = nil
+	steps.SpaceExportStep{BaseStep: steps.BaseStep{State: state}}.Execute(
+		// There is nobody to answer a prompt, so treat every prompt as confirmed
+		func(title string, message string, callback func(bool)) {
+			fmt.Println("🔵 " + message + " Continuing without prompting.")
+			callback(true)
+		},
+		func(message string, err error) {
+			if err := logutil.WriteTextToFile("space_export_error.txt", err.Error()); err != nil {
+				fmt.Println("Failed to write error to file")
+			}
+
+			fmt.Println(message)
+			spaceExportError = errors.Join(spaceExportError, err)
+		},
+		func(message string) {
+			fmt.Println(message)
+		},
+		false,
+		false)
+
+	if spaceExportError != nil {
+		return errors.Join(errors.New("failed to create the Octoterra Space Management project"), spaceExportError)
+	}
+
+	return nil
+}
+
+func createProjectRunbooks(state state.State) error {
+	fmt.Println("🔵 Adding runbooks to projects.")
+
+	var projectExportError error = nil
+	steps.ProjectExportStep{BaseStep: steps.BaseStep{State: state}}.Execute(
+		func(title string, message string, callback func(bool)) {
+			fmt.Println("🔵 " + message + " Continuing without prompting.")
+			callback(true)
+		},
+		func(message string) {
+			fmt.Println(message)
+		},
+		func() {},
+		func() {
+			fmt.Println("🟢 Added runbooks to all projects")
+		},
+		func(message string, err error) {
+			if err := logutil.WriteTextToFile("project_export_error.txt", err.Error()); err != nil {
+				fmt.Println("Failed to write error to file")
+			}
+
+			fmt.Println(message)
+			projectExportError = errors.Join(projectExportError, err)
+		})
+
+	if projectExportError != nil {
+		return errors.Join(errors.New("failed to add runbooks to projects"), projectExportError)
+	}
+
+	return nil
+}
+
+// getRunbookEnvironment returns the environment used to run the runbooks, defaulting to the
+// first environment in the space, which matches the default selected in the wizard
+func getRunbookEnvironment(state state.State, runbookEnvironment string) (string, error) {
+	if runbookEnvironment != "" {
+		return runbookEnvironment, nil
+	}
+
+	environments, err := infrastructure.GetEnvironments(state)
+
+	if err != nil {
+		return "", errors.Join(errors.New("failed to get the environments"), err)
+	}
+
+	if len(environments) == 0 {
+		return "", errors.New("the source space has no environments to run the runbooks in")
+	}
+
+	return environments[0].Name, nil
+}
+
+func runSpaceRunbooks(state state.State, runbookEnvironment string) error {
+	fmt.Println("🔵 Running the space runbooks in the " + runbookEnvironment + " environment.")
+
+	var startSpaceExportError error = nil
+	steps.StartSpaceExportStep{BaseStep: steps.BaseStep{State: state}}.Execute(
+		func(message string) {
+			fmt.Println(message)
+		},
+		nil,
+		func() {},
+		func() {
+			fmt.Println("🟢 Space runbooks ran successfully.")
+		},
+		func(err error) {
+			if err := logutil.WriteTextToFile("start_space_export_error.txt", err.Error()); err != nil {
+				fmt.Println("Failed to write error to file")
+			}
+
+			startSpaceExportError = errors.Join(errors.New("failed to publish and run the space runbooks"), err)
+		},
+		runbookEnvironment)
+
+	return startSpaceExportError
+}
+
+func runProjectRunbooks(state state.State, runbookEnvironment string) error {
+	fmt.Println("🔵 Running the project runbooks in the " + runbookEnvironment + " environment.")
+
+	var startProjectExportError error = nil
+	steps.StartProjectExportStep{BaseStep: steps.BaseStep{State: state}}.Execute(
+		func(message string) {
+			fmt.Println(message)
+		},
+		func() {},
+		func() {
+			fmt.Println("🟢 Project runbooks ran successfully.")
+		},
+		func(err error) {
+			if err == nil {
+				return
+			}
+
+			if err := logutil.WriteTextToFile("start_project_export_error.txt", err.Error()); err != nil {
+				fmt.Println("Failed to write error to file")
+			}
+
+			startProjectExportError = errors.Join(errors.New("failed to publish and run the project runbooks"), err)
+		},
+		runbookEnvironment)
+
+	return startProjectExportError
+}
+
+// Verify compares the source and destination spaces after a migration, prints the differences, and saves
+// the report to the path as HTML, or as JSON if the path has a .json extension.
+func Verify(state state.State, path string) error {
+	report, err := infrastructure.VerifyMigration(state, func(message string) {
+		fmt.Println(message)
+	})
+
+	if err != nil {
+		return errors.Join(errors.New("failed to verify the migration"), err)
+	}
+
+	fmt.Println(report.String())
+
+	file, err := os.Create(path)
+
+	if err != nil {
+		return errors.Join(errors.New("failed to create the verification report "+path), err)
+	}
+
+	defer file.Close()
+
+	if err := report.Write(path, file); err != nil {
+		return errors.Join(errors.New("failed to write the verification report "+path), err)
+	}
+
+	fmt.Println("🟢 Saved the verification report to " + path)
+	return nil
+}
+
+// Terraform state actions supported by ManageState
+const (
+	StateActionList    = "list"
+	StateActionArchive = "archive"
+	StateActionDelete  = "delete"
+)
+
+// ManageState lists the Terraform state saved by previous migrations. The archive and delete actions then archive or
+// delete the state in the workspace of the destination space, so the space can be migrated again from a clean state.
+func ManageState(state state.State, action string) error {
+	if action != StateActionList && action != StateActionArchive && action != StateActionDelete {
+		return errors.New("the Terraform state action must be one of \"" + StateActionList + "\", \"" + StateActionArchive + "\", or \"" + StateActionDelete + "\"")
+	}
+
+	store, err := steps.NewStateStore(state)
+
+	if err != nil {
+		return err
+	}
+
+	objects, err := store.List()
+
+	if err != nil {
+		return errors.Join(errors.New("failed to list the Terraform state"), err)
+	}
+
+	if action == StateActionList {
+		for _, object := range objects {
+			fmt.Println(object.String())
+		}
+
+		fmt.Printf("🟢 Found %d Terraform state files.\n", len(objects))
+		return nil
+	}
+
+	for _, object := range terraformstate.ForWorkspace(objects, state.DestinationSpace) {
+		if action == StateActionArchive {
+			fmt.Println("🔵 Archiving " + object.String())
+			if err := store.Archive(object); err != nil {
+				return err
+			}
+		} else {
+			fmt.Println("🔵 Deleting " + object.String())
+			if err := store.Delete(object); err != nil {
+				return err
+			}
+		}
+	}
+
+	fmt.Println("🟢 Cleaned the Terraform state of the destination space " + state.DestinationSpace + ".")
+	return nil
+}
@@ -0,0 +1,372 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/client"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
+	"github.com/mcasperson/OctoterraWizard/internal/sensitivevariables"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/verification"
+	"github.com/samber/lo"
+)
+
+// wizardResources are the resources created in the source space by the wizard to perform the migration. They are
+// not migrated, so they are excluded from the verification report.
+var wizardResources = map[string][]string{
+	verification.KindProject:            {"Octoterra Space Management"},
+	verification.KindLibraryVariableSet: {"Octoterra", sensitivevariables.SecretsLibraryVariableSetName},
+	verification.KindFeed:               {"Octoterra Docker Feed"},
+	verification.KindAccount:            {"Octoterra AWS Account", "Octoterra Azure Account", "Octoterra Google Cloud Account"},
+	verification.KindRunbook:            {"__ 1. Serialize Project", "__ 2. Deploy Project"},
+	verification.KindVariable:           {"OctoterraWiz.Destination.ProjectName"},
+}
+
+func isWizardResource(kind string, name string) bool {
+	return slices.Contains(wizardResources[kind], name)
+}
+
+// VerifyMigration inventories the source and destination spaces and compares them. Only the projects selected
+// for the migration are inventoried in the source space.
+func VerifyMigration(state state.State, statusCallback func(message string)) (verification.Report, error) {
+	sourceClient, err := octoclient.CreateClient(state)
+
+	if err != nil {
+		return verification.Report{}, errors.Join(errors.New("failed to create the source client"), err)
+	}
+
+	destinationClient, err := octoclient.CreateDestinationClient(state)
+
+	if err != nil {
+		return verification.Report{}, errors.Join(errors.New("failed to create the destination client"), err)
+	}
+
+	statusCallback("🔵 Reading the resources in the source space")
+
+	sourceProjects, err := GetSelectedProjects(sourceClient, state)
+
+	if err != nil {
+		return verification.Report{}, err
+	}
+
+	source, err := getInventory(sourceClient, sourceProjects, !state.ExcludeAllLibraryVariableSets, state.EnableProjectRenaming)
+
+	if err != nil {
+		return verification.Report{}, errors.Join(errors.New("failed to read the resources in the source space"), err)
+	}
+
+	statusCallback("🔵 Reading the resources in the destination space")
+
+	destinationProjects, err := destinationClient.Projects.GetAll()
+
+	if err != nil {
+		return verification.Report{}, errors.Join(errors.New("failed to get all projects in the destination space"), err)
+	}
+
+	destination, err := getInventory(destinationClient, destinationProjects, true, false)
+
+	if err != nil {
+		return verification.Report{}, errors.Join(errors.New("failed to read the resources in the destination space"), err)
+	}
+
+	return verification.Compare(state.Space, source, state.DestinationSpace, destination), nil
+}
+
+// getInventory reads the resources in a space. Properties that reference other resources are described by name,
+// as IDs are different in each space. When renameProjects is true, projects are given the name defined by the
+// OctoterraWiz.Destination.ProjectName variable.
+func getInventory(myclient *client.Client, allProjects []*projects.Project, includeLibraryVariableSets bool, renameProjects bool) (*verification.Inventory, error) {
+	inventory := verification.NewInventory()
+	add := func(kind string, name string, properties map[string]string) {
+		if !isWizardResource(kind, name) {
+			inventory.Add(kind, name, properties)
+		}
+	}
+
+	allEnvironments, err := myclient.Environments.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all environments"), err)
+	}
+
+	environmentNames := map[string]string{}
+	for _, environment := range allEnvironments {
+		environmentNames[environment.ID] = environment.Name
+		add(verification.KindEnvironment, environment.Name, map[string]string{
+			"Use Guided Failure":           fmt.Sprint(environment.UseGuidedFailure),
+			"Allow Dynamic Infrastructure": fmt.Sprint(environment.AllowDynamicInfrastructure),
+		})
+	}
+
+	allLifecycles, err := myclient.Lifecycles.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all lifecycles"), err)
+	}
+
+	lifecycleNames := map[string]string{}
+	for _, lifecycle := range allLifecycles {
+		lifecycleNames[lifecycle.ID] = lifecycle.Name
+		phaseNames := []string{}
+		for _, phase := range lifecycle.Phases {
+			phaseNames = append(phaseNames, phase.Name)
+		}
+		add(verification.KindLifecycle, lifecycle.Name, map[string]string{
+			"Phases": strings.Join(phaseNames, ", "),
+		})
+	}
+
+	allFeeds, err := myclient.Feeds.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all feeds"), err)
+	}
+
+	for _, feed := range allFeeds {
+		add(verification.KindFeed, feed.GetName(), map[string]string{
+			"Type": string(feed.GetFeedType()),
+		})
+	}
+
+	allAccounts, err := myclient.Accounts.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all accounts"), err)
+	}
+
+	for _, account := range allAccounts {
+		add(verification.KindAccount, account.GetName(), map[string]string{
+			"Type": string(account.GetAccountType()),
+		})
+	}
+
+	allMachines, err := myclient.Machines.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all targets"), err)
+	}
+
+	machineNames := map[string]string{}
+	for _, machine := range allMachines {
+		machineNames[machine.ID] = machine.Name
+		add(verification.KindTarget, machine.Name, map[string]string{
+			"Environments": joinNames(machine.EnvironmentIDs, environmentNames),
+			"Roles":        joinSorted(machine.Roles),
+			"Disabled":     fmt.Sprint(machine.IsDisabled),
+		})
+	}
+
+	allProjectGroups, err := myclient.ProjectGroups.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all project groups"), err)
+	}
+
+	projectGroupNames := map[string]string{}
+	for _, projectGroup := range allProjectGroups {
+		projectGroupNames[projectGroup.ID] = projectGroup.Name
+	}
+
+	allChannels, err := myclient.Channels.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all channels"), err)
+	}
+
+	channelNames := map[string]string{}
+	for _, channel := range allChannels {
+		channelNames[channel.ID] = channel.Name
+	}
+
+	allRunbooks, err := myclient.Runbooks.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all runbooks"), err)
+	}
+
+	allProjects = lo.Filter(allProjects, func(item *projects.Project, index int) bool {
+		return !isWizardResource(verification.KindProject, item.Name)
+	})
+
+	// Process owners are runbooks or projects
+	projectNames := map[string]string{}
+	processOwnerNames := map[string]string{}
+	projectVariableSets := map[string]*variables.VariableSet{}
+	for _, project := range allProjects {
+		variableSet, err := variables.GetVariableSet(myclient, myclient.GetSpaceID(), project.VariableSetID)
+
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to get the variables for project "+project.Name), err)
+		}
+
+		projectVariableSets[project.ID] = variableSet
+		projectNames[project.ID] = destinationProjectName(project, variableSet, renameProjects)
+		processOwnerNames[project.ID] = projectNames[project.ID]
+
+		add(verification.KindProject, projectNames[project.ID], map[string]string{
+			"Project Group": projectGroupNames[project.ProjectGroupID],
+			"Lifecycle":     lifecycleNames[project.LifecycleID],
+			"Disabled":      fmt.Sprint(project.IsDisabled),
+		})
+	}
+
+	for _, runbook := range allRunbooks {
+		projectName, ok := projectNames[runbook.ProjectID]
+
+		// Runbooks in projects that were not migrated are ignored
+		if !ok {
+			continue
+		}
+
+		processOwnerNames[runbook.ID] = runbook.Name
+		if !isWizardResource(verification.KindRunbook, runbook.Name) {
+			add(verification.KindRunbook, projectName+": "+runbook.Name, nil)
+		}
+	}
+
+	allTenants, err := myclient.Tenants.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all tenants"), err)
+	}
+
+	for _, tenant := range allTenants {
+		add(verification.KindTenant, tenant.Name, map[string]string{
+			"Tags":     joinSorted(tenant.TenantTags),
+			"Projects": joinNames(lo.Keys(tenant.ProjectEnvironments), projectNames),
+		})
+	}
+
+	names := scopeNames{
+		environments:  environmentNames,
+		machines:      machineNames,
+		channels:      channelNames,
+		processOwners: processOwnerNames,
+	}
+
+	for _, project := range allProjects {
+		addVariables(add, projectNames[project.ID], projectVariableSets[project.ID], names)
+	}
+
+	if includeLibraryVariableSets {
+		libraryVariableSets, err := myclient.LibraryVariableSets.GetAll()
+
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to get all library variable sets"), err)
+		}
+
+		for _, libraryVariableSet := range libraryVariableSets {
+			if isWizardResource(verification.KindLibraryVariableSet, libraryVariableSet.Name) {
+				continue
+			}
+
+			add(verification.KindLibraryVariableSet, libraryVariableSet.Name, nil)
+
+			variableSet, err := variables.GetVariableSet(myclient, myclient.GetSpaceID(), libraryVariableSet.VariableSetID)
+
+			if err != nil {
+				return nil, errors.Join(errors.New("failed to get the variables for library variable set "+libraryVariableSet.Name), err)
+			}
+
+			addVariables(add, libraryVariableSet.Name, variableSet, names)
+		}
+	}
+
+	return inventory, nil
+}
+
+// destinationProjectName returns the name the project is expected to have in the destination space
+func destinationProjectName(project *projects.Project, variableSet *variables.VariableSet, renameProjects bool) string {
+	if !renameProjects {
+		return project.Name
+	}
+
+	for _, variable := range variableSet.Variables {
+		if variable.Name == "OctoterraWiz.Destination.ProjectName" && variable.Value != nil && strings.TrimSpace(*variable.Value) != "" {
+			return strings.TrimSpace(*variable.Value)
+		}
+	}
+
+	return project.Name
+}
+
+// scopeNames maps the IDs referenced by variable scopes to names
+type scopeNames struct {
+	environments  map[string]string
+	machines      map[string]string
+	channels      map[string]string
+	processOwners map[string]string
+}
+
+// addVariables adds the variables in a variable set, identified by their owner, name and scope. The values of
+// sensitive variables are not returned by the API, so only their type is compared.
+func addVariables(add func(kind string, name string, properties map[string]string), owner string, variableSet *variables.VariableSet, names scopeNames) {
+	if variableSet == nil {
+		return
+	}
+
+	for _, variable := range variableSet.Variables {
+		if isWizardResource(verification.KindVariable, variable.Name) {
+			continue
+		}
+
+		properties := map[string]string{
+			"Type": variable.Type,
+		}
+
+		if !variable.IsSensitive && variable.Value != nil {
+			properties["Value"] = *variable.Value
+		}
+
+		add(verification.KindVariable, owner+": "+variable.Name+variableScope(variable.Scope, names), properties)
+	}
+}
+
+// variableScope describes a variable scope by name. Steps are identified by ID in the scope, and step IDs
+// are not retained by the migration, so only the number of scoped steps is described.
+func variableScope(scope variables.VariableScope, names scopeNames) string {
+	if scope.IsEmpty() {
+		return ""
+	}
+
+	parts := []string{}
+	addPart := func(name string, values string) {
+		if values != "" {
+			parts = append(parts, name+": "+values)
+		}
+	}
+
+	addPart("Environments", joinNames(scope.Environments, names.environments))
+	addPart("Targets", joinNames(scope.Machines, names.machines))
+	addPart("Roles", joinSorted(scope.Roles))
+	addPart("Channels", joinNames(scope.Channels, names.channels))
+	addPart("Processes", joinNames(scope.ProcessOwners, names.processOwners))
+	addPart("Tenant Tags", joinSorted(scope.TenantTags))
+
+	if len(scope.Actions) != 0 {
+		addPart("Steps", fmt.Sprint(len(scope.Actions)))
+	}
+
+	return " [" + strings.Join(parts, "; ") + "]"
+}
+
+// joinNames maps the IDs to names, falling back to the ID for unknown resources, and joins them in sorted order
+func joinNames(ids []string, names map[string]string) string {
+	return joinSorted(lo.Map(ids, func(item string, index int) string {
+		if name, ok := names[item]; ok {
+			return name
+		}
+		return item
+	}))
+}
+
+func joinSorted(values []string) string {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return strings.Join(sorted, ", ")
+}
@@ -8,17 +8,22 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/client"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/environments"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/feeds"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projectgroups"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/runbooks"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/tasks"
 	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
 	"github.com/mcasperson/OctoterraWizard/internal/octoerrors"
+	"github.com/mcasperson/OctoterraWizard/internal/projectfilter"
 	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/taskmonitor"
 	"github.com/samber/lo"
 )
 
@@ -34,56 +39,166 @@ func GetEnvironments(state state.State) ([]*environments.Environment, error) {
 	return environments.GetAll(myclient, myclient.GetSpaceID())
 }
 
+// DefaultTaskTimeout is the time to wait for a task to complete when no timeout is defined
+const DefaultTaskTimeout = 2 * time.Hour
+
+var taskMonitors = map[string]*taskmonitor.Monitor{}
+var taskMonitorsMutex sync.Mutex
+
+// WaitForTask waits for the task to complete, calling statusCallback each time the task state changes.
+// Concurrent calls share a task monitor, so the state of all the tasks is queried in a single request.
 func WaitForTask(state state.State, taskId string, statusCallback func(message string)) error {
-	myclient, err := octoclient.CreateClient(state)
+	monitor, err := getTaskMonitor(state)
 
 	if err != nil {
 		return err
 	}
 
-	// wait up to 2 hours for the task to complete
-	for i := 0; i < 7200; i++ {
+	status, err := monitor.Wait(taskId, statusCallback)
+
+	if errors.Is(err, taskmonitor.ErrTaskNotFound) {
+		return octoerrors.TaskNotFound{TaskId: taskId}
+	}
+
+	if errors.Is(err, taskmonitor.ErrTimeout) {
+		return octoerrors.TaskDidNotCompleteError{TaskId: taskId}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if status.State != "Success" {
+		return octoerrors.TaskFailedError{TaskId: taskId, Log: getTaskLogTail(state, taskId)}
+	}
+
+	return nil
+}
+
+// TaskLogLines is the number of lines from the end of a task log that are displayed
+const TaskLogLines = 100
+
+// TaskLogInterval is the time between reading the log of a task that is being tailed
+const TaskLogInterval = 10 * time.Second
+
+// WaitForTaskWithLog waits for the task to complete like WaitForTask, and also passes the end of the task log to
+// logCallback while the task is running
+func WaitForTaskWithLog(state state.State, taskId string, statusCallback func(message string), logCallback func(log string)) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			if log := getTaskLogTail(state, taskId); log != "" {
+				logCallback(log)
+			}
+
+			select {
+			case <-done:
+				return
+			case <-time.After(TaskLogInterval):
+			}
+		}
+	}()
+
+	return WaitForTask(state, taskId, statusCallback)
+}
+
+// GetTaskLog returns the raw log of a task
+func GetTaskLog(state state.State, taskId string) (string, error) {
+	myclient, err := octoclient.CreateClient(state)
+
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("GET", state.GetExternalServer()+"/api/"+state.Space+"/tasks/"+taskId+"/raw", nil)
+
+	if err != nil {
+		return "", errors.Join(errors.New("failed to create the task log request"), err)
+	}
+
+	res, err := myclient.HttpSession().DoRawRequest(req)
+
+	if err != nil {
+		return "", errors.Join(errors.New("failed to get the log for task "+taskId), err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return "", errors.Join(errors.New("failed to read the log for task "+taskId), err)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", errors.New("failed to get the log for task " + taskId + ": " + string(body))
+	}
+
+	return string(body), nil
+}
+
+// getTaskLogTail returns the end of a task log. The log is only used to explain a failure, so
+// any error reading it is printed and an empty string is returned.
+func getTaskLogTail(state state.State, taskId string) string {
+	log, err := GetTaskLog(state, taskId)
+
+	if err != nil {
+		fmt.Println(err.Error())
+		return ""
+	}
+
+	return strutil.TailLines(log, TaskLogLines)
+}
+
+// getTaskMonitor returns the task monitor shared by all tasks in the source space
+func getTaskMonitor(state state.State) (*taskmonitor.Monitor, error) {
+	timeout := DefaultTaskTimeout
+	if state.TaskTimeoutMinutes > 0 {
+		timeout = time.Duration(state.TaskTimeoutMinutes) * time.Minute
+	}
+
+	key := state.Server + "|" + state.ApiKey + "|" + state.Space + "|" + timeout.String()
+
+	taskMonitorsMutex.Lock()
+	defer taskMonitorsMutex.Unlock()
+
+	if monitor, ok := taskMonitors[key]; ok {
+		return monitor, nil
+	}
+
+	myclient, err := octoclient.CreateClient(state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	monitor := taskmonitor.New(func(taskIds []string) (map[string]taskmonitor.Status, error) {
 		mytasks, err := myclient.Tasks.Get(tasks.TasksQuery{
-			Environment:             "",
-			HasPendingInterruptions: false,
-			HasWarningsOrErrors:     false,
-			IDs:                     []string{taskId},
-			IncludeSystem:           false,
-			IsActive:                false,
-			IsRunning:               false,
-			Name:                    "",
-			Node:                    "",
-			PartialName:             "",
-			Project:                 "",
-			Runbook:                 "",
-			Skip:                    0,
-			Spaces:                  nil,
-			States:                  nil,
-			Take:                    1,
-			Tenant:                  "",
+			IDs:  taskIds,
+			Skip: 0,
+			Take: len(taskIds),
 		})
 
 		if err != nil {
-			return err
-		}
-
-		if len(mytasks.Items) == 0 {
-			return octoerrors.TaskNotFound{TaskId: taskId}
+			return nil, err
 		}
 
-		if mytasks.Items[0].IsCompleted != nil && *mytasks.Items[0].IsCompleted {
-			if mytasks.Items[0].State != "Success" {
-				return octoerrors.TaskFailedError{TaskId: taskId}
+		statuses := map[string]taskmonitor.Status{}
+		for _, task := range mytasks.Items {
+			statuses[task.ID] = taskmonitor.Status{
+				State:       task.State,
+				IsCompleted: task.IsCompleted != nil && *task.IsCompleted,
 			}
-			statusCallback(mytasks.Items[0].State)
-			return nil
-		} else {
-			statusCallback(mytasks.Items[0].State)
-			time.Sleep(10 * time.Second)
 		}
-	}
 
-	return octoerrors.TaskDidNotCompleteError{TaskId: taskId}
+		return statuses, nil
+	}, timeout)
+
+	taskMonitors[key] = monitor
+
+	return monitor, nil
 }
 
 func RunRunbook(state state.State, runbookName string, projectName string, environmentName string) (string, error) {
@@ -430,3 +545,59 @@ func GetProjects(myclient *client.Client) ([]*projects.Project, error) {
 		return filteredProjects, nil
 	}
 }
+
+// ProjectRules returns the project selection rules defined in the state
+func ProjectRules(state state.State) projectfilter.Rules {
+	return projectfilter.Rules{
+		IncludeGroups:      state.IncludeProjectGroups,
+		ExcludeGroups:      state.ExcludeProjectGroups,
+		IncludeNamePattern: state.IncludeProjectNamePattern,
+		ExcludeNamePattern: state.ExcludeProjectNamePattern,
+		IncludeIds:         state.IncludeProjectIds,
+		ExcludeIds:         state.ExcludeProjectIds,
+	}
+}
+
+// GetSelectedProjects gets the projects returned by GetProjects that are selected by the project
+// selection rules defined in the state.
+func GetSelectedProjects(myclient *client.Client, state state.State) ([]*projects.Project, error) {
+	allProjects, err := GetProjects(myclient)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rules := ProjectRules(state)
+
+	if rules.IsEmpty() {
+		return allProjects, nil
+	}
+
+	filter, err := projectfilter.New(rules)
+
+	if err != nil {
+		return nil, err
+	}
+
+	allProjectGroups, err := myclient.ProjectGroups.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all project groups"), err)
+	}
+
+	return lo.Filter(allProjects, func(item *projects.Project, index int) bool {
+		groupName := ""
+		if group, ok := lo.Find(allProjectGroups, func(group *projectgroups.ProjectGroup) bool {
+			return group.ID == item.ProjectGroupID
+		}); ok {
+			groupName = group.Name
+		}
+
+		return filter.IsSelected(projectfilter.Project{
+			Id:        item.ID,
+			Name:      item.Name,
+			GroupId:   item.ProjectGroupID,
+			GroupName: groupName,
+		})
+	}), nil
+}
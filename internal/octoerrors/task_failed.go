@@ -4,8 +4,14 @@ import "fmt"
 
 type TaskFailedError struct {
 	TaskId string
+	// Log holds the end of the task log, if it could be read
+	Log string
 }
 
 func (e TaskFailedError) Error() string {
+	if e.Log != "" {
+		return fmt.Sprintf("Task %s failed:\n%s", e.TaskId, e.Log)
+	}
+
 	return fmt.Sprintf("Task %s failed", e.TaskId)
 }
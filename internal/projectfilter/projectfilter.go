@@ -0,0 +1,128 @@
+package projectfilter
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Project holds the details of a project that the rules are matched against
+type Project struct {
+	Id        string
+	Name      string
+	GroupId   string
+	GroupName string
+}
+
+// Rules define the projects selected for migration. A project is selected if it matches any include rule
+// (or there are no include rules), and does not match any exclude rule. Groups can be matched by name or ID.
+type Rules struct {
+	IncludeGroups      []string
+	ExcludeGroups      []string
+	IncludeNamePattern string
+	ExcludeNamePattern string
+	IncludeIds         []string
+	ExcludeIds         []string
+}
+
+// Filter is a compiled set of rules
+type Filter struct {
+	rules       Rules
+	includeName *regexp.Regexp
+	excludeName *regexp.Regexp
+}
+
+// IsEmpty returns true if the rules select every project
+func (r Rules) IsEmpty() bool {
+	return len(cleanList(r.IncludeGroups)) == 0 &&
+		len(cleanList(r.ExcludeGroups)) == 0 &&
+		strings.TrimSpace(r.IncludeNamePattern) == "" &&
+		strings.TrimSpace(r.ExcludeNamePattern) == "" &&
+		len(cleanList(r.IncludeIds)) == 0 &&
+		len(cleanList(r.ExcludeIds)) == 0
+}
+
+// New compiles the rules, returning an error if a name pattern is not a valid regular expression
+func New(rules Rules) (*Filter, error) {
+	filter := &Filter{
+		rules: Rules{
+			IncludeGroups: cleanList(rules.IncludeGroups),
+			ExcludeGroups: cleanList(rules.ExcludeGroups),
+			IncludeIds:    cleanList(rules.IncludeIds),
+			ExcludeIds:    cleanList(rules.ExcludeIds),
+		},
+	}
+
+	if pattern := strings.TrimSpace(rules.IncludeNamePattern); pattern != "" {
+		includeName, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return nil, errors.Join(errors.New("the include project name pattern "+pattern+" is not a valid regular expression"), err)
+		}
+
+		filter.includeName = includeName
+	}
+
+	if pattern := strings.TrimSpace(rules.ExcludeNamePattern); pattern != "" {
+		excludeName, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return nil, errors.Join(errors.New("the exclude project name pattern "+pattern+" is not a valid regular expression"), err)
+		}
+
+		filter.excludeName = excludeName
+	}
+
+	return filter, nil
+}
+
+// IsSelected returns true if the project is selected by the rules
+func (f *Filter) IsSelected(project Project) bool {
+	if f.matchesExclude(project) {
+		return false
+	}
+
+	if len(f.rules.IncludeGroups) == 0 && len(f.rules.IncludeIds) == 0 && f.includeName == nil {
+		return true
+	}
+
+	return f.matchesInclude(project)
+}
+
+func (f *Filter) matchesInclude(project Project) bool {
+	return matchesGroup(f.rules.IncludeGroups, project) ||
+		lo.Contains(f.rules.IncludeIds, project.Id) ||
+		(f.includeName != nil && f.includeName.MatchString(project.Name))
+}
+
+func (f *Filter) matchesExclude(project Project) bool {
+	return matchesGroup(f.rules.ExcludeGroups, project) ||
+		lo.Contains(f.rules.ExcludeIds, project.Id) ||
+		(f.excludeName != nil && f.excludeName.MatchString(project.Name))
+}
+
+func matchesGroup(groups []string, project Project) bool {
+	return lo.ContainsBy(groups, func(group string) bool {
+		return group == project.GroupId || strings.EqualFold(group, project.GroupName)
+	})
+}
+
+// SplitList splits a comma separated list, as used by environment variables and the wizard, into its items
+func SplitList(list string) []string {
+	return cleanList(strings.Split(list, ","))
+}
+
+// JoinList joins items into a comma separated list
+func JoinList(items []string) string {
+	return strings.Join(cleanList(items), ", ")
+}
+
+func cleanList(items []string) []string {
+	return lo.Filter(lo.Map(items, func(item string, index int) string {
+		return strings.TrimSpace(item)
+	}), func(item string, index int) bool {
+		return item != ""
+	})
+}
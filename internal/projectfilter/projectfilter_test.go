@@ -0,0 +1,65 @@
+package projectfilter
+
+import (
+	"testing"
+)
+
+var webApp = Project{Id: "Projects-1", Name: "Web App", GroupId: "ProjectGroups-1", GroupName: "Websites"}
+var api = Project{Id: "Projects-2", Name: "Orders API", GroupId: "ProjectGroups-2", GroupName: "Services"}
+var legacyApi = Project{Id: "Projects-3", Name: "Legacy API", GroupId: "ProjectGroups-2", GroupName: "Services"}
+
+func TestIsSelected(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    Rules
+		selected []Project
+	}{
+		{"no rules", Rules{}, []Project{webApp, api, legacyApi}},
+		{"include group by name", Rules{IncludeGroups: []string{"services"}}, []Project{api, legacyApi}},
+		{"include group by id", Rules{IncludeGroups: []string{"ProjectGroups-1"}}, []Project{webApp}},
+		{"include name", Rules{IncludeNamePattern: "API$"}, []Project{api, legacyApi}},
+		{"include id", Rules{IncludeIds: []string{" Projects-3 "}}, []Project{legacyApi}},
+		{"include rules are combined", Rules{IncludeIds: []string{"Projects-1"}, IncludeNamePattern: "^Orders"}, []Project{webApp, api}},
+		{"exclude name", Rules{ExcludeNamePattern: "^Legacy"}, []Project{webApp, api}},
+		{"exclude wins over include", Rules{IncludeGroups: []string{"Services"}, ExcludeIds: []string{"Projects-3"}}, []Project{api}},
+		{"exclude group", Rules{ExcludeGroups: []string{"Websites"}}, []Project{api, legacyApi}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := New(test.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, project := range []Project{webApp, api, legacyApi} {
+				expected := false
+				for _, selected := range test.selected {
+					expected = expected || selected == project
+				}
+
+				if filter.IsSelected(project) != expected {
+					t.Fatalf("expected %s to be selected: %t", project.Name, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidPattern(t *testing.T) {
+	if _, err := New(Rules{IncludeNamePattern: "("}); err == nil {
+		t.Fatal("an invalid regular expression must be rejected")
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	items := SplitList(" Websites, ,Services ")
+
+	if len(items) != 2 || items[0] != "Websites" || items[1] != "Services" {
+		t.Fatalf("unexpected items %v", items)
+	}
+
+	if JoinList(items) != "Websites, Services" {
+		t.Fatalf("unexpected list %s", JoinList(items))
+	}
+}
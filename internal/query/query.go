@@ -22,6 +22,9 @@ type CommunityStepTemplate struct {
 	Website string `json:"Website"`
 }
 
+// ErrStepTemplateNotFound is returned by GetStepTemplateId when the step templates were read, but none has the name
+var ErrStepTemplateNotFound = errors.New("could not find the step template - make sure you have run the \"Install Step Templates\" step")
+
 type StepTemplates struct {
 	Items []StepTemplate `json:"Items"`
 }
@@ -55,6 +58,10 @@ func GetStepTemplateId(myclient *client.Client, state state.State, name string)
 		return "", err, "🔴 Failed to get the step templates"
 	}
 
+	if response.StatusCode != http.StatusOK {
+		return "", errors.New("the step templates request returned the status " + response.Status), "🔴 Failed to get the step templates"
+	}
+
 	responseBody, err := io.ReadAll(response.Body)
 
 	if err != nil {
@@ -75,7 +82,7 @@ func GetStepTemplateId(myclient *client.Client, state state.State, name string)
 	})
 
 	if len(filteredStepTemplates) == 0 {
-		return "", errors.New("could not find the step template - make sure you have run the \"Install Step Templates\" step"),
+		return "", ErrStepTemplateNotFound,
 			"🔴 Failed to find the step template called " + name
 	}
 
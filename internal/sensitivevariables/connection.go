@@ -0,0 +1,183 @@
+package sensitivevariables
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/microsoft/go-mssqldb/azuread"
+)
+
+// The methods used to authenticate with the Octopus database
+const (
+	DatabaseAuthSqlServer             = "SqlServer"
+	DatabaseAuthWindows               = "Windows"
+	DatabaseAuthAzureServicePrincipal = "AzureServicePrincipal"
+	DatabaseAuthAzureManagedIdentity  = "AzureManagedIdentity"
+	DatabaseAuthAzureInteractive      = "AzureInteractive"
+)
+
+var DatabaseAuthTypes = []string{DatabaseAuthSqlServer, DatabaseAuthWindows, DatabaseAuthAzureServicePrincipal,
+	DatabaseAuthAzureManagedIdentity, DatabaseAuthAzureInteractive}
+
+// The encryption modes supported by the SQL Server driver. An empty value uses the driver default.
+const (
+	DatabaseEncryptDefault  = ""
+	DatabaseEncryptRequired = "true"
+	DatabaseEncryptOptional = "false"
+	DatabaseEncryptStrict   = "strict"
+	DatabaseEncryptDisabled = "disable"
+)
+
+var DatabaseEncryptModes = []string{DatabaseEncryptDefault, DatabaseEncryptRequired, DatabaseEncryptOptional,
+	DatabaseEncryptStrict, DatabaseEncryptDisabled}
+
+// DatabaseAuthType returns the authentication method saved in the state, defaulting to a SQL Server login
+// for states created before other methods were supported.
+func DatabaseAuthType(state state.State) string {
+	for _, authType := range DatabaseAuthTypes {
+		if strings.EqualFold(strings.TrimSpace(state.DatabaseAuthType), authType) {
+			return authType
+		}
+	}
+
+	return DatabaseAuthSqlServer
+}
+
+// Connection holds the details used to connect to the Octopus database
+type Connection struct {
+	Server string
+	// Port is optional when connecting to a named instance, as the port is then resolved by the SQL Server Browser
+	Port     string
+	Instance string
+	Database string
+	AuthType string
+	// Username is the SQL Server login, the DOMAIN\user Windows account, or the login hint for interactive
+	// Azure AD authentication
+	Username string
+	// Password is the password of the login or Windows account, or the client secret of the service principal
+	Password               string
+	Encrypt                string
+	TrustServerCertificate bool
+	AzureTenantId          string
+	// AzureClientId is the service principal, the user-assigned managed identity, or the application used
+	// for interactive authentication
+	AzureClientId string
+}
+
+// ConnectionFromState returns the database connection details saved in the state
+func ConnectionFromState(state state.State) Connection {
+	return Connection{
+		Server:                 strings.TrimSpace(state.DatabaseServer),
+		Port:                   strings.TrimSpace(state.DatabasePort),
+		Instance:               strings.TrimSpace(state.DatabaseInstance),
+		Database:               strings.TrimSpace(state.DatabaseName),
+		AuthType:               DatabaseAuthType(state),
+		Username:               strings.TrimSpace(state.DatabaseUser),
+		Password:               state.DatabasePass,
+		Encrypt:                strings.TrimSpace(state.DatabaseEncrypt),
+		TrustServerCertificate: state.DatabaseTrustServerCertificate,
+		AzureTenantId:          strings.TrimSpace(state.DatabaseAzureTenantId),
+		AzureClientId:          strings.TrimSpace(state.DatabaseAzureClientId),
+	}
+}
+
+// IsAzureAd returns true if the connection authenticates with Azure AD
+func (c Connection) IsAzureAd() bool {
+	return c.AuthType == DatabaseAuthAzureServicePrincipal ||
+		c.AuthType == DatabaseAuthAzureManagedIdentity ||
+		c.AuthType == DatabaseAuthAzureInteractive
+}
+
+// driverName returns the name of the database/sql driver that supports the authentication method
+func (c Connection) driverName() string {
+	if c.IsAzureAd() {
+		return azuread.DriverName
+	}
+
+	return "sqlserver"
+}
+
+// dsn builds the sqlserver:// connection string understood by the SQL Server driver
+func (c Connection) dsn() (string, error) {
+	if c.Server == "" {
+		return "", errors.New("the database server is required")
+	}
+
+	host := c.Server
+	if c.Port != "" {
+		portNum, err := strconv.Atoi(c.Port)
+		if err != nil {
+			return "", errors.Join(errors.New("the database port "+c.Port+" is not a number"), err)
+		}
+
+		host = net.JoinHostPort(c.Server, strconv.Itoa(portNum))
+	} else if c.Instance == "" {
+		return "", errors.New("the database port is required when no named instance is defined")
+	}
+
+	query := url.Values{}
+	query.Set("database", c.Database)
+
+	if c.Encrypt != DatabaseEncryptDefault {
+		query.Set("encrypt", c.Encrypt)
+	}
+
+	if c.TrustServerCertificate {
+		query.Set("TrustServerCertificate", "true")
+	}
+
+	dsn := url.URL{
+		Scheme: "sqlserver",
+		Host:   host,
+		Path:   c.Instance,
+	}
+
+	switch c.AuthType {
+	case DatabaseAuthWindows:
+		// An empty username uses the account running the wizard, which is only supported by SSPI on Windows.
+		// Otherwise, the DOMAIN\user account is authenticated with NTLM.
+		if c.Username == "" {
+			if runtime.GOOS != "windows" {
+				return "", errors.New("a DOMAIN\\user username is required for Windows authentication on " + runtime.GOOS)
+			}
+		} else if !strings.Contains(c.Username, "\\") {
+			return "", errors.New("the username must be in the format DOMAIN\\user for Windows authentication")
+		} else {
+			dsn.User = url.UserPassword(c.Username, c.Password)
+		}
+	case DatabaseAuthAzureServicePrincipal:
+		if c.AzureTenantId == "" || c.AzureClientId == "" || c.Password == "" {
+			return "", errors.New("the tenant ID, client ID, and client secret are required for service principal authentication")
+		}
+
+		query.Set("fedauth", azuread.ActiveDirectoryServicePrincipal)
+		dsn.User = url.UserPassword(c.AzureClientId+"@"+c.AzureTenantId, c.Password)
+	case DatabaseAuthAzureManagedIdentity:
+		query.Set("fedauth", azuread.ActiveDirectoryManagedIdentity)
+		// A client ID selects a user-assigned identity. The system-assigned identity is used otherwise.
+		if c.AzureClientId != "" {
+			dsn.User = url.User(c.AzureClientId)
+		}
+	case DatabaseAuthAzureInteractive:
+		if c.AzureClientId == "" {
+			return "", errors.New("the client ID of the application is required for interactive authentication")
+		}
+
+		query.Set("fedauth", azuread.ActiveDirectoryInteractive)
+		query.Set("applicationclientid", c.AzureClientId)
+		if c.Username != "" {
+			dsn.User = url.User(c.Username)
+		}
+	default:
+		dsn.User = url.UserPassword(c.Username, c.Password)
+	}
+
+	dsn.RawQuery = query.Encode()
+
+	return dsn.String(), nil
+}
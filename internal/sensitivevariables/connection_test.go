@@ -0,0 +1,130 @@
+package sensitivevariables
+
+import (
+	"net/url"
+	"runtime"
+	"testing"
+)
+
+func TestConnectionDsn(t *testing.T) {
+	tests := []struct {
+		name       string
+		connection Connection
+		driver     string
+		user       string
+		password   string
+		path       string
+		host       string
+		query      map[string]string
+	}{
+		{
+			name:       "sql server login",
+			connection: Connection{Server: "db", Port: "1433", Database: "Octopus", AuthType: DatabaseAuthSqlServer, Username: "sa", Password: "p@ss:word"},
+			driver:     "sqlserver",
+			user:       "sa",
+			password:   "p@ss:word",
+			host:       "db:1433",
+			query:      map[string]string{"database": "Octopus"},
+		},
+		{
+			name:       "named instance without port",
+			connection: Connection{Server: "db", Instance: "SQLEXPRESS", Database: "Octopus", Username: "sa", Password: "password", Encrypt: DatabaseEncryptStrict, TrustServerCertificate: true},
+			driver:     "sqlserver",
+			user:       "sa",
+			password:   "password",
+			host:       "db",
+			path:       "/SQLEXPRESS",
+			query:      map[string]string{"database": "Octopus", "encrypt": "strict", "TrustServerCertificate": "true"},
+		},
+		{
+			name:       "windows account",
+			connection: Connection{Server: "db", Port: "1433", Database: "Octopus", AuthType: DatabaseAuthWindows, Username: "CORP\\octopus", Password: "password"},
+			driver:     "sqlserver",
+			user:       "CORP\\octopus",
+			password:   "password",
+			host:       "db:1433",
+			query:      map[string]string{"database": "Octopus"},
+		},
+		{
+			name:       "service principal",
+			connection: Connection{Server: "db.database.windows.net", Port: "1433", Database: "Octopus", AuthType: DatabaseAuthAzureServicePrincipal, Password: "secret", AzureTenantId: "tenant", AzureClientId: "client"},
+			driver:     "azuresql",
+			user:       "client@tenant",
+			password:   "secret",
+			host:       "db.database.windows.net:1433",
+			query:      map[string]string{"database": "Octopus", "fedauth": "ActiveDirectoryServicePrincipal"},
+		},
+		{
+			name:       "system assigned managed identity",
+			connection: Connection{Server: "db.database.windows.net", Port: "1433", Database: "Octopus", AuthType: DatabaseAuthAzureManagedIdentity},
+			driver:     "azuresql",
+			host:       "db.database.windows.net:1433",
+			query:      map[string]string{"database": "Octopus", "fedauth": "ActiveDirectoryManagedIdentity"},
+		},
+		{
+			name:       "interactive",
+			connection: Connection{Server: "db.database.windows.net", Port: "1433", Database: "Octopus", AuthType: DatabaseAuthAzureInteractive, Username: "user@example.org", AzureClientId: "app"},
+			driver:     "azuresql",
+			user:       "user@example.org",
+			host:       "db.database.windows.net:1433",
+			query:      map[string]string{"database": "Octopus", "fedauth": "ActiveDirectoryInteractive", "applicationclientid": "app"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dsn, err := test.connection.dsn()
+			if err != nil {
+				t.Fatalf("Failed to build the connection string: %v", err)
+			}
+
+			if test.connection.driverName() != test.driver {
+				t.Fatalf("Expected the %s driver, got %s", test.driver, test.connection.driverName())
+			}
+
+			parsed, err := url.Parse(dsn)
+			if err != nil {
+				t.Fatalf("Failed to parse the connection string: %v", err)
+			}
+
+			password, _ := parsed.User.Password()
+			if parsed.User.Username() != test.user || password != test.password {
+				t.Fatalf("Unexpected credentials %s", parsed.User.String())
+			}
+
+			if parsed.Host != test.host || parsed.Path != test.path {
+				t.Fatalf("Unexpected host %s and path %s", parsed.Host, parsed.Path)
+			}
+
+			if len(parsed.Query()) != len(test.query) {
+				t.Fatalf("Unexpected query %s", parsed.RawQuery)
+			}
+
+			for key, value := range test.query {
+				if parsed.Query().Get(key) != value {
+					t.Fatalf("Expected %s to be %s, got %s", key, value, parsed.Query().Get(key))
+				}
+			}
+		})
+	}
+}
+
+func TestConnectionDsnErrors(t *testing.T) {
+	connections := map[string]Connection{
+		"missing port":              {Server: "db", Database: "Octopus"},
+		"invalid port":              {Server: "db", Port: "abc", Database: "Octopus"},
+		"windows without domain":    {Server: "db", Port: "1433", AuthType: DatabaseAuthWindows, Username: "octopus"},
+		"incomplete principal":      {Server: "db", Port: "1433", AuthType: DatabaseAuthAzureServicePrincipal, AzureClientId: "client"},
+		"interactive without an id": {Server: "db", Port: "1433", AuthType: DatabaseAuthAzureInteractive},
+	}
+
+	if runtime.GOOS != "windows" {
+		connections["windows without username"] = Connection{Server: "db", Port: "1433", AuthType: DatabaseAuthWindows}
+	}
+
+	for name, connection := range connections {
+		if _, err := connection.dsn(); err == nil {
+			t.Fatalf("Expected an error for the %s connection", name)
+		}
+	}
+}
@@ -8,20 +8,17 @@ import (
 	"fmt"
 	"github.com/mcasperson/OctoterraWizard/internal/naming"
 	"log"
-	"strconv"
-	"strings"
 	"time"
 )
 import _ "github.com/microsoft/go-mssqldb"
 
-func GetDatabaseConnection(server string, port string, database string, username string, password string, ctx context.Context) (*sql.DB, error) {
-	portNum, err := strconv.Atoi(port)
+func GetDatabaseConnection(connection Connection, ctx context.Context) (*sql.DB, error) {
+	dsn, err := connection.dsn()
 	if err != nil {
 		return nil, err
 	}
 
-	dsn := "sqlserver://" + username + ":" + password + "@" + server + ":" + fmt.Sprint(portNum) + "?database=" + database
-	db, err := sql.Open("sqlserver", dsn)
+	db, err := sql.Open(connection.driverName(), dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -51,14 +48,16 @@ func CheckTableExists(ctx context.Context, db *sql.DB, tableName string) (bool,
 	return exists == 1, nil
 }
 
-// ExtractVariables extracts sensitive variables from the database and returns them as terraform variable values
-func ExtractVariables(server string, port string, database string, username string, password string, masterKey string) (string, error) {
+// ExtractVariables extracts sensitive variables from the database and returns them as terraform variable values.
+// Only the records in the scope are extracted. If continueOnError is true, values that can not be decrypted are
+// recorded as failures in the result, and otherwise the first failure is returned as an error.
+func ExtractVariables(connection Connection, masterKey string, scope Scope, continueOnError bool) (Result, error) {
 	ctx, stop := context.WithCancel(context.Background())
 	defer stop()
 
-	db, err := GetDatabaseConnection(server, port, database, username, password, ctx)
+	db, err := GetDatabaseConnection(connection, ctx)
 	if err != nil {
-		log.Fatal(err)
+		return Result{}, err
 	}
 	defer func() {
 		if err := db.Close(); err != nil {
@@ -68,102 +67,41 @@ func ExtractVariables(server string, port string, database string, username stri
 
 	// Test the connection
 	if err := PingDatabase(ctx, db); err != nil {
-		return "", err
-	}
-
-	var sensitiveValues strings.Builder
-
-	// Get the sensitive variables
-	sensitiveVars, err := getVariableSetSecrets(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
-	}
-
-	sensitiveValues.WriteString(sensitiveVars)
-
-	// Get the account passwords
-	accountCreds, err := getAccountCreds(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
-	}
-
-	sensitiveValues.WriteString(accountCreds)
-
-	// Get the tenant vars passwords
-	tenantVars, err := getTenantVarSensitiveValues(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
-	}
-
-	sensitiveValues.WriteString(tenantVars)
-
-	// Get the feed passwords
-	feedVars, err := geFeedSensitiveValues(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
-	sensitiveValues.WriteString(feedVars)
-
-	// Get the certificates
-	certificates, err := getCertificateSensitiveValues(ctx, db, masterKey)
+	e := &extraction{masterKey: masterKey, continueOnError: continueOnError}
 
-	if err != nil {
-		return "", err
+	extractors := []func(context.Context, *sql.DB, *extraction, Scope) error{
+		// Get the sensitive variables
+		getVariableSetSecrets,
+		// Get the account passwords
+		getAccountCreds,
+		// Get the tenant vars passwords
+		getTenantVarSensitiveValues,
+		// Get the feed passwords
+		geFeedSensitiveValues,
+		// Get the certificates
+		getCertificateSensitiveValues,
+		// Get the git credentials
+		getGitCredsSensitiveValues,
+		// Get the step template vars
+		getStepTemplateSensitiveValues,
+		// Get the step vars
+		getStepsSensitiveValues,
+		// Get the target vars
+		getTargetSensitiveValues,
+		// Get the proxy vars
+		getMachineProxyPassword,
 	}
 
-	sensitiveValues.WriteString(certificates)
-
-	// Get the git credentials
-	gitCreds, err := getGitCredsSensitiveValues(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
-	}
-
-	sensitiveValues.WriteString(gitCreds)
-
-	// Get the step template vars
-	stepTemplateVars, err := getStepTemplateSensitiveValues(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
-	}
-
-	sensitiveValues.WriteString(stepTemplateVars)
-
-	// Get the step vars
-	stepVars, err := getStepsSensitiveValues(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
-	}
-
-	sensitiveValues.WriteString(stepVars)
-
-	// Get the target vars
-	targetVars, err := getTargetSensitiveValues(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
-	}
-
-	sensitiveValues.WriteString(targetVars)
-
-	// Get the proxy vars
-	proxyVars, err := getMachineProxyPassword(ctx, db, masterKey)
-
-	if err != nil {
-		return "", err
+	for _, extractor := range extractors {
+		if err := extractor(ctx, db, e, scope); err != nil {
+			return e.result(), err
+		}
 	}
 
-	sensitiveValues.WriteString(proxyVars)
-
-	return sensitiveValues.String(), err
+	return e.result(), nil
 }
 
 func PingDatabase(ctx context.Context, db *sql.DB) error {
@@ -172,17 +110,19 @@ func PingDatabase(ctx context.Context, db *sql.DB) error {
 	return db.PingContext(ctx)
 }
 
-func getVariableSetSecrets(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getVariableSetSecrets(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var id string
+	var ownerId string
 	var jsonValue string
 	var isFrozen bool
 	var ownerType string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT Id, JSON, IsFrozen, OwnerType FROM VariableSet")
+	query, args := scope.filter("SELECT Id, OwnerId, JSON, IsFrozen, OwnerType FROM VariableSet", "SpaceId = %s", "(OwnerType <> 'Project' OR OwnerId IN (%s))")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -191,11 +131,9 @@ func getVariableSetSecrets(ctx context.Context, db *sql.DB, masterKey string) (s
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
-		if err = rows.Scan(&id, &jsonValue, &isFrozen, &ownerType); err != nil {
-			return "", err
+		if err = rows.Scan(&id, &ownerId, &jsonValue, &isFrozen, &ownerType); err != nil {
+			return err
 		}
 
 		if isFrozen {
@@ -209,7 +147,10 @@ func getVariableSetSecrets(ctx context.Context, db *sql.DB, masterKey string) (s
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(Record{Category: CategoryVariable, Owner: ownerId, Resource: id}, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		if variables, ok := result["Variables"].([]interface{}); ok {
@@ -227,17 +168,15 @@ func getVariableSetSecrets(ctx context.Context, db *sql.DB, masterKey string) (s
 						continue
 					}
 
-					variableName := naming.VariableSecretName(fmt.Sprint(variableMap["Id"]))
-					variableValue, err := DecryptSensitiveVariable(masterKey, fmt.Sprint(variableMap["Value"]))
-
-					if err != nil {
-						return "", err
+					record := Record{
+						Category:     CategoryVariable,
+						Owner:        ownerId,
+						Resource:     fmt.Sprint(variableMap["Name"]),
+						VariableName: naming.VariableSecretName(fmt.Sprint(variableMap["Id"])),
 					}
 
-					if tfVar, err := writeVariableFile(variableName, variableValue); err != nil {
-						return "", err
-					} else {
-						builder.WriteString(tfVar)
+					if err := e.add(record, fmt.Sprint(variableMap["Value"])); err != nil {
+						return err
 					}
 				}
 			}
@@ -245,18 +184,19 @@ func getVariableSetSecrets(ctx context.Context, db *sql.DB, masterKey string) (s
 
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getAccountCreds(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getAccountCreds(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var name string
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT Name, JSON FROM Account")
+	query, args := scope.filter("SELECT Name, JSON FROM Account", "SpaceId = %s", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -265,81 +205,77 @@ func getAccountCreds(ctx context.Context, db *sql.DB, masterKey string) (string,
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&name, &jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
-		}
-
-		// Each account type stores different secrets
-		password, passwordOk := result["Password"].(string)
-		secretKey, secretKeyOk := result["SecretKey"].(string)
-		jsonKey, jsonKeyOk := result["JsonKey"].(string)
-		privateKeyPassphrase, privateKeyPassphraseOk := result["PrivateKeyPassphrase"].(string)
-		privateKeyFile, privateKeyFileOk := result["PrivateKeyFile"].(string)
-		token, tokenOk := result["Token"].(string)
-
-		// Must have one sensitive value to extract
-		if !(passwordOk || secretKeyOk || jsonKeyOk || privateKeyPassphraseOk || privateKeyFileOk || tokenOk) {
+			if err := e.fail(Record{Category: CategoryAccount, Resource: name}, err); err != nil {
+				return err
+			}
 			continue
 		}
 
-		variableName := naming.AccountSecretName(fmt.Sprint(result["Name"]))
-		variableNameCert := naming.AccountCertName(fmt.Sprint(result["Name"]))
+		if err := addAccountCreds(e, name, result); err != nil {
+			return err
+		}
+	}
 
-		var variableValue string
-		var variableValueCert string
+	return nil
+}
 
-		if passwordOk {
-			variableValue, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(password))
-		} else if secretKeyOk {
-			variableValue, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(secretKey))
-		} else if jsonKeyOk {
-			variableValue, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(jsonKey))
-		} else if privateKeyPassphraseOk && privateKeyFileOk {
-			variableValue, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(privateKeyPassphrase))
-			variableValueCert, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(privateKeyFile))
-		} else if tokenOk {
-			variableValue, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(token))
-		}
+// addAccountCreds extracts the sensitive values of an account. The certificate variable is only written for
+// accounts with a private key. Other accounts have no certificate, and an empty value is never written to the
+// variables file, so these accounts have only ever had the secret variable.
+func addAccountCreds(e *extraction, name string, result map[string]interface{}) error {
+	// Each account type stores different secrets
+	password, passwordOk := result["Password"].(string)
+	secretKey, secretKeyOk := result["SecretKey"].(string)
+	jsonKey, jsonKeyOk := result["JsonKey"].(string)
+	privateKeyPassphrase, privateKeyPassphraseOk := result["PrivateKeyPassphrase"].(string)
+	privateKeyFile, privateKeyFileOk := result["PrivateKeyFile"].(string)
+	token, tokenOk := result["Token"].(string)
 
-		if err != nil {
-			return "", err
-		}
+	// Must have one sensitive value to extract
+	if !(passwordOk || secretKeyOk || jsonKeyOk || privateKeyPassphraseOk || privateKeyFileOk || tokenOk) {
+		return nil
+	}
 
-		if tfVar, err := writeVariableFile(variableName, variableValue); err != nil {
-			return "", err
-		} else {
-			builder.WriteString(tfVar)
-		}
+	record := Record{Category: CategoryAccount, Resource: name, VariableName: naming.AccountSecretName(fmt.Sprint(result["Name"]))}
 
-		if tfVar, err := writeVariableFile(variableNameCert, variableValueCert); err != nil {
-			return "", err
-		} else {
-			builder.WriteString(tfVar)
+	if passwordOk {
+		return e.add(record, password)
+	} else if secretKeyOk {
+		return e.add(record, secretKey)
+	} else if jsonKeyOk {
+		return e.add(record, jsonKey)
+	} else if privateKeyPassphraseOk && privateKeyFileOk {
+		if err := e.add(record, privateKeyPassphrase); err != nil {
+			return err
 		}
 
+		return e.add(Record{Category: CategoryAccount, Resource: name, VariableName: naming.AccountCertName(fmt.Sprint(result["Name"]))}, privateKeyFile)
+	} else if tokenOk {
+		return e.add(record, token)
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getTenantVarSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getTenantVarSensitiveValues(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var id string
+	var tenantId string
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT Id, JSON FROM TenantVariable")
+	query, args := scope.filter("SELECT Id, TenantId, JSON FROM TenantVariable", "TenantId IN (SELECT Id FROM Tenant WHERE SpaceId = %s)", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -348,57 +284,54 @@ func getTenantVarSensitiveValues(ctx context.Context, db *sql.DB, masterKey stri
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
-		if err = rows.Scan(&id, &jsonValue); err != nil {
-			return "", err
+		if err = rows.Scan(&id, &tenantId, &jsonValue); err != nil {
+			return err
 		}
 
+		record := Record{Category: CategoryTenantVariable, Owner: tenantId, Resource: id, VariableName: naming.TenantVariableSecretName(id)}
+
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(record, err); err != nil {
+				return err
+			}
+			continue
 		}
 
-		tentacleVariableName := naming.TenantVariableSecretName(id)
-
 		value, valueOk := result["Value"].(map[string]interface{})
 
 		if !valueOk {
-			return "", errors.New("Value is not a map")
+			if err := e.fail(record, errors.New("Value is not a map")); err != nil {
+				return err
+			}
+			continue
 		}
 
 		sensitiveValue, sensitiveValueOk := value["SensitiveValue"].(string)
 
 		if sensitiveValueOk {
-			decryptedSensitiveValue, err := DecryptSensitiveVariable(masterKey, fmt.Sprint(sensitiveValue))
-
-			if err != nil {
-				return "", err
-			}
-
-			if tfVar, err := writeVariableFile(tentacleVariableName, decryptedSensitiveValue); err != nil {
-				return "", err
-			} else {
-				builder.WriteString(tfVar)
+			if err := e.add(record, sensitiveValue); err != nil {
+				return err
 			}
 		}
 
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getCertificateSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getCertificateSensitiveValues(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var name string
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT Name, JSON FROM Certificate")
+	query, args := scope.filter("SELECT Name, JSON FROM Certificate", "SpaceId = %s", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -407,71 +340,57 @@ func getCertificateSensitiveValues(ctx context.Context, db *sql.DB, masterKey st
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&name, &jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
+		record := Record{Category: CategoryCertificate, Resource: name, VariableName: naming.CertificateDataName(name)}
+
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(record, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Each account type stores different secrets
 		certificate, certificateOk := result["CertificateData"].(string)
 
 		if !certificateOk {
-			return "", errors.New("CertificateData is not a string")
-		}
-
-		password, passwordOk := result["Password"].(string)
-
-		certDataName := naming.CertificateDataName(name)
-		certPassName := naming.CertificatePasswordName(name)
-
-		certValue, err := DecryptSensitiveVariable(masterKey, fmt.Sprint(certificate))
-
-		if err != nil {
-			return "", err
+			if err := e.fail(record, errors.New("CertificateData is not a string")); err != nil {
+				return err
+			}
+			continue
 		}
 
-		if tfVar, err := writeVariableFile(certDataName, certValue); err != nil {
-			return "", err
-		} else {
-			builder.WriteString(tfVar)
+		if err := e.add(record, certificate); err != nil {
+			return err
 		}
 
-		if passwordOk {
-			passwordValue, err := DecryptSensitiveVariable(masterKey, fmt.Sprint(password))
-
-			if err != nil {
-				return "", err
-			}
-
-			if tfVar, err := writeVariableFile(certPassName, passwordValue); err != nil {
-				return "", err
-			} else {
-				builder.WriteString(tfVar)
+		if password, passwordOk := result["Password"].(string); passwordOk {
+			if err := e.add(Record{Category: CategoryCertificate, Resource: name, VariableName: naming.CertificatePasswordName(name)}, password); err != nil {
+				return err
 			}
 		}
 
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func geFeedSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func geFeedSensitiveValues(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var name string
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT Name, JSON FROM Feed")
+	query, args := scope.filter("SELECT Name, JSON FROM Feed", "SpaceId = %s", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -480,17 +399,18 @@ func geFeedSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (s
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&name, &jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(Record{Category: CategoryFeed, Resource: name}, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Each account type stores different secrets
@@ -502,32 +422,22 @@ func geFeedSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (s
 			continue
 		}
 
-		var variableName string
-		var variableValue string
 		if passwordOk {
-			variableName = naming.FeedSecretName(fmt.Sprint(result["Name"]))
-			variableValue, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(password))
+			err = e.add(Record{Category: CategoryFeed, Resource: name, VariableName: naming.FeedSecretName(fmt.Sprint(result["Name"]))}, password)
 		} else if secretKeyOk {
-			variableName = naming.FeedSecretKeyName(fmt.Sprint(result["Name"]))
-			variableValue, err = DecryptSensitiveVariable(masterKey, fmt.Sprint(secretKey))
+			err = e.add(Record{Category: CategoryFeed, Resource: name, VariableName: naming.FeedSecretKeyName(fmt.Sprint(result["Name"]))}, secretKey)
 		}
 
 		if err != nil {
-			return "", err
-		}
-
-		if tfVar, err := writeVariableFile(variableName, variableValue); err != nil {
-			return "", err
-		} else {
-			builder.WriteString(tfVar)
+			return err
 		}
 
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getGitCredsSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getGitCredsSensitiveValues(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var id string
 	var jsonValue string
 
@@ -537,16 +447,17 @@ func getGitCredsSensitiveValues(ctx context.Context, db *sql.DB, masterKey strin
 	// Not all versions of Octopus have this table
 	exists, err := CheckTableExists(ctx, db, "GitCredential")
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	if !exists {
-		return "", nil
+		return nil
 	}
 
-	rows, err := db.QueryContext(timeout, "SELECT Id, JSON FROM GitCredential")
+	query, args := scope.filter("SELECT Id, JSON FROM GitCredential", "SpaceId = %s", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -555,56 +466,56 @@ func getGitCredsSensitiveValues(ctx context.Context, db *sql.DB, masterKey strin
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&id, &jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
+		record := Record{Category: CategoryGitCredential, Resource: id, VariableName: naming.GitCredentialSecretName(id)}
+
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(record, err); err != nil {
+				return err
+			}
+			continue
 		}
 
-		gitCredName := naming.GitCredentialSecretName(id)
+		if name, nameOk := result["Name"].(string); nameOk {
+			record.Resource = name
+		}
 
 		details, detailsOk := result["details"].(map[string]interface{})
 
 		if !detailsOk {
-			return "", errors.New("details is not a map")
+			if err := e.fail(record, errors.New("details is not a map")); err != nil {
+				return err
+			}
+			continue
 		}
 
 		sensitiveValue, sensitiveValueOk := details["Password"].(string)
 
 		if sensitiveValueOk {
-			decryptedSensitiveValue, err := DecryptSensitiveVariable(masterKey, fmt.Sprint(sensitiveValue))
-
-			if err != nil {
-				return "", err
-			}
-
-			if tfVar, err := writeVariableFile(gitCredName, decryptedSensitiveValue); err != nil {
-				return "", err
-			} else {
-				builder.WriteString(tfVar)
+			if err := e.add(record, sensitiveValue); err != nil {
+				return err
 			}
 		}
-
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getStepTemplateSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getStepTemplateSensitiveValues(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT JSON FROM ActionTemplate")
+	query, args := scope.filter("SELECT JSON FROM ActionTemplate", "SpaceId = %s", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -613,17 +524,18 @@ func getStepTemplateSensitiveValues(ctx context.Context, db *sql.DB, masterKey s
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(Record{Category: CategoryStepTemplate}, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Steps is an array of objects
@@ -683,33 +595,32 @@ func getStepTemplateSensitiveValues(ctx context.Context, db *sql.DB, masterKey s
 			}
 
 			// We can now decrypt the sensitive value
-			variableName := naming.StepTemplateParameterSecretName(templateId, parameterId)
-			variableValue, err := DecryptSensitiveVariable(masterKey, sensitiveValueValue)
-
-			if err != nil {
-				return "", err
+			record := Record{
+				Category:     CategoryStepTemplate,
+				Owner:        fmt.Sprint(result["Name"]),
+				Resource:     fmt.Sprint(parametersMap["Name"]),
+				VariableName: naming.StepTemplateParameterSecretName(templateId, parameterId),
 			}
 
-			if tfVar, err := writeVariableFile(variableName, variableValue); err != nil {
-				return "", err
-			} else {
-				builder.WriteString(tfVar)
+			if err := e.add(record, sensitiveValueValue); err != nil {
+				return err
 			}
 		}
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getStepsSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getStepsSensitiveValues(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var id string
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT OwnerId, JSON FROM DeploymentProcess")
+	query, args := scope.filter("SELECT OwnerId, JSON FROM DeploymentProcess", "SpaceId = %s", "OwnerId IN (%s)")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -718,17 +629,18 @@ func getStepsSensitiveValues(ctx context.Context, db *sql.DB, masterKey string)
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&id, &jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(Record{Category: CategoryStep, Owner: id}, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Steps is an array of objects
@@ -802,35 +714,34 @@ func getStepsSensitiveValues(ctx context.Context, db *sql.DB, masterKey string)
 					}
 
 					// We can now decrypt the sensitive value
-					variableName := naming.StepPropertySecretName(id, actionId, propertyName)
-					variableValue, err := DecryptSensitiveVariable(masterKey, sensitiveValueValue)
-
-					if err != nil {
-						return "", err
+					record := Record{
+						Category:     CategoryStep,
+						Owner:        id,
+						Resource:     fmt.Sprint(actionMap["Name"]) + "/" + propertyName,
+						VariableName: naming.StepPropertySecretName(id, actionId, propertyName),
 					}
 
-					if tfVar, err := writeVariableFile(variableName, variableValue); err != nil {
-						return "", err
-					} else {
-						builder.WriteString(tfVar)
+					if err := e.add(record, sensitiveValueValue); err != nil {
+						return err
 					}
 				}
 			}
 		}
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getTargetSensitiveValues(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getTargetSensitiveValues(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var name string
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT Name, JSON FROM Machine")
+	query, args := scope.filter("SELECT Name, JSON FROM Machine", "SpaceId = %s", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -839,58 +750,55 @@ func getTargetSensitiveValues(ctx context.Context, db *sql.DB, masterKey string)
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&name, &jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
+		// Note that as at 0.40.4 the TF provider does not expose the password for an offline target
+		record := Record{Category: CategoryTarget, Resource: name, VariableName: naming.MachineSecretName(name)}
+
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(record, err); err != nil {
+				return err
+			}
+			continue
 		}
 
-		// Note that as at 0.40.4 the TF provider does not expose the password for an offline target
-		gitCredName := naming.MachineSecretName(name)
-
 		endpoint, endpointOk := result["Endpoint"].(map[string]interface{})
 
 		if !endpointOk {
-			return "", errors.New("Endpoint is not a map")
+			if err := e.fail(record, errors.New("Endpoint is not a map")); err != nil {
+				return err
+			}
+			continue
 		}
 
 		sensitiveValue, sensitiveValueOk := endpoint["SensitiveVariablesEncryptionPassword"].(string)
 
 		if sensitiveValueOk {
-			decryptedSensitiveValue, err := DecryptSensitiveVariable(masterKey, fmt.Sprint(sensitiveValue))
-
-			if err != nil {
-				return "", err
-			}
-
-			if tfVar, err := writeVariableFile(gitCredName, decryptedSensitiveValue); err != nil {
-				return "", err
-			} else {
-				builder.WriteString(tfVar)
+			if err := e.add(record, sensitiveValue); err != nil {
+				return err
 			}
 		}
 
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
-func getMachineProxyPassword(ctx context.Context, db *sql.DB, masterKey string) (string, error) {
+func getMachineProxyPassword(ctx context.Context, db *sql.DB, e *extraction, scope Scope) error {
 	var name string
 	var jsonValue string
 
 	timeout, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
-	rows, err := db.QueryContext(timeout, "SELECT Name, JSON FROM Proxy")
+	query, args := scope.filter("SELECT Name, JSON FROM Proxy", "SpaceId = %s", "")
+	rows, err := db.QueryContext(timeout, query, args...)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	defer func() {
@@ -899,17 +807,20 @@ func getMachineProxyPassword(ctx context.Context, db *sql.DB, masterKey string)
 		}
 	}()
 
-	var builder strings.Builder
-
 	for rows.Next() {
 		if err = rows.Scan(&name, &jsonValue); err != nil {
-			return "", err
+			return err
 		}
 
+		record := Record{Category: CategoryProxy, Resource: name, VariableName: naming.MachineProxyPassword(name)}
+
 		var result map[string]interface{}
 
 		if err := json.Unmarshal([]byte(jsonValue), &result); err != nil {
-			return "", err
+			if err := e.fail(record, err); err != nil {
+				return err
+			}
+			continue
 		}
 
 		// Each account type stores different secrets
@@ -920,22 +831,13 @@ func getMachineProxyPassword(ctx context.Context, db *sql.DB, masterKey string)
 			continue
 		}
 
-		variableName := naming.MachineProxyPassword(fmt.Sprint(name))
-		variableValue, err := DecryptSensitiveVariable(masterKey, fmt.Sprint(password))
-
-		if err != nil {
-			return "", err
-		}
-
-		if tfVar, err := writeVariableFile(variableName, variableValue); err != nil {
-			return "", err
-		} else {
-			builder.WriteString(tfVar)
+		if err := e.add(record, password); err != nil {
+			return err
 		}
 
 	}
 
-	return builder.String(), nil
+	return nil
 }
 
 func writeVariableFile(variableName string, variableValue string) (string, error) {
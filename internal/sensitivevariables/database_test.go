@@ -6,13 +6,39 @@ import _ "github.com/microsoft/go-mssqldb"
 func TestExtractVariables(t *testing.T) {
 	return
 
-	result, err := ExtractVariables("localhost", "1433", "Octopus", "SA", "Password01!", "6EdU6IWsCtMEwk0kPKflQQ==")
+	result, err := ExtractVariables(Connection{Server: "localhost", Port: "1433", Database: "Octopus", Username: "SA", Password: "Password01!"}, "6EdU6IWsCtMEwk0kPKflQQ==", Scope{}, false)
 
 	if err != nil {
 		t.Fatalf("Failed to extract variables: %v", err)
 	}
 
-	if result != "e647abb66147e2d701c0b44934063b6e27dd4af84d10145d196a4a50bffcfc14_sensitive_value = \"success\"\n" {
+	if result.Values != "e647abb66147e2d701c0b44934063b6e27dd4af84d10145d196a4a50bffcfc14_sensitive_value = \"success\"\n" {
 		t.Errorf("Expected %s, got %s", "success", result)
 	}
 }
+
+func TestAddAccountCreds(t *testing.T) {
+	encrypted := "tHdE5KI9QVdsFSq6F6HeSA==|7oD+XzuTFF1uCQLXm8A3eg=="
+	e := &extraction{masterKey: "6EdU6IWsCtMEwk0kPKflQQ=="}
+
+	accounts := map[string]map[string]interface{}{
+		// Only accounts with a private key have a certificate variable
+		"Password":   {"Name": "Password", "Password": encrypted},
+		"PrivateKey": {"Name": "PrivateKey", "PrivateKeyPassphrase": encrypted, "PrivateKeyFile": encrypted},
+		"NoSecrets":  {"Name": "NoSecrets"},
+	}
+
+	for _, name := range []string{"Password", "PrivateKey", "NoSecrets"} {
+		if err := addAccountCreds(e, name, accounts[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := "account_password = \"success\"\n" +
+		"account_privatekey = \"success\"\n" +
+		"account_privatekey_cert = \"success\"\n"
+
+	if result := e.result(); result.Values != expected {
+		t.Fatalf("expected %s, got %s", expected, result.Values)
+	}
+}
@@ -44,6 +44,11 @@ func DecryptSensitiveVariable(masterKey string, value string) (string, error) {
 		return "", errors.New("IV length must be equal to block size")
 	}
 
+	// A corrupt value, or one encrypted with a different master key, must return an error rather than panic
+	if len(cipherText) == 0 || len(cipherText)%aes.BlockSize != 0 {
+		return "", errors.New("cipher text length must be a multiple of the block size")
+	}
+
 	// Create a new CBC decrypter
 	mode := cipher.NewCBCDecrypter(block, iv)
 
@@ -52,6 +57,10 @@ func DecryptSensitiveVariable(masterKey string, value string) (string, error) {
 	mode.CryptBlocks(decrypted, cipherText)
 
 	// Remove padding
+	if padding := int(decrypted[len(decrypted)-1]); padding == 0 || padding > aes.BlockSize {
+		return "", errors.New("invalid padding. Please check the master key")
+	}
+
 	decrypted = PKCS7Unpad(decrypted)
 
 	return string(decrypted), nil
@@ -2,6 +2,7 @@ package sensitivevariables
 
 import (
 	"errors"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/client"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/libraryvariablesets"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
 	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
@@ -22,6 +23,22 @@ func CreateSecretsLibraryVariableSet(values string, state state.State) error {
 		return errors.Join(errors.New("failed to create client"), err)
 	}
 
+	return createSecretsLibraryVariableSet(values, myclient)
+}
+
+// CreateDestinationSecretsLibraryVariableSet creates the same library variable set as CreateSecretsLibraryVariableSet
+// in the destination space
+func CreateDestinationSecretsLibraryVariableSet(values string, state state.State) error {
+	myclient, err := octoclient.CreateDestinationClient(state)
+
+	if err != nil {
+		return errors.Join(errors.New("failed to create client"), err)
+	}
+
+	return createSecretsLibraryVariableSet(values, myclient)
+}
+
+func createSecretsLibraryVariableSet(values string, myclient *client.Client) error {
 	// Find an existing library variable set or create a new one
 	existingLvs, err := libraryvariablesets.Get(myclient, myclient.GetSpaceID(), variables.LibraryVariablesQuery{
 		ContentType: "",
@@ -0,0 +1,168 @@
+package sensitivevariables
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The categories of records that sensitive values are extracted from
+const (
+	CategoryVariable       = "Variable"
+	CategoryAccount        = "Account"
+	CategoryTenantVariable = "Tenant Variable"
+	CategoryCertificate    = "Certificate"
+	CategoryFeed           = "Feed"
+	CategoryGitCredential  = "Git Credential"
+	CategoryStepTemplate   = "Step Template"
+	CategoryStep           = "Step"
+	CategoryTarget         = "Target"
+	CategoryProxy          = "Proxy"
+)
+
+// Categories lists the categories in the order they are extracted
+var Categories = []string{CategoryVariable, CategoryAccount, CategoryTenantVariable, CategoryFeed, CategoryCertificate,
+	CategoryGitCredential, CategoryStepTemplate, CategoryStep, CategoryTarget, CategoryProxy}
+
+// Record describes a sensitive value found in the database. The value itself is never included.
+type Record struct {
+	Category string
+	// Owner is the ID of the project, library variable set, tenant, or step template holding the value, if any
+	Owner string
+	// Resource is the name of the variable, account, feed, or other resource holding the value
+	Resource string
+	// VariableName is the Terraform variable that the value is assigned to
+	VariableName string
+	// Error is the reason the value could not be extracted, or empty if it was extracted
+	Error string
+}
+
+func (r Record) Failed() bool {
+	return r.Error != ""
+}
+
+// Result is the outcome of extracting the sensitive values
+type Result struct {
+	Records []Record
+	// Values is the contents of a terraform.tfvars file holding the extracted values
+	Values string
+}
+
+// Count returns the number of records in the category that were extracted, and that failed
+func (r Result) Count(category string) (extracted int, failed int) {
+	for _, record := range r.Records {
+		if record.Category != category {
+			continue
+		}
+
+		if record.Failed() {
+			failed++
+		} else {
+			extracted++
+		}
+	}
+
+	return extracted, failed
+}
+
+// Failures returns the records that could not be extracted
+func (r Result) Failures() []Record {
+	failures := []Record{}
+	for _, record := range r.Records {
+		if record.Failed() {
+			failures = append(failures, record)
+		}
+	}
+
+	return failures
+}
+
+// String summarizes the number of values extracted from each category, and lists the failures
+func (r Result) String() string {
+	var builder strings.Builder
+
+	for _, category := range Categories {
+		extracted, failed := r.Count(category)
+
+		if extracted == 0 && failed == 0 {
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("%s: %d extracted, %d failed\n", category, extracted, failed))
+	}
+
+	for _, failure := range r.Failures() {
+		builder.WriteString(fmt.Sprintf("Failed to extract %s %s %s: %s\n", failure.Category, failure.Owner, failure.Resource, failure.Error))
+	}
+
+	return builder.String()
+}
+
+// WriteCsv writes the records as CSV. The sensitive values are not included.
+func (r Result) WriteCsv(writer io.Writer) error {
+	csvWriter := csv.NewWriter(writer)
+
+	if err := csvWriter.Write([]string{"Category", "Owner", "Resource", "Variable Name", "Status", "Reason"}); err != nil {
+		return errors.Join(errors.New("failed to write the extraction report"), err)
+	}
+
+	for _, record := range r.Records {
+		status := "Extracted"
+		if record.Failed() {
+			status = "Failed"
+		}
+
+		if err := csvWriter.Write([]string{record.Category, record.Owner, record.Resource, record.VariableName, status, record.Error}); err != nil {
+			return errors.Join(errors.New("failed to write the extraction report"), err)
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// extraction collects the records and values as the database is read
+type extraction struct {
+	masterKey       string
+	continueOnError bool
+	values          strings.Builder
+	records         []Record
+}
+
+// add decrypts the value and assigns it to the Terraform variable defined by the record
+func (e *extraction) add(record Record, encryptedValue string) error {
+	value, err := DecryptSensitiveVariable(e.masterKey, encryptedValue)
+
+	if err != nil {
+		return e.fail(record, err)
+	}
+
+	tfVar, err := writeVariableFile(record.VariableName, value)
+
+	if err != nil {
+		return e.fail(record, err)
+	}
+
+	e.values.WriteString(tfVar)
+	e.records = append(e.records, record)
+	return nil
+}
+
+// fail records a value that could not be extracted. The error is only returned if extraction does
+// not continue on error.
+func (e *extraction) fail(record Record, err error) error {
+	record.Error = err.Error()
+	e.records = append(e.records, record)
+
+	if e.continueOnError {
+		return nil
+	}
+
+	return errors.Join(fmt.Errorf("failed to extract the %s %s", strings.ToLower(record.Category), strings.TrimSpace(record.Owner+" "+record.Resource)), err)
+}
+
+func (e *extraction) result() Result {
+	return Result{Records: e.records, Values: e.values.String()}
+}
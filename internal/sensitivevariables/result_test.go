@@ -0,0 +1,42 @@
+package sensitivevariables
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtraction(t *testing.T) {
+	e := &extraction{masterKey: "6EdU6IWsCtMEwk0kPKflQQ==", continueOnError: true}
+
+	if err := e.add(Record{Category: CategoryVariable, Owner: "Projects-1", Resource: "Password", VariableName: "password_sensitive_value"}, "tHdE5KI9QVdsFSq6F6HeSA==|7oD+XzuTFF1uCQLXm8A3eg=="); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.add(Record{Category: CategoryVariable, Owner: "Projects-1", Resource: "Corrupt", VariableName: "corrupt_sensitive_value"}, "not encrypted"); err != nil {
+		t.Fatalf("expected the failure to be recorded when continuing on error, got %v", err)
+	}
+
+	result := e.result()
+
+	if result.Values != "password_sensitive_value = \"success\"\n" {
+		t.Fatalf("expected only the decrypted value, got %s", result.Values)
+	}
+
+	if extracted, failed := result.Count(CategoryVariable); extracted != 1 || failed != 1 {
+		t.Fatalf("expected 1 extracted and 1 failed variable, got %d and %d", extracted, failed)
+	}
+
+	var csv strings.Builder
+	if err := result.WriteCsv(&csv); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(csv.String(), "success") || !strings.Contains(csv.String(), "Variable,Projects-1,Corrupt,corrupt_sensitive_value,Failed,") {
+		t.Fatalf("expected the report to list the failure without the values, got %s", csv.String())
+	}
+
+	e = &extraction{masterKey: "6EdU6IWsCtMEwk0kPKflQQ=="}
+	if err := e.add(Record{Category: CategoryAccount, Resource: "Azure"}, "AAAA|AAAA"); err == nil {
+		t.Fatal("expected the failure to be returned when not continuing on error")
+	}
+}
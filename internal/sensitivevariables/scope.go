@@ -0,0 +1,43 @@
+package sensitivevariables
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope limits the records that sensitive values are extracted from
+type Scope struct {
+	// SpaceId is the space whose records are extracted. Records from every space are extracted if this is empty.
+	SpaceId string
+	// ProjectIds limits the project variables and deployment processes to these projects. Every project
+	// is extracted if this is empty.
+	ProjectIds []string
+}
+
+// filter appends a WHERE clause limiting the query to the scope, and returns the query arguments.
+// The spaceCondition and projectCondition are format strings that receive the query parameters.
+// An empty projectCondition means the records do not belong to a project.
+func (s Scope) filter(query string, spaceCondition string, projectCondition string) (string, []any) {
+	conditions := []string{}
+	args := []any{}
+
+	if s.SpaceId != "" {
+		args = append(args, s.SpaceId)
+		conditions = append(conditions, fmt.Sprintf(spaceCondition, fmt.Sprintf("@p%d", len(args))))
+	}
+
+	if projectCondition != "" && len(s.ProjectIds) != 0 {
+		parameters := []string{}
+		for _, projectId := range s.ProjectIds {
+			args = append(args, projectId)
+			parameters = append(parameters, fmt.Sprintf("@p%d", len(args)))
+		}
+		conditions = append(conditions, fmt.Sprintf(projectCondition, strings.Join(parameters, ", ")))
+	}
+
+	if len(conditions) == 0 {
+		return query, args
+	}
+
+	return query + " WHERE " + strings.Join(conditions, " AND "), args
+}
@@ -0,0 +1,26 @@
+package sensitivevariables
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScopeFilter(t *testing.T) {
+	query, args := Scope{}.filter("SELECT Name, JSON FROM Account", "SpaceId = %s", "")
+	if query != "SELECT Name, JSON FROM Account" || len(args) != 0 {
+		t.Fatalf("expected an empty scope to leave the query unchanged, got %s %v", query, args)
+	}
+
+	scope := Scope{SpaceId: "Spaces-2", ProjectIds: []string{"Projects-1", "Projects-2"}}
+
+	query, args = scope.filter("SELECT Name, JSON FROM Account", "SpaceId = %s", "")
+	if query != "SELECT Name, JSON FROM Account WHERE SpaceId = @p1" || !reflect.DeepEqual(args, []any{"Spaces-2"}) {
+		t.Fatalf("expected the query to be limited to the space, got %s %v", query, args)
+	}
+
+	query, args = scope.filter("SELECT OwnerId, JSON FROM DeploymentProcess", "SpaceId = %s", "OwnerId IN (%s)")
+	if query != "SELECT OwnerId, JSON FROM DeploymentProcess WHERE SpaceId = @p1 AND OwnerId IN (@p2, @p3)" ||
+		!reflect.DeepEqual(args, []any{"Spaces-2", "Projects-1", "Projects-2"}) {
+		t.Fatalf("expected the query to be limited to the space and projects, got %s %v", query, args)
+	}
+}
@@ -0,0 +1,140 @@
+package sensitivevariables
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// SecretsFileVersion is the version of the encrypted secrets file written by this version of the wizard
+const SecretsFileVersion = 1
+
+// The scrypt parameters recommended for interactive logins
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// SecretsFile holds the terraform.tfvars file with the sensitive values, encrypted with AES-GCM using a key
+// derived from a passphrase with scrypt
+type SecretsFile struct {
+	Version    int    `json:"version"`
+	Kdf        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// WriteSecretsFile encrypts the sensitive values and saves them to the path, allowing the values to be kept
+// out of Octopus until they are uploaded with ReadSecretsFile and CreateSecretsLibraryVariableSet
+func WriteSecretsFile(path string, values string, passphrase string) error {
+	content, err := EncryptSecrets(values, passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return errors.Join(errors.New("failed to write the secrets file "+path), err)
+	}
+
+	return nil
+}
+
+// ReadSecretsFile returns the sensitive values saved by WriteSecretsFile
+func ReadSecretsFile(path string, passphrase string) (string, error) {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return "", errors.Join(errors.New("failed to read the secrets file "+path), err)
+	}
+
+	return DecryptSecrets(content, passphrase)
+}
+
+// EncryptSecrets returns the contents of a secrets file holding the encrypted values
+func EncryptSecrets(values string, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("a passphrase is required to encrypt the secrets file")
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	gcm, err := newSecretsCipher(passphrase, salt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(SecretsFile{
+		Version:    SecretsFileVersion,
+		Kdf:        "scrypt",
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, []byte(values), nil),
+	}, "", "  ")
+}
+
+// DecryptSecrets returns the values held by the contents of a secrets file
+func DecryptSecrets(content []byte, passphrase string) (string, error) {
+	secretsFile := SecretsFile{}
+
+	if err := json.Unmarshal(content, &secretsFile); err != nil {
+		return "", errors.Join(errors.New("failed to parse the secrets file"), err)
+	}
+
+	if secretsFile.Version != SecretsFileVersion || secretsFile.Kdf != "scrypt" {
+		return "", fmt.Errorf("the secrets file has version %d, but this version of the wizard only supports version %d", secretsFile.Version, SecretsFileVersion)
+	}
+
+	gcm, err := newSecretsCipher(passphrase, secretsFile.Salt)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(secretsFile.Nonce) != gcm.NonceSize() {
+		return "", errors.New("the secrets file has an invalid nonce")
+	}
+
+	values, err := gcm.Open(nil, secretsFile.Nonce, secretsFile.Ciphertext, nil)
+
+	if err != nil {
+		return "", errors.New("failed to decrypt the secrets file. Please check the passphrase")
+	}
+
+	return string(values), nil
+}
+
+func newSecretsCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,33 @@
+package sensitivevariables
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretsFile(t *testing.T) {
+	values := "variable_sensitive_value = \"success\"\n"
+	path := filepath.Join(t.TempDir(), "secrets.json")
+
+	if err := WriteSecretsFile(path, values, "correct horse battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	decrypted, err := ReadSecretsFile(path, "correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decrypted != values {
+		t.Fatalf("expected %s, got %s", values, decrypted)
+	}
+
+	if _, err := ReadSecretsFile(path, "wrong passphrase"); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Fatalf("expected the wrong passphrase to fail, got %v", err)
+	}
+
+	if _, err := EncryptSecrets(values, ""); err == nil {
+		t.Fatal("expected an empty passphrase to fail")
+	}
+}
@@ -0,0 +1,160 @@
+package spreadvariables
+
+import (
+	"errors"
+
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/channels"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/client"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/deployments"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/environments"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/machines"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/runbooks"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+)
+
+// The types of the resources passed to ScopeNames and ScopeName
+const (
+	scopeEnvironments = "Environments"
+	scopeMachines     = "Machines"
+	scopeChannels     = "Channels"
+	scopeRunbooks     = "Runbooks"
+)
+
+// spaceApi is the part of the Octopus API used to spread variables and undo the spreading
+type spaceApi interface {
+	LibraryVariableSets() ([]*variables.LibraryVariableSet, error)
+	Projects() ([]*projects.Project, error)
+	Project(id string) (*projects.Project, error)
+	VariableSet(id string) (*variables.VariableSet, error)
+	DeploymentProcess(id string) (*deployments.DeploymentProcess, error)
+	// ScopeNames returns the names of all the resources of the scope type, indexed by ID
+	ScopeNames(scopeType string) (map[string]string, error)
+	// ScopeName returns the name of a single resource of the scope type
+	ScopeName(scopeType string, id string) (string, error)
+	AddVariable(ownerId string, variable *variables.Variable) error
+	UpdateVariable(ownerId string, variable *variables.Variable) error
+	DeleteVariable(ownerId string, variableId string) error
+}
+
+// clientApi implements spaceApi with the Octopus client
+type clientApi struct {
+	client *client.Client
+}
+
+func (a clientApi) LibraryVariableSets() ([]*variables.LibraryVariableSet, error) {
+	return a.client.LibraryVariableSets.GetAll()
+}
+
+func (a clientApi) Projects() ([]*projects.Project, error) {
+	return a.client.Projects.GetAll()
+}
+
+func (a clientApi) Project(id string) (*projects.Project, error) {
+	return projects.GetByID(a.client, a.client.GetSpaceID(), id)
+}
+
+func (a clientApi) VariableSet(id string) (*variables.VariableSet, error) {
+	return variables.GetVariableSet(a.client, a.client.GetSpaceID(), id)
+}
+
+func (a clientApi) DeploymentProcess(id string) (*deployments.DeploymentProcess, error) {
+	return deployments.GetDeploymentProcessByID(a.client, a.client.GetSpaceID(), id)
+}
+
+func (a clientApi) ScopeNames(scopeType string) (map[string]string, error) {
+	names := map[string]string{}
+
+	switch scopeType {
+	case scopeEnvironments:
+		resources, err := a.client.Environments.GetAll()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resources {
+			names[resource.ID] = resource.Name
+		}
+	case scopeMachines:
+		resources, err := a.client.Machines.GetAll()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resources {
+			names[resource.ID] = resource.Name
+		}
+	case scopeChannels:
+		resources, err := a.client.Channels.GetAll()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resources {
+			names[resource.ID] = resource.Name
+		}
+	case scopeRunbooks:
+		resources, err := a.client.Runbooks.GetAll()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, resource := range resources {
+			names[resource.ID] = resource.Name
+		}
+	default:
+		return nil, errors.New("unknown scope type " + scopeType)
+	}
+
+	return names, nil
+}
+
+func (a clientApi) ScopeName(scopeType string, id string) (string, error) {
+	switch scopeType {
+	case scopeEnvironments:
+		resource, err := environments.GetByID(a.client, a.client.GetSpaceID(), id)
+		if err != nil {
+			return "", err
+		}
+
+		return resource.Name, nil
+	case scopeMachines:
+		resource, err := machines.GetByID(a.client, a.client.GetSpaceID(), id)
+		if err != nil {
+			return "", err
+		}
+
+		return resource.Name, nil
+	case scopeChannels:
+		resource, err := channels.GetByID(a.client, a.client.GetSpaceID(), id)
+		if err != nil {
+			return "", err
+		}
+
+		return resource.Name, nil
+	case scopeRunbooks:
+		resource, err := runbooks.GetByID(a.client, a.client.GetSpaceID(), id)
+		if err != nil {
+			return "", err
+		}
+
+		return resource.Name, nil
+	default:
+		return "", errors.New("unknown scope type " + scopeType)
+	}
+}
+
+func (a clientApi) AddVariable(ownerId string, variable *variables.Variable) error {
+	_, err := variables.AddSingle(a.client, a.client.GetSpaceID(), ownerId, variable)
+	return err
+}
+
+func (a clientApi) UpdateVariable(ownerId string, variable *variables.Variable) error {
+	_, err := variables.UpdateSingle(a.client, a.client.GetSpaceID(), ownerId, variable)
+	return err
+}
+
+func (a clientApi) DeleteVariable(ownerId string, variableId string) error {
+	_, err := variables.DeleteSingle(a.client, a.client.GetSpaceID(), ownerId, variableId)
+	return err
+}
@@ -0,0 +1,170 @@
+package spreadvariables
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/deployments"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+	"github.com/samber/lo"
+)
+
+// fakeSpaceApi stores the variable sets in memory, and counts the requests made to each method
+type fakeSpaceApi struct {
+	libraryVariableSets []*variables.LibraryVariableSet
+	projects            []*projects.Project
+	// variableSets are indexed by owner ID
+	variableSets map[string]*variables.VariableSet
+	scopeNames   map[string]map[string]string
+	requests     map[string]int
+	nextId       int
+}
+
+func newFakeSpaceApi() *fakeSpaceApi {
+	return &fakeSpaceApi{
+		variableSets: map[string]*variables.VariableSet{},
+		scopeNames: map[string]map[string]string{
+			scopeEnvironments: {"Environments-1": "Development", "Environments-2": "Production"},
+			scopeMachines:     {},
+			scopeChannels:     {},
+			scopeRunbooks:     {},
+		},
+		requests: map[string]int{},
+	}
+}
+
+func (a *fakeSpaceApi) addProject(id string, name string, versionControlled bool, projectVariables ...*variables.Variable) *projects.Project {
+	project := &projects.Project{
+		Name:                name,
+		VariableSetID:       "variableset-" + id,
+		DeploymentProcessID: "deploymentprocess-" + id,
+		IsVersionControlled: versionControlled,
+	}
+	project.ID = id
+	a.projects = append(a.projects, project)
+	a.variableSets[id] = &variables.VariableSet{OwnerID: id, Variables: projectVariables}
+	return project
+}
+
+func (a *fakeSpaceApi) addLibraryVariableSet(id string, name string, libraryVariables ...*variables.Variable) {
+	libraryVariableSet := &variables.LibraryVariableSet{
+		Name:          name,
+		VariableSetID: "variableset-" + id,
+	}
+	libraryVariableSet.ID = id
+	a.libraryVariableSets = append(a.libraryVariableSets, libraryVariableSet)
+	a.variableSets[id] = &variables.VariableSet{OwnerID: id, Variables: libraryVariables}
+}
+
+// lookupRequests returns the number of requests made to find the names of the scopes
+func (a *fakeSpaceApi) lookupRequests() int {
+	return a.requests["ScopeNames"] + a.requests["ScopeName"] + a.requests["Project"] + a.requests["DeploymentProcess"]
+}
+
+func (a *fakeSpaceApi) LibraryVariableSets() ([]*variables.LibraryVariableSet, error) {
+	a.requests["LibraryVariableSets"]++
+	return a.libraryVariableSets, nil
+}
+
+func (a *fakeSpaceApi) Projects() ([]*projects.Project, error) {
+	a.requests["Projects"]++
+	return a.projects, nil
+}
+
+func (a *fakeSpaceApi) Project(id string) (*projects.Project, error) {
+	a.requests["Project"]++
+	if project, ok := lo.Find(a.projects, func(item *projects.Project) bool {
+		return item.ID == id
+	}); ok {
+		return project, nil
+	}
+
+	return nil, errors.New("project " + id + " not found")
+}
+
+// VariableSet returns a copy of the variable set, as each request to the API returns new variables
+func (a *fakeSpaceApi) VariableSet(id string) (*variables.VariableSet, error) {
+	a.requests["VariableSet"]++
+	for _, variableSet := range a.variableSets {
+		if "variableset-"+variableSet.OwnerID != id {
+			continue
+		}
+
+		variableSetCopy := *variableSet
+		variableSetCopy.Variables = lo.Map(variableSet.Variables, func(item *variables.Variable, index int) *variables.Variable {
+			variableCopy := *item
+			return &variableCopy
+		})
+		return &variableSetCopy, nil
+	}
+
+	return nil, errors.New("variable set " + id + " not found")
+}
+
+func (a *fakeSpaceApi) DeploymentProcess(id string) (*deployments.DeploymentProcess, error) {
+	a.requests["DeploymentProcess"]++
+	return &deployments.DeploymentProcess{}, nil
+}
+
+func (a *fakeSpaceApi) ScopeNames(scopeType string) (map[string]string, error) {
+	a.requests["ScopeNames"]++
+	return a.scopeNames[scopeType], nil
+}
+
+func (a *fakeSpaceApi) ScopeName(scopeType string, id string) (string, error) {
+	a.requests["ScopeName"]++
+	if name, ok := a.scopeNames[scopeType][id]; ok {
+		return name, nil
+	}
+
+	return "", errors.New(scopeType + " " + id + " not found")
+}
+
+func (a *fakeSpaceApi) AddVariable(ownerId string, variable *variables.Variable) error {
+	a.requests["AddVariable"]++
+	variableSet, ok := a.variableSets[ownerId]
+
+	if !ok {
+		return errors.New("owner " + ownerId + " not found")
+	}
+
+	a.nextId++
+	variableCopy := *variable
+	variableCopy.ID = fmt.Sprintf("Variables-Added-%d", a.nextId)
+	variableSet.Variables = append(variableSet.Variables, &variableCopy)
+	return nil
+}
+
+func (a *fakeSpaceApi) UpdateVariable(ownerId string, variable *variables.Variable) error {
+	a.requests["UpdateVariable"]++
+	variableSet, ok := a.variableSets[ownerId]
+
+	if !ok {
+		return errors.New("owner " + ownerId + " not found")
+	}
+
+	for index, existing := range variableSet.Variables {
+		if existing.ID == variable.ID {
+			variableCopy := *variable
+			variableSet.Variables[index] = &variableCopy
+			return nil
+		}
+	}
+
+	return errors.New("variable " + variable.ID + " not found")
+}
+
+func (a *fakeSpaceApi) DeleteVariable(ownerId string, variableId string) error {
+	a.requests["DeleteVariable"]++
+	variableSet, ok := a.variableSets[ownerId]
+
+	if !ok {
+		return errors.New("owner " + ownerId + " not found")
+	}
+
+	variableSet.Variables = lo.Filter(variableSet.Variables, func(item *variables.Variable, index int) bool {
+		return item.ID != variableId
+	})
+	return nil
+}
@@ -0,0 +1,217 @@
+package spreadvariables
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
+	"github.com/samber/lo"
+)
+
+// The categories of sensitive values that are not spread
+const (
+	SkippedTenantVariable        = "Tenant Variable"
+	SkippedStepTemplateParameter = "Step Template Parameter"
+)
+
+// SkippedValue describes a sensitive value that can not be spread. Spreading renames a sensitive variable to keep
+// its value, as the API never returns sensitive values. Tenant variables and step template parameters are named
+// by their templates, and their values are not stored in a variable that can be renamed, so they are reported
+// instead. Their values must be extracted from the Octopus database.
+type SkippedValue struct {
+	Category string
+	Owner    string
+	Name     string
+	// Environments are the names of the environments a tenant project variable has a value for
+	Environments []string
+}
+
+func (v SkippedValue) String() string {
+	owner := v.Owner
+	if len(v.Environments) != 0 {
+		owner += " in " + strings.Join(v.Environments, ", ")
+	}
+
+	return fmt.Sprintf("%s: the sensitive %s %s can not be spread, and must be extracted from the Octopus database",
+		owner, v.Category, v.Name)
+}
+
+// FindSkippedValues returns the sensitive tenant variables and step template parameters in the space. The values
+// of a tenant project variable are reported once, listing the environments they are defined for.
+func (c *VariableSpreader) FindSkippedValues() ([]SkippedValue, error) {
+	myclient, err := octoclient.CreateClient(c.State)
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to create client"), err)
+	}
+
+	skipped := []SkippedValue{}
+
+	actionTemplates, err := myclient.ActionTemplates.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all step templates"), err)
+	}
+
+	for _, actionTemplate := range actionTemplates {
+		for _, parameter := range actionTemplate.Parameters {
+			if parameter.DisplaySettings["Octopus.ControlType"] != "Sensitive" {
+				continue
+			}
+
+			skipped = append(skipped, SkippedValue{
+				Category: SkippedStepTemplateParameter,
+				Owner:    actionTemplate.Name,
+				Name:     parameter.Name,
+			})
+		}
+	}
+
+	tenants, err := myclient.Tenants.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all tenants"), err)
+	}
+
+	if len(tenants) == 0 {
+		return skipped, nil
+	}
+
+	allEnvironments, err := myclient.Environments.GetAll()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all environments"), err)
+	}
+
+	environmentNames := map[string]string{}
+	for _, environment := range allEnvironments {
+		environmentNames[environment.ID] = environment.Name
+	}
+
+	for _, tenant := range tenants {
+		tenantVariables, err := myclient.Tenants.GetVariables(tenant)
+
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to get the variables for tenant "+tenant.Name), err)
+		}
+
+		for _, libraryVariable := range tenantVariables.LibraryVariables {
+			for _, template := range libraryVariable.Templates {
+				if value, ok := libraryVariable.Variables[template.ID]; ok && value.IsSensitive {
+					skipped = append(skipped, SkippedValue{
+						Category: SkippedTenantVariable,
+						Owner:    tenant.Name + " (" + libraryVariable.LibraryVariableSetName + ")",
+						Name:     template.Name,
+					})
+				}
+			}
+		}
+
+		for _, projectVariable := range tenantVariables.ProjectVariables {
+			for _, template := range projectVariable.Templates {
+				// Project template values are defined for each environment
+				environmentIds := []string{}
+				for environmentId, environmentValues := range projectVariable.Variables {
+					if value, ok := environmentValues[template.ID]; ok && value.IsSensitive {
+						environmentIds = append(environmentIds, environmentId)
+					}
+				}
+
+				if len(environmentIds) == 0 {
+					continue
+				}
+
+				skipped = append(skipped, SkippedValue{
+					Category:     SkippedTenantVariable,
+					Owner:        tenant.Name + " (" + projectVariable.ProjectName + ")",
+					Name:         template.Name,
+					Environments: environmentNamesForIds(environmentIds, environmentNames),
+				})
+			}
+		}
+	}
+
+	return skipped, nil
+}
+
+// environmentNamesForIds returns the sorted names of the environments, falling back to the ID of any environment
+// that could not be found
+func environmentNamesForIds(environmentIds []string, environmentNames map[string]string) []string {
+	names := lo.Map(environmentIds, func(item string, index int) string {
+		if name, ok := environmentNames[item]; ok && strings.TrimSpace(name) != "" {
+			return name
+		}
+
+		return item
+	})
+
+	slices.Sort(names)
+
+	return names
+}
+
+// SkippedProject describes a version controlled project whose variables are not spread. The non-sensitive variables
+// of these projects are stored in Git, so the reference variables can not be created next to the sensitive
+// variables stored in Octopus without committing them to a branch.
+type SkippedProject struct {
+	ProjectID   string
+	ProjectName string
+	Reason      string
+}
+
+func (p SkippedProject) String() string {
+	return fmt.Sprintf("%s (%s): %s", p.ProjectName, p.ProjectID, p.Reason)
+}
+
+// FindSkippedProjects returns the version controlled projects with sensitive variables that would otherwise be spread
+func (c *VariableSpreader) FindSkippedProjects() ([]SkippedProject, error) {
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+
+	projects, err := c.api.Projects()
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get all projects"), err)
+	}
+
+	skipped := []SkippedProject{}
+	for _, project := range projects {
+		if !project.IsVersionControlled || slices.Contains(c.ExcludedOwners, project.ID) {
+			continue
+		}
+
+		// The sensitive variables of version controlled projects are still stored in Octopus
+		variableSet, err := c.api.VariableSet(project.VariableSetID)
+
+		if err != nil {
+			skipped = append(skipped, SkippedProject{
+				ProjectID:   project.ID,
+				ProjectName: project.Name,
+				Reason:      "the project is version controlled, and its sensitive variables could not be read: " + err.Error(),
+			})
+			continue
+		}
+
+		groupedVariables, err := c.findSecretVariablesWithSharedName(variableSet)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(groupedVariables) == 0 {
+			continue
+		}
+
+		skipped = append(skipped, SkippedProject{
+			ProjectID:   project.ID,
+			ProjectName: project.Name,
+			Reason: "the project is version controlled, so the scoped sensitive variables " + strings.Join(groupedVariables, ", ") +
+				" were not spread. Give each sensitive variable a unique name and remove its scopes, and commit the variables referencing them to Git.",
+		})
+	}
+
+	return skipped, nil
+}
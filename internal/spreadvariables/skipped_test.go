@@ -0,0 +1,52 @@
+package spreadvariables
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEnvironmentNamesForIds(t *testing.T) {
+	environmentNames := map[string]string{
+		"Environments-1": "Production",
+		"Environments-2": "Development",
+	}
+
+	names := environmentNamesForIds([]string{"Environments-1", "Environments-3", "Environments-2"}, environmentNames)
+	expected := []string{"Development", "Environments-3", "Production"}
+
+	if !slices.Equal(names, expected) {
+		t.Fatalf("Expected the environments %v, got %v", expected, names)
+	}
+}
+
+func TestSkippedValueString(t *testing.T) {
+	value := SkippedValue{
+		Category:     SkippedTenantVariable,
+		Owner:        "Tenant (Project)",
+		Name:         "Password",
+		Environments: []string{"Development", "Production"},
+	}
+
+	expected := "Tenant (Project) in Development, Production: the sensitive Tenant Variable Password can not be spread, and must be extracted from the Octopus database"
+
+	if value.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, value.String())
+	}
+}
+
+func TestFindSkippedProjectsExcludedOwners(t *testing.T) {
+	api := newFakeSpaceApi()
+	api.addProject("Projects-1", "Web", true, newSharedNameVariables("Variables-Web")...)
+	api.addProject("Projects-2", "Api", true, newSharedNameVariables("Variables-Api")...)
+
+	spreader := &VariableSpreader{api: api, ExcludedOwners: []string{"Projects-2"}}
+	skipped, err := spreader.FindSkippedProjects()
+
+	if err != nil {
+		t.Fatalf("Failed to find the skipped projects: %v", err)
+	}
+
+	if len(skipped) != 1 || skipped[0].ProjectID != "Projects-1" {
+		t.Fatalf("Expected only the project that was not excluded to be reported, got %v", skipped)
+	}
+}
@@ -0,0 +1,174 @@
+package spreadvariables
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+	"github.com/samber/lo"
+)
+
+// UnspreadResult describes a reference variable created by spreading a sensitive variable
+type UnspreadResult struct {
+	OwnerID      string
+	VariableName string
+	// Error is the reason the sensitive variable could not be restored, or empty if it was restored
+	Error string
+}
+
+func (r UnspreadResult) Failed() bool {
+	return r.Error != ""
+}
+
+// UnspreadReport lists the sensitive variables restored by UnspreadAllVariables
+type UnspreadReport struct {
+	Results []UnspreadResult
+}
+
+// Failures returns the sensitive variables that could not be restored
+func (r UnspreadReport) Failures() []UnspreadResult {
+	return lo.Filter(r.Results, func(item UnspreadResult, index int) bool {
+		return item.Failed()
+	})
+}
+
+// String summarizes the number of variables restored, and lists the failures
+func (r UnspreadReport) String() string {
+	var builder strings.Builder
+	failures := r.Failures()
+
+	builder.WriteString(fmt.Sprintf("%d sensitive variables restored, %d failed\n", len(r.Results)-len(failures), len(failures)))
+
+	for _, failure := range failures {
+		builder.WriteString(fmt.Sprintf("Failed to restore %s in %s: %s\n", failure.VariableName, failure.OwnerID, failure.Error))
+	}
+
+	return builder.String()
+}
+
+// spreadMetadata is the information recorded in the description of a reference variable by spreadVariables
+type spreadMetadata struct {
+	ReplacedVariableID string
+	OriginalScope      variables.VariableScope
+}
+
+// parseSpreadMetadata returns the metadata recorded in the description of a reference variable. The boolean is
+// false if the description was not written by spreadVariables.
+func parseSpreadMetadata(description string) (spreadMetadata, bool, error) {
+	replacedIndex := strings.LastIndex(description, replacedVariableHeading)
+
+	if replacedIndex == -1 {
+		return spreadMetadata{}, false, nil
+	}
+
+	metadata := strings.SplitN(description[replacedIndex+len(replacedVariableHeading):], originalScopeHeading, 2)
+
+	if len(metadata) != 2 || strings.TrimSpace(metadata[0]) == "" {
+		return spreadMetadata{}, true, errors.New("the description does not record the replaced variable and its original scope")
+	}
+
+	scope := variables.VariableScope{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(metadata[1])), &scope); err != nil {
+		return spreadMetadata{}, true, errors.Join(errors.New("the original scope recorded in the description is invalid"), err)
+	}
+
+	return spreadMetadata{
+		ReplacedVariableID: strings.TrimSpace(metadata[0]),
+		OriginalScope:      scope,
+	}, true, nil
+}
+
+// UnspreadAllVariables undoes SpreadAllVariables. Each reference variable is deleted after the sensitive variable
+// it refers to is given the original name and scope again. Variables that can not be restored are reported rather
+// than stopping the process, and running it again retries them.
+func (c *VariableSpreader) UnspreadAllVariables() (UnspreadReport, error) {
+	if err := c.connect(); err != nil {
+		return UnspreadReport{}, err
+	}
+
+	// Library variable sets and version controlled projects are always checked, as they may have been spread by a
+	// migration with different options or before the project was converted to version control
+	variableSets, err := c.getVariableSets(true, true)
+
+	if err != nil {
+		return UnspreadReport{}, err
+	}
+
+	report := UnspreadReport{}
+	for _, variableSet := range variableSets {
+		report.Results = append(report.Results, c.unspreadVariables(variableSet.OwnerID, variableSet.VariableSet)...)
+	}
+
+	return report, nil
+}
+
+func (c *VariableSpreader) unspreadVariables(ownerId string, variableSet *variables.VariableSet) []UnspreadResult {
+	results := []UnspreadResult{}
+
+	for _, referenceVar := range variableSet.Variables {
+		// Reference variables are regular variables
+		if referenceVar.IsSensitive || referenceVar.Type != "String" {
+			continue
+		}
+
+		metadata, ok, err := parseSpreadMetadata(referenceVar.Description)
+
+		if !ok {
+			continue
+		}
+
+		if err == nil {
+			err = c.restoreVariable(ownerId, variableSet, referenceVar, metadata)
+		}
+
+		result := UnspreadResult{OwnerID: ownerId, VariableName: referenceVar.Name}
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// restoreVariable gives the sensitive variable the name of the reference variable and its original scope, and then
+// deletes the reference variable. The sensitive variable is restored first so a failure to delete the reference
+// variable can be retried.
+func (c *VariableSpreader) restoreVariable(ownerId string, variableSet *variables.VariableSet, referenceVar *variables.Variable, metadata spreadMetadata) error {
+	sensitiveVar, found := lo.Find(variableSet.Variables, func(item *variables.Variable) bool {
+		return item.ID == metadata.ReplacedVariableID
+	})
+
+	if !found {
+		return errors.New("the sensitive variable " + metadata.ReplacedVariableID + " no longer exists")
+	}
+
+	if !(sensitiveVar.IsSensitive && sensitiveVar.Type == "Sensitive") {
+		return errors.New("the variable " + sensitiveVar.Name + " is no longer sensitive")
+	}
+
+	if sensitiveVar.Value != nil {
+		return errors.New("the value of the sensitive variable " + sensitiveVar.Name + " must be empty, otherwise the sensitive value may be overwritten")
+	}
+
+	fmt.Println("Renaming " + sensitiveVar.Name + " to " + referenceVar.Name + " and restoring scopes for " + ownerId)
+
+	restoredVar := *sensitiveVar
+	restoredVar.Name = referenceVar.Name
+	restoredVar.Scope = metadata.OriginalScope
+
+	if err := c.api.UpdateVariable(ownerId, &restoredVar); err != nil {
+		return errors.Join(errors.New("failed to restore the sensitive variable "+sensitiveVar.Name), err)
+	}
+
+	fmt.Println("Deleting " + referenceVar.Name + " referencing " + sensitiveVar.Name + " for " + ownerId)
+
+	if err := c.api.DeleteVariable(ownerId, referenceVar.ID); err != nil {
+		return errors.Join(errors.New("failed to delete the reference variable "+referenceVar.Name), err)
+	}
+
+	return nil
+}
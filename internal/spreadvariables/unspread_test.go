@@ -0,0 +1,124 @@
+package spreadvariables
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+	"github.com/samber/lo"
+)
+
+func TestParseSpreadMetadata(t *testing.T) {
+	scope := variables.VariableScope{Environments: []string{"Environments-1", "Environments-2"}}
+	jsonData, err := json.Marshal(scope)
+
+	if err != nil {
+		t.Fatalf("Failed to marshal the scope: %v", err)
+	}
+
+	description := "A connection string" + replacedVariableHeading + "Variables-1" + originalScopeHeading + string(jsonData)
+	metadata, ok, err := parseSpreadMetadata(description)
+
+	if !ok || err != nil {
+		t.Fatalf("Expected the metadata to be parsed, got %v", err)
+	}
+
+	if metadata.ReplacedVariableID != "Variables-1" {
+		t.Fatalf("Expected the replaced variable Variables-1, got %s", metadata.ReplacedVariableID)
+	}
+
+	if !slices.Equal(metadata.OriginalScope.Environments, scope.Environments) {
+		t.Fatalf("Expected the environments %v, got %v", scope.Environments, metadata.OriginalScope.Environments)
+	}
+}
+
+func TestParseSpreadMetadataInvalid(t *testing.T) {
+	if _, ok, _ := parseSpreadMetadata("A regular variable"); ok {
+		t.Fatalf("Expected a regular variable to be ignored")
+	}
+
+	if _, ok, err := parseSpreadMetadata(replacedVariableHeading + "Variables-1"); !ok || err == nil {
+		t.Fatalf("Expected an error for a description without the original scope")
+	}
+
+	if _, ok, err := parseSpreadMetadata(replacedVariableHeading + "Variables-1" + originalScopeHeading + "{"); !ok || err == nil {
+		t.Fatalf("Expected an error for an invalid scope")
+	}
+}
+
+func newSharedNameVariables(prefix string) []*variables.Variable {
+	return []*variables.Variable{
+		newSensitiveVariable(prefix+"-1", "ConnectionString", variables.VariableScope{}),
+		newSensitiveVariable(prefix+"-2", "ConnectionString", variables.VariableScope{Environments: []string{"Environments-1"}}),
+		newSensitiveVariable(prefix+"-3", "ConnectionString", variables.VariableScope{Environments: []string{"Environments-2"}}),
+	}
+}
+
+func TestSpreadAndUnspreadVersionControlledProject(t *testing.T) {
+	api := newFakeSpaceApi()
+	project := api.addProject("Projects-1", "Web", false, newSharedNameVariables("Variables-Web")...)
+	api.addProject("Projects-2", "Api", true, newSharedNameVariables("Variables-Api")...)
+
+	spreader := &VariableSpreader{api: api}
+
+	if err := spreader.SpreadAllVariables(); err != nil {
+		t.Fatalf("Failed to spread the variables: %v", err)
+	}
+
+	if len(api.variableSets["Projects-1"].Variables) != 6 {
+		t.Fatalf("Expected the variables of the project to be spread, got %d variables", len(api.variableSets["Projects-1"].Variables))
+	}
+
+	// The version controlled project must not be spread
+	if len(api.variableSets["Projects-2"].Variables) != 3 || api.requests["UpdateVariable"] != 3 {
+		t.Fatalf("Expected the version controlled project to be left unchanged")
+	}
+
+	// Spreading again must not change anything
+	if err := spreader.SpreadAllVariables(); err != nil {
+		t.Fatalf("Failed to spread the variables: %v", err)
+	}
+
+	if api.requests["AddVariable"] != 3 || api.requests["UpdateVariable"] != 3 {
+		t.Fatalf("Expected spreading a second time to make no changes")
+	}
+
+	// Projects can be converted to version control after they were spread, and must still be restored
+	project.IsVersionControlled = true
+
+	report, err := spreader.UnspreadAllVariables()
+
+	if err != nil {
+		t.Fatalf("Failed to undo the spreading: %v", err)
+	}
+
+	if len(report.Results) != 3 || len(report.Failures()) != 0 {
+		t.Fatalf("Expected 3 restored variables, got %v", report.String())
+	}
+
+	for _, original := range newSharedNameVariables("Variables-Web") {
+		restored, found := lo.Find(api.variableSets["Projects-1"].Variables, func(item *variables.Variable) bool {
+			return item.ID == original.ID
+		})
+
+		if !found || restored.Name != original.Name || !restored.IsSensitive || !slices.Equal(restored.Scope.Environments, original.Scope.Environments) {
+			t.Fatalf("Expected the variable %s to be restored", original.ID)
+		}
+	}
+
+	if len(api.variableSets["Projects-1"].Variables) != 3 {
+		t.Fatalf("Expected the reference variables to be deleted, got %d variables", len(api.variableSets["Projects-1"].Variables))
+	}
+
+	// Undoing again must not change anything
+	report, err = spreader.UnspreadAllVariables()
+
+	if err != nil {
+		t.Fatalf("Failed to undo the spreading: %v", err)
+	}
+
+	if len(report.Results) != 0 {
+		t.Fatalf("Expected no variables to be restored, got %v", report.String())
+	}
+}
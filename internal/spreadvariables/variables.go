@@ -4,13 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/channels"
-	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/client"
-	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/deployments"
-	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/environments"
-	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/machines"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
-	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/runbooks"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
 	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
 	"github.com/mcasperson/OctoterraWizard/internal/state"
@@ -19,15 +13,54 @@ import (
 	"strings"
 )
 
+// The headings appended to the description of the reference variables created by spreadVariables. They record the
+// sensitive variable that was renamed, and its original scope, allowing the spreading to be undone.
+const (
+	replacedVariableHeading = "\n\nReplaced variable ID\n\n"
+	originalScopeHeading    = "\n\nOriginal Scope\n\n"
+)
+
 type OwnerVariablePair struct {
 	OwnerID     string
+	OwnerName   string
 	VariableSet *variables.VariableSet
 }
 
+// SpreadChange describes a sensitive variable that is renamed and unscoped when it is spread, and the reference
+// variable that is created with the original name and scope
+type SpreadChange struct {
+	OwnerID      string
+	OwnerName    string
+	VariableID   string
+	OriginalName string
+	NewName      string
+}
+
+// Reference is the value of the reference variable
+func (c SpreadChange) Reference() string {
+	return "#{" + c.NewName + "}"
+}
+
+func (c SpreadChange) String() string {
+	return fmt.Sprintf("%s: rename the sensitive variable %s (%s) to %s and remove its scopes, and create the variable %s with the value %s",
+		c.OwnerName, c.OriginalName, c.VariableID, c.NewName, c.OriginalName, c.Reference())
+}
+
 type VariableSpreader struct {
-	State  state.State
-	cache  map[string]map[string]string
-	client *client.Client
+	State state.State
+	// ExcludedOwners are the IDs of the projects and library variable sets whose variables are not spread
+	ExcludedOwners []string
+	// ExcludedVariables are the IDs of the sensitive variables that are not spread
+	ExcludedVariables []string
+	// cache maps the IDs used as variable scopes to names, and is shared by every variable set in the run
+	cache map[string]map[string]string
+	// projects indexes the projects in the space by ID, and processesLoaded records the projects whose
+	// deployment process actions have been added to the cache
+	projects        map[string]*projects.Project
+	processesLoaded map[string]bool
+	// lookupRequests counts the API requests made to find the names of the scopes
+	lookupRequests int
+	api            spaceApi
 }
 
 func (c *VariableSpreader) findSecretVariablesWithSharedName(variableSet *variables.VariableSet) ([]string, error) {
@@ -58,24 +91,113 @@ func (c *VariableSpreader) findSecretVariablesWithSharedName(variableSet *variab
 	return groupedVariables, nil
 }
 
-// populateCache returns names for all the IDs used as variable scopes.
+// loadCache builds the index of the names of the resources used as variable scopes. The index is loaded once with
+// bulk requests and reused for every variable set in the run. Deployment processes are only loaded for projects
+// with variables scoped to actions.
+func (c *VariableSpreader) loadCache() error {
+	if c.cache != nil {
+		return nil
+	}
+
+	cache := map[string]map[string]string{}
+	cache["Environments"] = map[string]string{}
+	cache["Machines"] = map[string]string{}
+	cache["Channels"] = map[string]string{}
+	cache["ProcessOwners"] = map[string]string{}
+	cache["Actions"] = map[string]string{}
+
+	for _, scope := range []struct{ scopeType, cacheKey string }{
+		{scopeEnvironments, "Environments"},
+		{scopeMachines, "Machines"},
+		{scopeChannels, "Channels"},
+		{scopeRunbooks, "ProcessOwners"},
+	} {
+		c.lookupRequests++
+		names, err := c.api.ScopeNames(scope.scopeType)
+
+		if err != nil {
+			return errors.Join(errors.New("failed to get all "+strings.ToLower(scope.scopeType)), err)
+		}
+
+		for id, name := range names {
+			cache[scope.cacheKey][id] = name
+		}
+	}
+
+	c.lookupRequests++
+	allProjects, err := c.api.Projects()
+
+	if err != nil {
+		return errors.Join(errors.New("failed to get all projects"), err)
+	}
+
+	c.projects = map[string]*projects.Project{}
+	for _, resource := range allProjects {
+		cache["ProcessOwners"][resource.ID] = resource.Name
+		c.projects[resource.ID] = resource
+	}
+
+	c.processesLoaded = map[string]bool{}
+	c.cache = cache
+
+	return nil
+}
+
+// loadActions adds the names of the actions in the deployment process of the project to the cache
+func (c *VariableSpreader) loadActions(projectId string) error {
+	if c.processesLoaded[projectId] {
+		return nil
+	}
+
+	project, ok := c.projects[projectId]
+
+	if !ok {
+		c.lookupRequests++
+		resource, err := c.api.Project(projectId)
+
+		if err != nil {
+			return err
+		}
+
+		project = resource
+		c.projects[projectId] = project
+	}
+
+	c.lookupRequests++
+	deploymentProcess, err := c.api.DeploymentProcess(project.DeploymentProcessID)
+
+	if err != nil {
+		return err
+	}
+
+	for _, step := range deploymentProcess.Steps {
+		for _, action := range step.Actions {
+			c.cache["Actions"][action.ID] = action.Name
+		}
+	}
+
+	c.processesLoaded[projectId] = true
+
+	return nil
+}
+
+// populateCache ensures the cache holds names for all the IDs used as the scopes of the variable. Resources missing
+// from the index loaded by loadCache, such as those created during the run, are looked up individually.
 func (c *VariableSpreader) populateCache(variable *variables.Variable, parent *variables.VariableSet) error {
-	c.cache = map[string]map[string]string{}
-	c.cache["Environments"] = map[string]string{}
-	c.cache["Machines"] = map[string]string{}
-	c.cache["Channels"] = map[string]string{}
-	c.cache["ProcessOwners"] = map[string]string{}
-	c.cache["Actions"] = map[string]string{}
+	if err := c.loadCache(); err != nil {
+		return err
+	}
 
 	for _, resourceId := range variable.Scope.Environments {
 		if _, ok := c.cache["Environments"][resourceId]; ok {
 			continue
 		}
 
-		if resource, err := environments.GetByID(c.client, c.client.GetSpaceID(), resourceId); err != nil {
+		c.lookupRequests++
+		if name, err := c.api.ScopeName(scopeEnvironments, resourceId); err != nil {
 			return err
 		} else {
-			c.cache["Environments"][resourceId] = resource.Name
+			c.cache["Environments"][resourceId] = name
 		}
 	}
 
@@ -84,10 +206,11 @@ func (c *VariableSpreader) populateCache(variable *variables.Variable, parent *v
 			continue
 		}
 
-		if resource, err := machines.GetByID(c.client, c.client.GetSpaceID(), resourceId); err != nil {
+		c.lookupRequests++
+		if name, err := c.api.ScopeName(scopeMachines, resourceId); err != nil {
 			return err
 		} else {
-			c.cache["Machines"][resourceId] = resource.Name
+			c.cache["Machines"][resourceId] = name
 		}
 	}
 
@@ -96,10 +219,11 @@ func (c *VariableSpreader) populateCache(variable *variables.Variable, parent *v
 			continue
 		}
 
-		if resource, err := channels.GetByID(c.client, c.client.GetSpaceID(), resourceId); err != nil {
+		c.lookupRequests++
+		if name, err := c.api.ScopeName(scopeChannels, resourceId); err != nil {
 			return err
 		} else {
-			c.cache["Channels"][resourceId] = resource.Name
+			c.cache["Channels"][resourceId] = name
 		}
 	}
 
@@ -108,31 +232,13 @@ func (c *VariableSpreader) populateCache(variable *variables.Variable, parent *v
 			continue
 		}
 
-		project, err := projects.GetByID(c.client, c.client.GetSpaceID(), parent.OwnerID)
-
-		if err != nil {
-			return err
-		}
-
-		deploymentProcess, err := deployments.GetDeploymentProcessByID(c.client, c.client.GetSpaceID(), project.DeploymentProcessID)
-
-		if err != nil {
+		if err := c.loadActions(parent.OwnerID); err != nil {
 			return err
 		}
 
-		actions := lo.FlatMap(deploymentProcess.Steps, func(item *deployments.DeploymentStep, index int) []*deployments.DeploymentAction {
-			return item.Actions
-		})
-
-		action := lo.Filter(actions, func(item *deployments.DeploymentAction, index int) bool {
-			return item.ID == resourceId
-		})
-
-		if len(action) == 0 {
+		if _, ok := c.cache["Actions"][resourceId]; !ok {
 			return errors.New("Could not find action " + resourceId)
 		}
-
-		c.cache["Actions"][resourceId] = action[0].Name
 	}
 
 	for _, resourceId := range variable.Scope.ProcessOwners {
@@ -140,14 +246,15 @@ func (c *VariableSpreader) populateCache(variable *variables.Variable, parent *v
 			continue
 		}
 
+		c.lookupRequests++
 		if strings.HasPrefix(resourceId, "Runbooks-") {
-			if resource, err := runbooks.GetByID(c.client, c.client.GetSpaceID(), resourceId); err != nil {
+			if name, err := c.api.ScopeName(scopeRunbooks, resourceId); err != nil {
 				return err
 			} else {
-				c.cache["ProcessOwners"][resourceId] = resource.Name
+				c.cache["ProcessOwners"][resourceId] = name
 			}
 		} else {
-			if resource, err := projects.GetByID(c.client, c.client.GetSpaceID(), resourceId); err != nil {
+			if resource, err := c.api.Project(resourceId); err != nil {
 				return err
 			} else {
 				c.cache["ProcessOwners"][resourceId] = resource.Name
@@ -249,20 +356,29 @@ func (c *VariableSpreader) buildUniqueVariableName(variable *variables.Variable,
 	return name, nil
 }
 
-func (c *VariableSpreader) spreadVariables(client *client.Client, ownerId string, variableSet *variables.VariableSet) error {
-	groupedVariables, err := c.findSecretVariablesWithSharedName(variableSet)
+// planSpread returns the sensitive variables in the variable set that are renamed and unscoped by spreadVariables.
+// Excluded owners and variables are left unchanged. The names are built for every sensitive variable that shares a
+// name, including the excluded ones, so excluding a variable does not change the names given to the others.
+func (c *VariableSpreader) planSpread(variableSet OwnerVariablePair) ([]SpreadChange, error) {
+	changes := []SpreadChange{}
+
+	if slices.Contains(c.ExcludedOwners, variableSet.OwnerID) {
+		return changes, nil
+	}
+
+	groupedVariables, err := c.findSecretVariablesWithSharedName(variableSet.VariableSet)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get a list of all the existing variable names. We can't reuse any of these names.
-	usedNames := lo.Uniq(lo.Map(variableSet.Variables, func(item *variables.Variable, index int) string {
+	usedNames := lo.Uniq(lo.Map(variableSet.VariableSet.Variables, func(item *variables.Variable, index int) string {
 		return item.Name
 	}))
 
 	for _, groupedVariable := range groupedVariables {
-		for _, variable := range variableSet.Variables {
+		for _, variable := range variableSet.VariableSet.Variables {
 			if groupedVariable != variable.Name {
 				continue
 			}
@@ -273,140 +389,249 @@ func (c *VariableSpreader) spreadVariables(client *client.Client, ownerId string
 				continue
 			}
 
-			// Copy the original variable
-			originalVar := *variable
-
 			// Lookup things like environments
-			if err := c.populateCache(variable, variableSet); err != nil {
-				return err
+			if err := c.populateCache(variable, variableSet.VariableSet); err != nil {
+				return nil, err
 			}
 
 			// Get a unique name
 			uniqueName, err := c.buildUniqueVariableName(variable, usedNames)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-			// Create a new variable with the original name and scopes referencing the new unscoped variable
-			referenceVar := originalVar
+			usedNames = append(usedNames, uniqueName)
 
-			jsonData, err := json.Marshal(referenceVar.Scope)
-			if err != nil {
-				return err
+			if slices.Contains(c.ExcludedVariables, variable.ID) {
+				continue
 			}
 
-			// Note the original scope of this variable
-			referenceVar.Description += "\n\nReplaced variable ID\n\n" + referenceVar.ID
-			referenceVar.Description += "\n\nOriginal Scope\n\n" + string(jsonData)
+			changes = append(changes, SpreadChange{
+				OwnerID:      variableSet.OwnerID,
+				OwnerName:    variableSet.OwnerName,
+				VariableID:   variable.ID,
+				OriginalName: variable.Name,
+				NewName:      uniqueName,
+			})
+		}
+	}
 
-			referenceVar.IsSensitive = false
-			referenceVar.Type = "String"
-			referenceVar.ID = ""
-			reference := "#{" + uniqueName + "}"
-			referenceVar.Value = &reference
+	return changes, nil
+}
 
-			fmt.Println("Recreating " + referenceVar.Name + " referencing " + reference)
+// planAllVariables returns the variable sets to spread and the changes made to them. The same plan is shown by
+// PreviewAllVariables and applied by SpreadAllVariables.
+func (c *VariableSpreader) planAllVariables() ([]OwnerVariablePair, []SpreadChange, error) {
+	if err := c.connect(); err != nil {
+		return nil, nil, err
+	}
 
-			_, err = variables.AddSingle(client, client.GetSpaceID(), ownerId, &referenceVar)
+	c.resetCache()
 
-			if err != nil {
-				return err
-			}
+	// If we are not exporting library variable sets, we don't need to process them
+	variableSets, err := c.getVariableSets(!c.State.ExcludeAllLibraryVariableSets, false)
 
-			// Update the original variable with the new name and no scopes
-			originalName := variable.Name
-			usedNames = append(usedNames, uniqueName)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			if variable.Value != nil {
-				panic("The value of the variable must be nil here, otherwise we may be overriding sensitive values")
-			}
+	changes := []SpreadChange{}
+	for _, variableSet := range variableSets {
+		ownerChanges, err := c.planSpread(variableSet)
 
-			fmt.Println("Renaming " + originalName + " to " + uniqueName + " and removing scopes for " + ownerId)
+		if err != nil {
+			return nil, nil, errors.Join(errors.New("failed to plan the spreading of variables for "+variableSet.OwnerName), err)
+		}
 
-			jsonData, err = json.Marshal(variable.Scope)
-			if err != nil {
-				return err
-			}
+		changes = append(changes, ownerChanges...)
+	}
+
+	return variableSets, changes, nil
+}
 
-			// Note the original scope of this variable
-			referenceVar.Description += "\n\nOriginal Name\n\n" + variable.Name
-			// Note the original scope of this variable
-			referenceVar.Description += "\n\nOriginal Scope\n\n" + string(jsonData)
+// spreadVariables applies the changes planned for the variable set
+func (c *VariableSpreader) spreadVariables(variableSet OwnerVariablePair, changes []SpreadChange) error {
+	ownerId := variableSet.OwnerID
 
-			variable.Name = uniqueName
-			variable.Scope = variables.VariableScope{}
+	for _, change := range changes {
+		if change.OwnerID != ownerId {
+			continue
+		}
 
-			_, err = variables.UpdateSingle(client, client.GetSpaceID(), ownerId, variable)
+		variable, found := lo.Find(variableSet.VariableSet.Variables, func(item *variables.Variable) bool {
+			return item.ID == change.VariableID
+		})
 
-			if err != nil {
-				return err
-			}
+		if !found {
+			return errors.New("Could not find variable " + change.VariableID)
+		}
+
+		// Copy the original variable
+		originalVar := *variable
+		uniqueName := change.NewName
+
+		// Create a new variable with the original name and scopes referencing the new unscoped variable
+		referenceVar := originalVar
+
+		jsonData, err := json.Marshal(referenceVar.Scope)
+		if err != nil {
+			return err
+		}
+
+		// Note the original scope of this variable
+		referenceVar.Description += replacedVariableHeading + referenceVar.ID
+		referenceVar.Description += originalScopeHeading + string(jsonData)
+
+		referenceVar.IsSensitive = false
+		referenceVar.Type = "String"
+		referenceVar.ID = ""
+		reference := change.Reference()
+		referenceVar.Value = &reference
+
+		fmt.Println("Recreating " + referenceVar.Name + " referencing " + reference)
+
+		err = c.api.AddVariable(ownerId, &referenceVar)
+
+		if err != nil {
+			return err
+		}
+
+		// Update the original variable with the new name and no scopes
+		originalName := variable.Name
+
+		if variable.Value != nil {
+			panic("The value of the variable must be nil here, otherwise we may be overriding sensitive values")
+		}
+
+		fmt.Println("Renaming " + originalName + " to " + uniqueName + " and removing scopes for " + ownerId)
+
+		variable.Name = uniqueName
+		variable.Scope = variables.VariableScope{}
+
+		err = c.api.UpdateVariable(ownerId, variable)
+
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// PreviewAllVariables returns the changes SpreadAllVariables would make, without modifying any variables
+func (c *VariableSpreader) PreviewAllVariables() ([]SpreadChange, error) {
+	_, changes, err := c.planAllVariables()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
 func (c *VariableSpreader) SpreadAllVariables() error {
+	variableSets, changes, err := c.planAllVariables()
+
+	if err != nil {
+		return err
+	}
+
+	for _, variableSet := range variableSets {
+
+		err = c.spreadVariables(variableSet, changes)
+
+		if err != nil {
+			return errors.Join(errors.New("failed to spread variables"), err)
+		}
+	}
+
+	return nil
+}
+
+// LookupRequests returns the number of API requests made by the last preview or spreading to find the names of the
+// variable scopes
+func (c *VariableSpreader) LookupRequests() int {
+	return c.lookupRequests
+}
+
+// connect creates the client used to call the Octopus API, unless an API has already been provided
+func (c *VariableSpreader) connect() error {
+	if c.api != nil {
+		return nil
+	}
+
 	myclient, err := octoclient.CreateClient(c.State)
 
 	if err != nil {
 		return errors.Join(errors.New("failed to create client"), err)
 	}
 
-	c.client = myclient
+	c.api = clientApi{client: myclient}
+
+	return nil
+}
 
-	libraryVariableSets, err := c.client.LibraryVariableSets.GetAll()
+// resetCache discards the names of the scopes loaded by a previous run, which may have used a different client
+func (c *VariableSpreader) resetCache() {
+	c.cache = nil
+	c.projects = nil
+	c.processesLoaded = nil
+	c.lookupRequests = 0
+}
+
+// getVariableSets returns the variable sets of the projects, and optionally the library variable sets, in the space.
+// Version controlled projects are only included when requested, as their variables are not spread, but undoing the
+// spreading must still check them.
+func (c *VariableSpreader) getVariableSets(includeLibraryVariableSets bool, includeVersionControlled bool) ([]OwnerVariablePair, error) {
+	libraryVariableSets, err := c.api.LibraryVariableSets()
 
 	if err != nil {
-		return errors.Join(errors.New("failed to get all library variable sets"), err)
+		return nil, errors.Join(errors.New("failed to get all library variable sets"), err)
 	}
 
-	projects, err := c.client.Projects.GetAll()
+	projects, err := c.api.Projects()
 
 	if err != nil {
-		return errors.Join(errors.New("failed to get all projects"), err)
+		return nil, errors.Join(errors.New("failed to get all projects"), err)
 	}
 
 	variableSets := []OwnerVariablePair{}
 
-	// If we are not exporting library variable sets, we don't need to process them
-	if !c.State.ExcludeAllLibraryVariableSets {
+	if includeLibraryVariableSets {
 		for _, libraryVariableSet := range libraryVariableSets {
-			variableSet, err := variables.GetVariableSet(c.client, c.client.GetSpaceID(), libraryVariableSet.VariableSetID)
+			variableSet, err := c.api.VariableSet(libraryVariableSet.VariableSetID)
 
 			if err != nil {
-				return errors.New("failed to get variable set for library variable set " + libraryVariableSet.Name + ". Error was \"" + err.Error() + "\"")
+				return nil, errors.New("failed to get variable set for library variable set " + libraryVariableSet.Name + ". Error was \"" + err.Error() + "\"")
 			}
 
 			variableSets = append(variableSets, OwnerVariablePair{
 				OwnerID:     libraryVariableSet.ID,
+				OwnerName:   libraryVariableSet.Name,
 				VariableSet: variableSet,
 			})
 		}
 	}
 
 	for _, project := range projects {
-		variableSet, err := variables.GetVariableSet(c.client, c.client.GetSpaceID(), project.VariableSetID)
+		// The variables of version controlled projects are split between Git and Octopus, so they are not spread,
+		// and are reported by FindSkippedProjects instead
+		if project.IsVersionControlled && !includeVersionControlled {
+			continue
+		}
+
+		variableSet, err := c.api.VariableSet(project.VariableSetID)
 
 		if err != nil {
-			return errors.New("Failed to get variable set for project " + project.Name + ". Error was \"" + err.Error() + "\"")
+			return nil, errors.New("Failed to get variable set for project " + project.Name + ". Error was \"" + err.Error() + "\"")
 		}
 
 		variableSets = append(variableSets, OwnerVariablePair{
 			OwnerID:     project.ID,
+			OwnerName:   project.Name,
 			VariableSet: variableSet,
 		})
 	}
 
-	for _, variableSet := range variableSets {
-
-		err = c.spreadVariables(c.client, variableSet.OwnerID, variableSet.VariableSet)
-
-		if err != nil {
-			return errors.Join(errors.New("failed to spread variables"), err)
-		}
-	}
-
-	return nil
+	return variableSets, nil
 }
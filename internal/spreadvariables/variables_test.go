@@ -0,0 +1,216 @@
+package spreadvariables
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+	"github.com/samber/lo"
+)
+
+// newTestSpreader returns a spreader whose cache is already loaded, so planning does not call the API
+func newTestSpreader() *VariableSpreader {
+	return &VariableSpreader{
+		cache: map[string]map[string]string{
+			"Environments":  {"Environments-1": "Development", "Environments-2": "Production"},
+			"Machines":      {},
+			"Channels":      {},
+			"ProcessOwners": {},
+			"Actions":       {},
+		},
+		processesLoaded: map[string]bool{},
+	}
+}
+
+func newSensitiveVariable(id string, name string, scope variables.VariableScope) *variables.Variable {
+	variable := variables.NewVariable(name)
+	variable.ID = id
+	variable.IsSensitive = true
+	variable.Type = "Sensitive"
+	variable.Scope = scope
+	return variable
+}
+
+// newTestVariableSet returns a variable set where the names built for Variables-2 and Variables-3 collide
+func newTestVariableSet() OwnerVariablePair {
+	return OwnerVariablePair{
+		OwnerID:   "Projects-1",
+		OwnerName: "My Project",
+		VariableSet: &variables.VariableSet{
+			OwnerID: "Projects-1",
+			Variables: []*variables.Variable{
+				newSensitiveVariable("Variables-1", "ConnectionString", variables.VariableScope{}),
+				newSensitiveVariable("Variables-2", "ConnectionString", variables.VariableScope{Environments: []string{"Environments-1"}}),
+				newSensitiveVariable("Variables-3", "ConnectionString", variables.VariableScope{Roles: []string{"Development"}}),
+				newSensitiveVariable("Variables-4", "ConnectionString", variables.VariableScope{Environments: []string{"Environments-2"}}),
+				newSensitiveVariable("Variables-5", "ApiKey", variables.VariableScope{}),
+			},
+		},
+	}
+}
+
+func changeNames(changes []SpreadChange) map[string]string {
+	return lo.SliceToMap(changes, func(item SpreadChange) (string, string) {
+		return item.VariableID, item.NewName
+	})
+}
+
+func TestPlanSpread(t *testing.T) {
+	changes, err := newTestSpreader().planSpread(newTestVariableSet())
+
+	if err != nil {
+		t.Fatalf("Failed to plan the spreading: %v", err)
+	}
+
+	expected := map[string]string{
+		"Variables-1": "ConnectionString_Unscoped",
+		"Variables-2": "ConnectionString_Development",
+		"Variables-3": "ConnectionString_Development_1",
+		"Variables-4": "ConnectionString_Production",
+	}
+
+	if names := changeNames(changes); !lo.ElementsMatch(lo.Entries(names), lo.Entries(expected)) {
+		t.Fatalf("Expected the names %v, got %v", expected, names)
+	}
+
+	for _, change := range changes {
+		if change.OwnerID != "Projects-1" || change.OwnerName != "My Project" || change.OriginalName != "ConnectionString" {
+			t.Fatalf("Expected the change to record the owner and original name, got %v", change)
+		}
+	}
+}
+
+func TestPlanSpreadExcludedOwners(t *testing.T) {
+	spreader := newTestSpreader()
+	spreader.ExcludedOwners = []string{"Projects-1"}
+
+	changes, err := spreader.planSpread(newTestVariableSet())
+
+	if err != nil {
+		t.Fatalf("Failed to plan the spreading: %v", err)
+	}
+
+	if len(changes) != 0 {
+		t.Fatalf("Expected no changes for an excluded owner, got %v", changes)
+	}
+}
+
+func TestPlanSpreadExcludedVariables(t *testing.T) {
+	allChanges, err := newTestSpreader().planSpread(newTestVariableSet())
+
+	if err != nil {
+		t.Fatalf("Failed to plan the spreading: %v", err)
+	}
+
+	spreader := newTestSpreader()
+	spreader.ExcludedVariables = []string{"Variables-2"}
+
+	changes, err := spreader.planSpread(newTestVariableSet())
+
+	if err != nil {
+		t.Fatalf("Failed to plan the spreading: %v", err)
+	}
+
+	names := changeNames(changes)
+
+	if _, ok := names["Variables-2"]; ok {
+		t.Fatalf("Expected the excluded variable to be left unchanged")
+	}
+
+	if len(changes) != len(allChanges)-1 {
+		t.Fatalf("Expected %d changes, got %d", len(allChanges)-1, len(changes))
+	}
+
+	// Excluding a variable must not change the names given to the others, so the preview matches what is applied
+	for id, name := range names {
+		if changeNames(allChanges)[id] != name {
+			t.Fatalf("Expected %s to be named %s, got %s", id, changeNames(allChanges)[id], name)
+		}
+	}
+}
+
+func TestPlanSpreadIsStable(t *testing.T) {
+	spreader := newTestSpreader()
+	variableSet := newTestVariableSet()
+
+	first, err := spreader.planSpread(variableSet)
+
+	if err != nil {
+		t.Fatalf("Failed to plan the spreading: %v", err)
+	}
+
+	second, err := spreader.planSpread(variableSet)
+
+	if err != nil {
+		t.Fatalf("Failed to plan the spreading: %v", err)
+	}
+
+	if !lo.ElementsMatch(first, second) {
+		t.Fatalf("Expected the same plan each time, got %v and %v", first, second)
+	}
+}
+
+func TestGetVariableSetsVersionControlled(t *testing.T) {
+	api := newFakeSpaceApi()
+	api.addProject("Projects-1", "Web", false)
+	api.addProject("Projects-2", "Api", true)
+	api.addLibraryVariableSet("LibraryVariableSets-1", "Shared")
+
+	spreader := &VariableSpreader{api: api}
+
+	ownerIds := func(includeLibraryVariableSets bool, includeVersionControlled bool) []string {
+		variableSets, err := spreader.getVariableSets(includeLibraryVariableSets, includeVersionControlled)
+
+		if err != nil {
+			t.Fatalf("Failed to get the variable sets: %v", err)
+		}
+
+		return lo.Map(variableSets, func(item OwnerVariablePair, index int) string {
+			return item.OwnerID
+		})
+	}
+
+	if owners := ownerIds(true, false); !lo.ElementsMatch(owners, []string{"LibraryVariableSets-1", "Projects-1"}) {
+		t.Fatalf("Expected the version controlled project to be skipped, got %v", owners)
+	}
+
+	if owners := ownerIds(false, true); !lo.ElementsMatch(owners, []string{"Projects-1", "Projects-2"}) {
+		t.Fatalf("Expected the version controlled project to be included, got %v", owners)
+	}
+}
+
+func TestLookupRequestsDoNotGrowWithVariableSets(t *testing.T) {
+	lookupRequests := func(projectCount int) (int, int) {
+		api := newFakeSpaceApi()
+		for i := 0; i < projectCount; i++ {
+			id := fmt.Sprintf("Projects-%d", i)
+			api.addProject(id, "Project "+id, false, newSharedNameVariables("Variables-"+id)...)
+			api.addLibraryVariableSet(fmt.Sprintf("LibraryVariableSets-%d", i), "Shared "+id, newSharedNameVariables("Variables-Library-"+id)...)
+		}
+
+		spreader := &VariableSpreader{api: api}
+		changes, err := spreader.PreviewAllVariables()
+
+		if err != nil {
+			t.Fatalf("Failed to preview the spreading: %v", err)
+		}
+
+		if len(changes) != projectCount*6 {
+			t.Fatalf("Expected %d changes, got %d", projectCount*6, len(changes))
+		}
+
+		return api.lookupRequests(), spreader.LookupRequests()
+	}
+
+	apiRequests, spreaderRequests := lookupRequests(1)
+
+	for _, projectCount := range []int{5, 50} {
+		if requests, _ := lookupRequests(projectCount); requests != apiRequests {
+			t.Fatalf("Expected %d requests to look up the scopes of %d projects, got %d", apiRequests, projectCount, requests)
+		}
+
+		if _, requests := lookupRequests(projectCount); requests != spreaderRequests {
+			t.Fatalf("Expected %d requests to be reported for %d projects, got %d", spreaderRequests, projectCount, requests)
+		}
+	}
+}
@@ -14,6 +14,14 @@ type State struct {
 	AwsSecretKey                  string
 	AwsS3Bucket                   string
 	AwsS3BucketRegion             string
+	AwsS3Endpoint                 string
+	AwsS3UsePathStyle             bool
+	AwsCaBundle                   string
+	AwsProfile                    string
+	AwsRoleArn                    string
+	AwsRoleSessionName            string
+	AwsRoleExternalId             string
+	AwsWebIdentityTokenFile       string
 	PromptForDelete               bool
 	UseContainerImages            bool
 	AzureResourceGroupName        string
@@ -23,9 +31,29 @@ type State struct {
 	AzureTenantId                 string
 	AzureApplicationId            string
 	AzurePassword                 string
+	AzureAuthType                 string
+	AzureCertificatePath          string
+	AzureCertificatePassword      string
+	AzureFederatedTokenFile       string
+	AzureLocation                 string
+	CreateBackendStorage          bool
+	GoogleCloudBucket             string
+	GoogleCloudCredentials        string
+	HttpAddress                   string
+	HttpUsername                  string
+	HttpPassword                  string
+	PostgresConnectionString      string
 	ExcludeAllLibraryVariableSets bool
 	EnableVariableSpreading       bool
 	EnableProjectRenaming         bool
+	IncludeProjectGroups          []string
+	ExcludeProjectGroups          []string
+	IncludeProjectNamePattern     string
+	ExcludeProjectNamePattern     string
+	IncludeProjectIds             []string
+	ExcludeProjectIds             []string
+	MaxConcurrentRunbooks         int
+	TaskTimeoutMinutes            int
 
 	DatabaseServer    string
 	DatabaseUser      string
@@ -33,6 +61,19 @@ type State struct {
 	DatabasePort      string
 	DatabaseName      string
 	DatabaseMasterKey string
+	// DatabaseAuthType is one of the sensitivevariables.DatabaseAuthTypes, defaulting to a SQL Server login
+	DatabaseAuthType string
+	// DatabaseInstance is the optional named instance of the database server
+	DatabaseInstance string
+	// DatabaseEncrypt is one of the sensitivevariables.DatabaseEncryptModes
+	DatabaseEncrypt                string
+	DatabaseTrustServerCertificate bool
+	DatabaseAzureTenantId          string
+	DatabaseAzureClientId          string
+	SecretsFile                    string
+	SecretsPassphrase              string
+	// ContinueOnExtractionError skips sensitive values that can not be decrypted rather than failing the extraction
+	ContinueOnExtractionError bool
 }
 
 func (s State) GetExternalServer() string {
@@ -1,8 +1,13 @@
 package steps
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
@@ -21,9 +26,7 @@ func (s AdvancedOptionsStep) GetContainer(parent fyne.Window) *fyne.Container {
 			Wizard:   s.Wizard,
 			BaseStep: BaseStep{State: s.State}})
 	}, func() {
-		s.Wizard.ShowWizardStep(ExtractSecrets{
-			Wizard:   s.Wizard,
-			BaseStep: BaseStep{State: s.State}})
+		s.showNextStep()
 	})
 
 	heading := widget.NewLabel("Advanced Options")
@@ -44,9 +47,101 @@ func (s AdvancedOptionsStep) GetContainer(parent fyne.Window) *fyne.Container {
 		radio.SetSelected("Export all library variable sets")
 	}
 
-	middle := container.New(layout.NewVBoxLayout(), heading, label1, radio)
+	concurrencyLabel := widget.NewLabel("Maximum concurrent project runbooks")
+	concurrency := widget.NewEntry()
+	concurrency.SetText(fmt.Sprint(s.State.MaxConcurrentRunbooks))
+	concurrency.OnChanged = func(value string) {
+		if maxConcurrentRunbooks, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && maxConcurrentRunbooks > 0 {
+			s.State.MaxConcurrentRunbooks = maxConcurrentRunbooks
+		}
+	}
+
+	timeoutLabel := widget.NewLabel("Runbook task timeout (minutes)")
+	timeout := widget.NewEntry()
+	timeout.SetText(fmt.Sprint(s.State.TaskTimeoutMinutes))
+	timeout.OnChanged = func(value string) {
+		if taskTimeoutMinutes, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && taskTimeoutMinutes > 0 {
+			s.State.TaskTimeoutMinutes = taskTimeoutMinutes
+		}
+	}
+
+	formLayout := container.New(layout.NewFormLayout(), concurrencyLabel, concurrency, timeoutLabel, timeout)
+
+	stateLabel := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		Migrating a space again reuses the Terraform state saved by the previous migration.
+		Archive or delete the state to migrate the space from a clean state.
+	`))
+	manageState := widget.NewButton("Manage Terraform State", func() {
+		s.Wizard.ShowWizardStep(TerraformStateStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.State}})
+	})
+
+	if !StateStoreSupported(s.State.BackendType) {
+		stateLabel.SetText(strutil.TrimMultilineWhitespace(`
+			Migrating a space again reuses the Terraform state saved by the previous migration.
+			The ` + NormalizeBackendType(s.State.BackendType) + ` backend has no way to list the saved state,
+			so archive or delete the state with the service that stores it to migrate the space from a clean state.
+		`))
+		manageState.Disable()
+	}
+
+	middle := container.New(layout.NewVBoxLayout(), heading, label1, radio, formLayout, stateLabel, manageState)
 
 	content := container.NewBorder(nil, bottom, nil, nil, middle)
 
 	return content
 }
+
+// showNextStep offers to resume a previous migration of the source space recorded in the checkpoint
+// journal, and otherwise moves to the first step that modifies the space
+func (s AdvancedOptionsStep) showNextStep() {
+	firstStep := func() {
+		s.Wizard.ShowWizardStep(ExtractSecrets{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.State}})
+	}
+
+	journal, err := LoadJournal(s.State)
+
+	if err != nil {
+		fmt.Println(err.Error())
+		firstStep()
+		return
+	}
+
+	if !journal.HasProgress() {
+		firstStep()
+		return
+	}
+
+	nextPhase, incomplete := journal.NextPhase()
+
+	// A completed migration is started again from the beginning
+	if !incomplete {
+		if err := journal.Reset(); err != nil {
+			fmt.Println(err.Error())
+		}
+		firstStep()
+		return
+	}
+
+	dialog.NewConfirm(
+		"Resume the previous migration?",
+		strutil.TrimMultilineWhitespace(`
+			A previous migration of this space to `+s.State.DestinationSpace+` did not complete.
+			Do you want to resume from the "`+phaseName(nextPhase)+`" step?
+			Projects that were already migrated will be skipped.
+			Select "No" to start the migration again.`),
+		func(resume bool) {
+			if resume {
+				s.Wizard.ShowWizardStep(stepForPhase(nextPhase, s.Wizard, s.State))
+				return
+			}
+
+			if err := journal.Reset(); err != nil {
+				fmt.Println(err.Error())
+			}
+			firstStep()
+		}, s.Wizard.Window).Show()
+}
@@ -23,6 +23,14 @@ type AwsTerraformStateStep struct {
 	secretKey *widget.Entry
 	s3Bucket  *widget.Entry
 	s3Region  *widget.Entry
+	endpoint  *widget.Entry
+	pathStyle *widget.Check
+	caBundle  *widget.Entry
+	profile   *widget.Entry
+	roleArn   *widget.Entry
+	session   *widget.Entry
+	external  *widget.Entry
+	webToken  *widget.Entry
 	result    *widget.Label
 	infinite  *widget.ProgressBarInfinite
 	logs      *widget.Entry
@@ -43,6 +51,14 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 		s.secretKey.Disable()
 		s.s3Bucket.Disable()
 		s.s3Region.Disable()
+		s.endpoint.Disable()
+		s.pathStyle.Disable()
+		s.caBundle.Disable()
+		s.profile.Disable()
+		s.roleArn.Disable()
+		s.session.Disable()
+		s.external.Disable()
+		s.webToken.Disable()
 		s.logs.Hide()
 		s.logs.SetText("")
 		s.next.Disable()
@@ -53,6 +69,14 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 		defer s.secretKey.Enable()
 		defer s.s3Bucket.Enable()
 		defer s.s3Region.Enable()
+		defer s.endpoint.Enable()
+		defer s.pathStyle.Enable()
+		defer s.caBundle.Enable()
+		defer s.profile.Enable()
+		defer s.roleArn.Enable()
+		defer s.session.Enable()
+		defer s.external.Enable()
+		defer s.webToken.Enable()
 		defer s.next.Enable()
 		defer s.next.Enable()
 
@@ -62,13 +86,14 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 				fmt.Println("Failed to write error to file")
 			}
 
-			s.result.SetText("🔴 Unable to validate the credentials. Please check the Access Key, Secret Key, S3 Bucket Name, and S3 Bucket Region.")
+			s.result.SetText("🔴 Unable to validate the credentials. Please check the Access Key, Secret Key, Profile, Role, and S3 Bucket Region.")
 			s.logs.SetText(err.Error())
 			s.logs.Show()
 			validationFailed = true
 		}
 
-		if err := validators.TestS3Bucket(s.getState()); err != nil {
+		bucketMissing := false
+		if exists, err := validators.S3BucketExists(s.getState()); err != nil {
 			if err := logutil.WriteTextToFile("aws_terraform_state_error.txt", err.Error()); err != nil {
 				fmt.Println("Failed to write error to file")
 			}
@@ -77,6 +102,10 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 			s.logs.SetText(err.Error())
 			s.logs.Show()
 			validationFailed = true
+		} else if !exists {
+			s.result.SetText("🔴 The S3 bucket " + s.getState().AwsS3Bucket + " does not exist.")
+			validationFailed = true
+			bucketMissing = true
 		}
 
 		nexCallback := func(proceed bool) {
@@ -87,8 +116,48 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 			}
 		}
 
-		if validationFailed {
+		continueAnyway := func() {
 			dialog.NewConfirm("AWS Validation failed", "Validation of the AWS details failed. Do you wish to continue anyway?", nexCallback, s.Wizard.Window).Show()
+		}
+
+		createBucket := func(create bool) {
+			if !create {
+				continueAnyway()
+				return
+			}
+
+			s.result.SetText("🔵 Creating the S3 bucket.")
+			s.infinite.Show()
+			s.next.Disable()
+			s.previous.Disable()
+
+			err := validators.CreateS3Bucket(s.getState())
+
+			s.infinite.Hide()
+			s.next.Enable()
+			s.previous.Enable()
+
+			if err != nil {
+				if err := logutil.WriteTextToFile("aws_terraform_state_error.txt", err.Error()); err != nil {
+					fmt.Println("Failed to write error to file")
+				}
+
+				s.result.SetText("🔴 Unable to create the S3 bucket.")
+				s.logs.SetText(err.Error())
+				s.logs.Show()
+				continueAnyway()
+				return
+			}
+
+			s.result.SetText("🟢 Created the S3 bucket.")
+			nexCallback(true)
+		}
+
+		if bucketMissing {
+			dialog.NewConfirm("Create S3 Bucket", "The S3 bucket "+s.getState().AwsS3Bucket+" does not exist. Do you want to create it? "+
+				"Versioning and encryption are enabled, and public access is blocked.", createBucket, s.Wizard.Window).Show()
+		} else if validationFailed {
+			continueAnyway()
 		} else {
 			nexCallback(true)
 		}
@@ -103,6 +172,13 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 
 	label1 := widget.NewLabel(strutil.TrimMultilineWhitespace(`
 		Terraform manages its state in an S3 bucket in AWS. Please provide the details of the S3 bucket that will be used to store the Terraform state.
+		To use S3 compatible storage, like MinIO, enter the URL of the storage service in the endpoint field. These services usually require path-style addressing.
+		The CA bundle is the path to a PEM file holding any certificate authorities that must be trusted to connect to the endpoint.
+		The CA bundle must also be available at the same path on the workers that run the migration runbooks.
+		Instead of an access key, you can provide the name of an AWS profile, including SSO profiles. The runbooks then use the credentials of the worker.
+		If a role ARN is provided, the access key or profile is used to assume the role, or the web identity token is exchanged for the role credentials.
+		A web identity token creates an OpenID Connect account in Octopus, and the role must trust the Octopus server.
+		If the bucket does not exist, the wizard offers to create it.
 	`))
 
 	linkUrl, _ := url.Parse("https://developer.hashicorp.com/terraform/language/settings/backends/s3")
@@ -140,8 +216,56 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 	s.s3Region.SetPlaceHolder("us-east-1")
 	s.s3Region.SetText(s.State.AwsS3BucketRegion)
 
+	endpointLabel := widget.NewLabel("S3 Endpoint URL (Optional)")
+	s.endpoint = widget.NewEntry()
+	s.endpoint.SetPlaceHolder("https://minio.example.com:9000")
+	s.endpoint.SetText(s.State.AwsS3Endpoint)
+
+	pathStyleLabel := widget.NewLabel("Path-Style Addressing")
+	s.pathStyle = widget.NewCheck("Use path-style addressing", func(value bool) {})
+	s.pathStyle.SetChecked(s.State.AwsS3UsePathStyle)
+
+	caBundleLabel := widget.NewLabel("CA Bundle Path (Optional)")
+	s.caBundle = widget.NewEntry()
+	s.caBundle.SetPlaceHolder("/etc/ssl/certs/minio-ca.pem")
+	s.caBundle.SetText(s.State.AwsCaBundle)
+
+	profileLabel := widget.NewLabel("AWS Profile (Optional)")
+	s.profile = widget.NewEntry()
+	s.profile.SetPlaceHolder("my-sso-profile")
+	s.profile.SetText(s.State.AwsProfile)
+
+	roleArnLabel := widget.NewLabel("Role ARN (Optional)")
+	s.roleArn = widget.NewEntry()
+	s.roleArn.SetPlaceHolder("arn:aws:iam::123456789012:role/terraform-state")
+	s.roleArn.SetText(s.State.AwsRoleArn)
+
+	sessionLabel := widget.NewLabel("Role Session Name (Optional)")
+	s.session = widget.NewEntry()
+	s.session.SetPlaceHolder(validators.DefaultRoleSessionName)
+	s.session.SetText(s.State.AwsRoleSessionName)
+
+	externalLabel := widget.NewLabel("Role External ID (Optional)")
+	s.external = widget.NewEntry()
+	s.external.SetPlaceHolder("")
+	s.external.SetText(s.State.AwsRoleExternalId)
+
+	webTokenLabel := widget.NewLabel("Web Identity Token File (Optional)")
+	s.webToken = widget.NewEntry()
+	s.webToken.SetPlaceHolder("/var/run/secrets/token")
+	s.webToken.SetText(s.State.AwsWebIdentityTokenFile)
+
 	validation := func(input string) {
-		if s.accessKey != nil && s.accessKey.Text != "" && s.secretKey != nil && s.secretKey.Text != "" && s.s3Bucket != nil && s.s3Bucket.Text != "" && s.s3Region != nil && s.s3Region.Text != "" {
+		if s.accessKey == nil || s.secretKey == nil || s.profile == nil || s.roleArn == nil || s.webToken == nil || s.s3Bucket == nil || s.s3Region == nil {
+			next.Disable()
+			return
+		}
+
+		hasAccessKey := s.accessKey.Text != "" && s.secretKey.Text != ""
+		hasProfile := s.profile.Text != ""
+		hasWebIdentity := s.webToken.Text != "" && s.roleArn.Text != ""
+
+		if (hasAccessKey || hasProfile || hasWebIdentity) && s.s3Bucket.Text != "" && s.s3Region.Text != "" {
 			next.Enable()
 		} else {
 			next.Disable()
@@ -154,8 +278,11 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 	s.secretKey.OnChanged = validation
 	s.s3Bucket.OnChanged = validation
 	s.s3Region.OnChanged = validation
+	s.profile.OnChanged = validation
+	s.roleArn.OnChanged = validation
+	s.webToken.OnChanged = validation
 
-	formLayout := container.New(layout.NewFormLayout(), accessKeyLabel, s.accessKey, secretKeyLabel, s.secretKey, s3BucketLabel, s.s3Bucket, apiKeyLabel, s.s3Region)
+	formLayout := container.New(layout.NewFormLayout(), accessKeyLabel, s.accessKey, secretKeyLabel, s.secretKey, s3BucketLabel, s.s3Bucket, apiKeyLabel, s.s3Region, endpointLabel, s.endpoint, pathStyleLabel, s.pathStyle, caBundleLabel, s.caBundle, profileLabel, s.profile, roleArnLabel, s.roleArn, sessionLabel, s.session, externalLabel, s.external, webTokenLabel, s.webToken)
 
 	middle := container.New(layout.NewVBoxLayout(), heading, label1, link, formLayout, s.infinite, s.result, s.logs)
 
@@ -166,34 +293,102 @@ func (s AwsTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container
 
 func (s AwsTerraformStateStep) getState() state.State {
 	return state.State{
-		BackendType:               s.State.BackendType,
-		Server:                    s.State.Server,
-		ServerExternal:            "",
-		ApiKey:                    s.State.ApiKey,
-		Space:                     s.State.Space,
-		DestinationServer:         s.State.DestinationServer,
-		DestinationServerExternal: "",
-		DestinationApiKey:         s.State.DestinationApiKey,
-		DestinationSpace:          s.State.DestinationSpace,
-		AwsAccessKey:              strings.TrimSpace(s.accessKey.Text),
-		AwsSecretKey:              strings.TrimSpace(s.secretKey.Text),
-		AwsS3Bucket:               strings.TrimSpace(s.s3Bucket.Text),
-		AwsS3BucketRegion:         strings.TrimSpace(s.s3Region.Text),
-		PromptForDelete:           s.State.PromptForDelete,
-		UseContainerImages:        s.State.UseContainerImages,
-		AzureResourceGroupName:    s.State.AzureResourceGroupName,
-		AzureStorageAccountName:   s.State.AzureStorageAccountName,
-		AzureContainerName:        s.State.AzureContainerName,
-		AzureSubscriptionId:       s.State.AzureSubscriptionId,
-		AzureTenantId:             s.State.AzureTenantId,
-		AzureApplicationId:        s.State.AzureApplicationId,
-		AzurePassword:             s.State.AzurePassword,
-		DatabaseServer:            s.State.DatabaseServer,
-		DatabaseUser:              s.State.DatabaseUser,
-		DatabasePass:              s.State.DatabasePass,
-		DatabasePort:              s.State.DatabasePort,
-		DatabaseName:              s.State.DatabaseName,
-		DatabaseMasterKey:         s.State.DatabaseMasterKey,
-		EnableProjectRenaming:     s.State.EnableProjectRenaming,
+		BackendType:                    s.State.BackendType,
+		Server:                         s.State.Server,
+		ServerExternal:                 "",
+		ApiKey:                         s.State.ApiKey,
+		Space:                          s.State.Space,
+		DestinationServer:              s.State.DestinationServer,
+		DestinationServerExternal:      "",
+		DestinationApiKey:              s.State.DestinationApiKey,
+		DestinationSpace:               s.State.DestinationSpace,
+		AwsAccessKey:                   strings.TrimSpace(s.accessKey.Text),
+		AwsSecretKey:                   strings.TrimSpace(s.secretKey.Text),
+		AwsS3Bucket:                    strings.TrimSpace(s.s3Bucket.Text),
+		AwsS3BucketRegion:              strings.TrimSpace(s.s3Region.Text),
+		PromptForDelete:                s.State.PromptForDelete,
+		UseContainerImages:             s.State.UseContainerImages,
+		AzureResourceGroupName:         s.State.AzureResourceGroupName,
+		AzureStorageAccountName:        s.State.AzureStorageAccountName,
+		AzureContainerName:             s.State.AzureContainerName,
+		AzureSubscriptionId:            s.State.AzureSubscriptionId,
+		AzureTenantId:                  s.State.AzureTenantId,
+		AzureApplicationId:             s.State.AzureApplicationId,
+		AzurePassword:                  s.State.AzurePassword,
+		DatabaseServer:                 s.State.DatabaseServer,
+		DatabaseUser:                   s.State.DatabaseUser,
+		DatabasePass:                   s.State.DatabasePass,
+		DatabasePort:                   s.State.DatabasePort,
+		DatabaseName:                   s.State.DatabaseName,
+		DatabaseMasterKey:              s.State.DatabaseMasterKey,
+		DatabaseAuthType:               s.State.DatabaseAuthType,
+		DatabaseInstance:               s.State.DatabaseInstance,
+		DatabaseEncrypt:                s.State.DatabaseEncrypt,
+		DatabaseTrustServerCertificate: s.State.DatabaseTrustServerCertificate,
+		DatabaseAzureTenantId:          s.State.DatabaseAzureTenantId,
+		DatabaseAzureClientId:          s.State.DatabaseAzureClientId,
+		EnableProjectRenaming:          s.State.EnableProjectRenaming,
+		IncludeProjectGroups:           s.State.IncludeProjectGroups,
+		ExcludeProjectGroups:           s.State.ExcludeProjectGroups,
+		IncludeProjectNamePattern:      s.State.IncludeProjectNamePattern,
+		ExcludeProjectNamePattern:      s.State.ExcludeProjectNamePattern,
+		IncludeProjectIds:              s.State.IncludeProjectIds,
+		ExcludeProjectIds:              s.State.ExcludeProjectIds,
+		MaxConcurrentRunbooks:          s.State.MaxConcurrentRunbooks,
+		TaskTimeoutMinutes:             s.State.TaskTimeoutMinutes,
+		GoogleCloudBucket:              s.State.GoogleCloudBucket,
+		GoogleCloudCredentials:         s.State.GoogleCloudCredentials,
+		HttpAddress:                    s.State.HttpAddress,
+		HttpUsername:                   s.State.HttpUsername,
+		HttpPassword:                   s.State.HttpPassword,
+		PostgresConnectionString:       s.State.PostgresConnectionString,
+		AwsS3Endpoint:                  strings.TrimRight(strings.TrimSpace(s.endpoint.Text), "/"),
+		AwsS3UsePathStyle:              s.pathStyle.Checked,
+		AwsCaBundle:                    strings.TrimSpace(s.caBundle.Text),
+		AwsProfile:                     strings.TrimSpace(s.profile.Text),
+		AwsRoleArn:                     strings.TrimSpace(s.roleArn.Text),
+		AwsRoleSessionName:             strings.TrimSpace(s.session.Text),
+		AwsRoleExternalId:              strings.TrimSpace(s.external.Text),
+		AwsWebIdentityTokenFile:        strings.TrimSpace(s.webToken.Text),
+		AzureAuthType:                  s.State.AzureAuthType,
+		AzureCertificatePath:           s.State.AzureCertificatePath,
+		AzureCertificatePassword:       s.State.AzureCertificatePassword,
+		AzureFederatedTokenFile:        s.State.AzureFederatedTokenFile,
+		AzureLocation:                  s.State.AzureLocation,
+		CreateBackendStorage:           s.State.CreateBackendStorage,
+		SecretsFile:                    s.State.SecretsFile,
+		SecretsPassphrase:              s.State.SecretsPassphrase,
+		ContinueOnExtractionError:      s.State.ContinueOnExtractionError,
 	}
 }
+
+// AWS credential types used by the runbooks to access the S3 bucket
+const (
+	AwsCredentialsAccessKey   = "AccessKey"
+	AwsCredentialsWebIdentity = "WebIdentity"
+	AwsCredentialsWorker      = "Worker"
+)
+
+// awsCredentialType returns how the runbooks access the S3 bucket. An access key is saved in an Octopus AWS account,
+// a web identity token is replaced by an Octopus OpenID Connect account that assumes the role, and profiles only
+// exist on the local machine, so the runbooks use the credentials of the worker instead.
+func awsCredentialType(state state.State) string {
+	if state.AwsWebIdentityTokenFile != "" && state.AwsRoleArn != "" {
+		return AwsCredentialsWebIdentity
+	}
+
+	if state.AwsProfile == "" && state.AwsAccessKey != "" {
+		return AwsCredentialsAccessKey
+	}
+
+	return AwsCredentialsWorker
+}
+
+// awsRoleSessionName returns the session name used when assuming a role
+func awsRoleSessionName(state state.State) string {
+	if state.AwsRoleSessionName != "" {
+		return state.AwsRoleSessionName
+	}
+
+	return validators.DefaultRoleSessionName
+}
@@ -27,6 +27,12 @@ type AzureTerraformStateStep struct {
 	tenantId           *widget.Entry
 	applicationId      *widget.Entry
 	password           *widget.Entry
+	authType           *widget.Select
+	certificatePath    *widget.Entry
+	certificatePass    *widget.Entry
+	federatedToken     *widget.Entry
+	location           *widget.Entry
+	infinite           *widget.ProgressBarInfinite
 	previous           *widget.Button
 	next               *widget.Button
 	logs               *widget.Entry
@@ -46,6 +52,11 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 		s.storageAccountName.Disable()
 		s.resourceGroupName.Disable()
 		s.password.Disable()
+		s.authType.Disable()
+		s.certificatePath.Disable()
+		s.certificatePass.Disable()
+		s.federatedToken.Disable()
+		s.location.Disable()
 		s.previous.Disable()
 		s.next.Disable()
 		s.logs.Hide()
@@ -58,12 +69,17 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 		defer s.storageAccountName.Enable()
 		defer s.resourceGroupName.Enable()
 		defer s.password.Enable()
+		defer s.authType.Enable()
+		defer s.certificatePath.Enable()
+		defer s.certificatePass.Enable()
+		defer s.federatedToken.Enable()
+		defer s.location.Enable()
 		defer s.previous.Enable()
 		defer s.next.Enable()
 
 		validationFailed := false
 		newState := s.getState()
-		exists, err := validators.AzureContainerExists(newState.AzureTenantId, newState.AzureApplicationId, newState.AzurePassword, newState.AzureStorageAccountName, newState.AzureContainerName)
+		exists, err := validators.AzureContainerExists(newState)
 
 		if err != nil {
 			if err := logutil.WriteTextToFile("azure_terraform_state_error.txt", err.Error()); err != nil {
@@ -79,7 +95,7 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 			validationFailed = true
 		}
 
-		rgExists, err := validators.AzureResourceGroupExists(newState.AzureTenantId, newState.AzureApplicationId, newState.AzureSubscriptionId, newState.AzurePassword, newState.AzureResourceGroupName)
+		rgExists, err := validators.AzureResourceGroupExists(newState)
 
 		if err != nil {
 			if err := logutil.WriteTextToFile("azure_terraform_state_error.txt", err.Error()); err != nil {
@@ -103,8 +119,57 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 			}
 		}
 
-		if validationFailed {
+		continueAnyway := func() {
 			dialog.NewConfirm("Azure Validation failed", "Validation of the Azure details failed. Do you wish to continue anyway?", nextCallback, s.Wizard.Window).Show()
+		}
+
+		// Missing resources can only be identified if the credentials are valid
+		missing := []string{}
+		if validationFailed {
+			if resources, err := validators.MissingAzureResources(newState); err == nil {
+				missing = resources
+			}
+		}
+
+		createStorage := func(create bool) {
+			if !create {
+				continueAnyway()
+				return
+			}
+
+			s.result.SetText("🔵 Creating the Azure " + strings.Join(missing, ", ") + ". This can take a few minutes.")
+			s.infinite.Show()
+			s.next.Disable()
+			s.previous.Disable()
+
+			err := validators.CreateAzureStorage(s.getState())
+
+			s.infinite.Hide()
+			s.next.Enable()
+			s.previous.Enable()
+
+			if err != nil {
+				if err := logutil.WriteTextToFile("azure_terraform_state_error.txt", err.Error()); err != nil {
+					fmt.Println("Failed to write error to file")
+				}
+
+				s.result.SetText("🔴 Unable to create the Azure storage account.")
+				s.logs.SetText(err.Error())
+				s.logs.Show()
+				continueAnyway()
+				return
+			}
+
+			s.result.SetText("🟢 Created the Azure " + strings.Join(missing, ", ") + ".")
+			s.logs.Hide()
+			nextCallback(true)
+		}
+
+		if len(missing) != 0 {
+			dialog.NewConfirm("Create Azure Storage", "The Azure "+strings.Join(missing, ", ")+" do not exist. Do you want to create them? "+
+				"The storage account requires TLS 1.2, blocks public access, and keeps previous versions of the state.", createStorage, s.Wizard.Window).Show()
+		} else if validationFailed {
+			continueAnyway()
 		} else {
 			nextCallback(true)
 		}
@@ -120,6 +185,8 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 	label1 := widget.NewLabel(strutil.TrimMultilineWhitespace(`
 		Terraform manages its state in an storage account in Azure. 
 		Please provide the details of the storage account that will be used to store the Terraform state.
+		The wizard can authenticate with a client secret, a client certificate, a federated OIDC token, or the managed identity of this machine.
+		If the resource group, storage account, or container do not exist, the wizard offers to create them in the location below.
 	`))
 
 	linkUrl, _ := url.Parse("https://developer.hashicorp.com/terraform/language/settings/backends/azurerm")
@@ -133,6 +200,10 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 
 	s.result = widget.NewLabel("")
 
+	s.infinite = widget.NewProgressBarInfinite()
+	s.infinite.Hide()
+	s.infinite.Start()
+
 	subscriptionIdLabel := widget.NewLabel("Azure Subscription ID")
 	s.subscriptionId = widget.NewEntry()
 	s.subscriptionId.SetPlaceHolder("")
@@ -153,6 +224,24 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 	s.password.SetPlaceHolder("")
 	s.password.SetText(s.State.AzurePassword)
 
+	certificatePathLabel := widget.NewLabel("Certificate Path")
+	s.certificatePath = widget.NewEntry()
+	s.certificatePath.SetPlaceHolder("/etc/ssl/private/terraform.pfx")
+	s.certificatePath.SetText(s.State.AzureCertificatePath)
+
+	certificatePassLabel := widget.NewLabel("Certificate Password")
+	s.certificatePass = widget.NewPasswordEntry()
+	s.certificatePass.SetPlaceHolder("")
+	s.certificatePass.SetText(s.State.AzureCertificatePassword)
+
+	federatedTokenLabel := widget.NewLabel("Federated Token File")
+	s.federatedToken = widget.NewEntry()
+	s.federatedToken.SetPlaceHolder("/var/run/secrets/azure/tokens/azure-identity-token")
+	s.federatedToken.SetText(s.State.AzureFederatedTokenFile)
+
+	authHelp := widget.NewLabel("")
+	authHelp.Wrapping = fyne.TextWrapWord
+
 	azureResourceGroupLabel := widget.NewLabel("Azure Resource Group")
 	s.resourceGroupName = widget.NewEntry()
 	s.resourceGroupName.SetPlaceHolder("")
@@ -168,11 +257,27 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 	s.containerName.SetPlaceHolder("my-container")
 	s.containerName.SetText(s.State.AzureContainerName)
 
+	locationLabel := widget.NewLabel("Azure Location (Optional)")
+	s.location = widget.NewEntry()
+	s.location.SetPlaceHolder("australiaeast")
+	s.location.SetText(s.State.AzureLocation)
+
 	validation := func(input string) {
-		if s.subscriptionId != nil && s.subscriptionId.Text != "" &&
-			s.tenantId != nil && s.tenantId.Text != "" &&
-			s.applicationId != nil && s.applicationId.Text != "" &&
-			s.password != nil && s.password.Text != "" &&
+		if s.authType == nil {
+			return
+		}
+
+		authType := azureAuthTypeFromLabel(s.authType.Selected)
+		// A managed identity does not need a tenant or application ID, although an application ID can
+		// be used to select a user-assigned identity.
+		credentialsValid := authType == validators.AzureAuthManagedIdentity ||
+			(s.tenantId.Text != "" && s.applicationId.Text != "" &&
+				(authType != validators.AzureAuthClientSecret || s.password.Text != "") &&
+				(authType != validators.AzureAuthCertificate || s.certificatePath.Text != "") &&
+				(authType != validators.AzureAuthOidc || s.federatedToken.Text != ""))
+
+		if credentialsValid &&
+			s.subscriptionId != nil && s.subscriptionId.Text != "" &&
 			s.resourceGroupName != nil && s.resourceGroupName.Text != "" &&
 			s.storageAccountName != nil && s.storageAccountName.Text != "" &&
 			s.containerName != nil && s.containerName.Text != "" {
@@ -182,6 +287,38 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 		}
 	}
 
+	authFields := map[string][]fyne.CanvasObject{
+		validators.AzureAuthClientSecret: {passwordLabel, s.password},
+		validators.AzureAuthCertificate:  {certificatePathLabel, s.certificatePath, certificatePassLabel, s.certificatePass},
+		validators.AzureAuthOidc:         {federatedTokenLabel, s.federatedToken},
+	}
+
+	authTypeLabel := widget.NewLabel("Authentication")
+	s.authType = widget.NewSelect(azureAuthTypeLabels, func(selected string) {
+		authType := azureAuthTypeFromLabel(selected)
+
+		for fieldsAuthType, fields := range authFields {
+			for _, field := range fields {
+				if fieldsAuthType == authType {
+					field.Show()
+				} else {
+					field.Hide()
+				}
+			}
+		}
+
+		authHelp.SetText(azureAuthHelp[authType])
+
+		if authType == validators.AzureAuthManagedIdentity {
+			applicationIdLabel.SetText("Azure Client ID (Optional)")
+		} else {
+			applicationIdLabel.SetText("Azure Application ID")
+		}
+
+		validation("")
+	})
+	s.authType.SetSelected(azureAuthTypeLabel(validators.AzureAuthType(s.State)))
+
 	validation("")
 
 	s.resourceGroupName.OnChanged = validation
@@ -191,9 +328,13 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 	s.tenantId.OnChanged = validation
 	s.applicationId.OnChanged = validation
 	s.password.OnChanged = validation
+	s.certificatePath.OnChanged = validation
+	s.federatedToken.OnChanged = validation
 
 	formLayout := container.New(
 		layout.NewFormLayout(),
+		authTypeLabel,
+		s.authType,
 		subscriptionIdLabel,
 		s.subscriptionId,
 		tenantIdLabel,
@@ -202,14 +343,22 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 		s.applicationId,
 		passwordLabel,
 		s.password,
+		certificatePathLabel,
+		s.certificatePath,
+		certificatePassLabel,
+		s.certificatePass,
+		federatedTokenLabel,
+		s.federatedToken,
 		azureResourceGroupLabel,
 		s.resourceGroupName,
 		azureStorageAccountNameLabel,
 		s.storageAccountName,
 		azureContainerNameLabel,
-		s.containerName)
+		s.containerName,
+		locationLabel,
+		s.location)
 
-	middle := container.New(layout.NewVBoxLayout(), heading, label1, link, formLayout, s.result, s.logs)
+	middle := container.New(layout.NewVBoxLayout(), heading, label1, link, formLayout, authHelp, s.infinite, s.result, s.logs)
 
 	content := container.NewBorder(nil, bottom, nil, nil, middle)
 
@@ -218,34 +367,101 @@ func (s AzureTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Containe
 
 func (s AzureTerraformStateStep) getState() state.State {
 	return state.State{
-		BackendType:               s.State.BackendType,
-		Server:                    s.State.Server,
-		ServerExternal:            s.State.ServerExternal,
-		ApiKey:                    s.State.ApiKey,
-		Space:                     s.State.Space,
-		DestinationServer:         s.State.DestinationServer,
-		DestinationServerExternal: s.State.DestinationServerExternal,
-		DestinationApiKey:         s.State.DestinationApiKey,
-		DestinationSpace:          s.State.DestinationSpace,
-		AwsAccessKey:              s.State.AwsAccessKey,
-		AwsSecretKey:              s.State.AwsSecretKey,
-		AwsS3Bucket:               s.State.AwsS3Bucket,
-		AwsS3BucketRegion:         s.State.AwsS3BucketRegion,
-		PromptForDelete:           s.State.PromptForDelete,
-		UseContainerImages:        s.State.UseContainerImages,
-		AzureResourceGroupName:    strings.TrimSpace(s.resourceGroupName.Text),
-		AzureStorageAccountName:   strings.TrimSpace(s.storageAccountName.Text),
-		AzureContainerName:        strings.TrimSpace(s.containerName.Text),
-		AzureSubscriptionId:       strings.TrimSpace(s.subscriptionId.Text),
-		AzureTenantId:             strings.TrimSpace(s.tenantId.Text),
-		AzureApplicationId:        strings.TrimSpace(s.applicationId.Text),
-		AzurePassword:             strings.TrimSpace(s.password.Text),
-		DatabaseServer:            s.State.DatabaseServer,
-		DatabaseUser:              s.State.DatabaseUser,
-		DatabasePass:              s.State.DatabasePass,
-		DatabasePort:              s.State.DatabasePort,
-		DatabaseName:              s.State.DatabaseName,
-		DatabaseMasterKey:         s.State.DatabaseMasterKey,
-		EnableProjectRenaming:     s.State.EnableProjectRenaming,
+		BackendType:                    s.State.BackendType,
+		Server:                         s.State.Server,
+		ServerExternal:                 s.State.ServerExternal,
+		ApiKey:                         s.State.ApiKey,
+		Space:                          s.State.Space,
+		DestinationServer:              s.State.DestinationServer,
+		DestinationServerExternal:      s.State.DestinationServerExternal,
+		DestinationApiKey:              s.State.DestinationApiKey,
+		DestinationSpace:               s.State.DestinationSpace,
+		AwsAccessKey:                   s.State.AwsAccessKey,
+		AwsSecretKey:                   s.State.AwsSecretKey,
+		AwsS3Bucket:                    s.State.AwsS3Bucket,
+		AwsS3BucketRegion:              s.State.AwsS3BucketRegion,
+		PromptForDelete:                s.State.PromptForDelete,
+		UseContainerImages:             s.State.UseContainerImages,
+		AzureResourceGroupName:         strings.TrimSpace(s.resourceGroupName.Text),
+		AzureStorageAccountName:        strings.TrimSpace(s.storageAccountName.Text),
+		AzureContainerName:             strings.TrimSpace(s.containerName.Text),
+		AzureSubscriptionId:            strings.TrimSpace(s.subscriptionId.Text),
+		AzureTenantId:                  strings.TrimSpace(s.tenantId.Text),
+		AzureApplicationId:             strings.TrimSpace(s.applicationId.Text),
+		AzurePassword:                  strings.TrimSpace(s.password.Text),
+		AzureAuthType:                  azureAuthTypeFromLabel(s.authType.Selected),
+		AzureCertificatePath:           strings.TrimSpace(s.certificatePath.Text),
+		AzureCertificatePassword:       s.certificatePass.Text,
+		AzureFederatedTokenFile:        strings.TrimSpace(s.federatedToken.Text),
+		AzureLocation:                  strings.TrimSpace(s.location.Text),
+		CreateBackendStorage:           s.State.CreateBackendStorage,
+		SecretsFile:                    s.State.SecretsFile,
+		SecretsPassphrase:              s.State.SecretsPassphrase,
+		ContinueOnExtractionError:      s.State.ContinueOnExtractionError,
+		DatabaseServer:                 s.State.DatabaseServer,
+		DatabaseUser:                   s.State.DatabaseUser,
+		DatabasePass:                   s.State.DatabasePass,
+		DatabasePort:                   s.State.DatabasePort,
+		DatabaseName:                   s.State.DatabaseName,
+		DatabaseMasterKey:              s.State.DatabaseMasterKey,
+		DatabaseAuthType:               s.State.DatabaseAuthType,
+		DatabaseInstance:               s.State.DatabaseInstance,
+		DatabaseEncrypt:                s.State.DatabaseEncrypt,
+		DatabaseTrustServerCertificate: s.State.DatabaseTrustServerCertificate,
+		DatabaseAzureTenantId:          s.State.DatabaseAzureTenantId,
+		DatabaseAzureClientId:          s.State.DatabaseAzureClientId,
+		EnableProjectRenaming:          s.State.EnableProjectRenaming,
+		IncludeProjectGroups:           s.State.IncludeProjectGroups,
+		ExcludeProjectGroups:           s.State.ExcludeProjectGroups,
+		IncludeProjectNamePattern:      s.State.IncludeProjectNamePattern,
+		ExcludeProjectNamePattern:      s.State.ExcludeProjectNamePattern,
+		IncludeProjectIds:              s.State.IncludeProjectIds,
+		ExcludeProjectIds:              s.State.ExcludeProjectIds,
+		MaxConcurrentRunbooks:          s.State.MaxConcurrentRunbooks,
+		TaskTimeoutMinutes:             s.State.TaskTimeoutMinutes,
+		GoogleCloudBucket:              s.State.GoogleCloudBucket,
+		GoogleCloudCredentials:         s.State.GoogleCloudCredentials,
+		HttpAddress:                    s.State.HttpAddress,
+		HttpUsername:                   s.State.HttpUsername,
+		HttpPassword:                   s.State.HttpPassword,
+		PostgresConnectionString:       s.State.PostgresConnectionString,
+		AwsS3Endpoint:                  s.State.AwsS3Endpoint,
+		AwsS3UsePathStyle:              s.State.AwsS3UsePathStyle,
+		AwsCaBundle:                    s.State.AwsCaBundle,
+		AwsProfile:                     s.State.AwsProfile,
+		AwsRoleArn:                     s.State.AwsRoleArn,
+		AwsRoleSessionName:             s.State.AwsRoleSessionName,
+		AwsRoleExternalId:              s.State.AwsRoleExternalId,
+		AwsWebIdentityTokenFile:        s.State.AwsWebIdentityTokenFile,
 	}
 }
+
+// azureAuthTypeLabels are the labels displayed for each of the validators.AzureAuthTypes
+var azureAuthTypeLabels = []string{"Client Secret", "Client Certificate", "Federated OIDC Token", "Managed Identity"}
+
+var azureAuthHelp = map[string]string{
+	validators.AzureAuthClientSecret:    "The client secret is saved in an Azure account in Octopus that is used by the runbooks.",
+	validators.AzureAuthCertificate:     "Octopus accounts do not support certificates, so the runbooks read the certificate from the same path on the worker.",
+	validators.AzureAuthOidc:            "The federated token can not be used by the runbooks. An Azure OpenID Connect account is created in Octopus instead, so the application must also have a federated credential trusting the Octopus server.",
+	validators.AzureAuthManagedIdentity: "The runbooks use the managed identity of the worker, so the workers must run in Azure with access to the storage account.",
+}
+
+func azureAuthTypeLabel(authType string) string {
+	for i, value := range validators.AzureAuthTypes {
+		if value == authType {
+			return azureAuthTypeLabels[i]
+		}
+	}
+
+	return azureAuthTypeLabels[0]
+}
+
+func azureAuthTypeFromLabel(label string) string {
+	for i, value := range azureAuthTypeLabels {
+		if value == label {
+			return validators.AzureAuthTypes[i]
+		}
+	}
+
+	return validators.AzureAuthClientSecret
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
 	"net/url"
+	"slices"
 )
 
 type BackendSelectionStep struct {
@@ -17,6 +18,21 @@ type BackendSelectionStep struct {
 
 var AwsS3 = "AWS S3"
 var AzureStorage = "Azure Storage"
+var GoogleCloudStorage = "Google Cloud Storage"
+var HttpBackend = "HTTP"
+var PostgreSQL = "PostgreSQL"
+
+// BackendTypes are the Terraform backends that can hold the state of the migrated space
+var BackendTypes = []string{AwsS3, AzureStorage, GoogleCloudStorage, HttpBackend, PostgreSQL}
+
+// NormalizeBackendType returns the backend type, or Azure Storage if the type is not set or not recognized
+func NormalizeBackendType(backendType string) string {
+	if slices.Contains(BackendTypes, backendType) {
+		return backendType
+	}
+
+	return AzureStorage
+}
 
 func (s BackendSelectionStep) GetContainer(parent fyne.Window) *fyne.Container {
 
@@ -26,12 +42,25 @@ func (s BackendSelectionStep) GetContainer(parent fyne.Window) *fyne.Container {
 			BaseStep: BaseStep{State: s.State}})
 	}, func() {
 		// Use azure if the value is set to azure, is not set, or is not set to a recognized value
-		if s.isAzure() {
-			s.Wizard.ShowWizardStep(AzureTerraformStateStep{
+		switch NormalizeBackendType(s.State.BackendType) {
+		case AwsS3:
+			s.Wizard.ShowWizardStep(AwsTerraformStateStep{
 				Wizard:   s.Wizard,
 				BaseStep: BaseStep{State: s.State}})
-		} else {
-			s.Wizard.ShowWizardStep(AwsTerraformStateStep{
+		case GoogleCloudStorage:
+			s.Wizard.ShowWizardStep(GoogleCloudTerraformStateStep{
+				Wizard:   s.Wizard,
+				BaseStep: BaseStep{State: s.State}})
+		case HttpBackend:
+			s.Wizard.ShowWizardStep(HttpTerraformStateStep{
+				Wizard:   s.Wizard,
+				BaseStep: BaseStep{State: s.State}})
+		case PostgreSQL:
+			s.Wizard.ShowWizardStep(PostgresTerraformStateStep{
+				Wizard:   s.Wizard,
+				BaseStep: BaseStep{State: s.State}})
+		default:
+			s.Wizard.ShowWizardStep(AzureTerraformStateStep{
 				Wizard:   s.Wizard,
 				BaseStep: BaseStep{State: s.State}})
 		}
@@ -47,15 +76,11 @@ func (s BackendSelectionStep) GetContainer(parent fyne.Window) *fyne.Container {
 	linkUrl, _ := url.Parse("https://developer.hashicorp.com/terraform/language/settings/backends/configuration")
 	link := widget.NewHyperlink("Learn more about Terraform backends.", linkUrl)
 
-	radio := widget.NewRadioGroup([]string{AwsS3, AzureStorage}, func(value string) {
+	radio := widget.NewRadioGroup(BackendTypes, func(value string) {
 		s.State.BackendType = value
 	})
 
-	if s.isAzure() {
-		radio.SetSelected(AzureStorage)
-	} else {
-		radio.SetSelected(s.State.BackendType)
-	}
+	radio.SetSelected(NormalizeBackendType(s.State.BackendType))
 
 	middle := container.New(layout.NewVBoxLayout(), heading, label1, link, radio)
 
@@ -63,7 +88,3 @@ func (s BackendSelectionStep) GetContainer(parent fyne.Window) *fyne.Container {
 
 	return content
 }
-
-func (s *BackendSelectionStep) isAzure() bool {
-	return s.State.BackendType == AzureStorage || s.State.BackendType == "" || (s.State.BackendType != AzureStorage && s.State.BackendType != AwsS3)
-}
@@ -0,0 +1,65 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+)
+
+// LoadJournal loads the checkpoint journal recording the progress of the migration of the source space
+func LoadJournal(state state.State) (*checkpoint.Journal, error) {
+	return checkpoint.Load(state.Server, state.Space, state.DestinationSpace)
+}
+
+// completePhase records a completed phase in the checkpoint journal. A checkpoint that can not
+// be saved does not fail the migration, it only means the migration can not be resumed.
+func completePhase(state state.State, phase checkpoint.Phase) {
+	journal, err := LoadJournal(state)
+
+	if err != nil {
+		fmt.Println(err.Error())
+		return
+	}
+
+	if err := journal.CompletePhase(phase); err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// phaseName returns the heading of the step that runs the phase
+func phaseName(phase checkpoint.Phase) string {
+	switch phase {
+	case checkpoint.PhaseExtractSecrets:
+		return "Sensitive Value Extraction"
+	case checkpoint.PhaseStepTemplates:
+		return "Install Step Templates"
+	case checkpoint.PhaseSpaceExport:
+		return "Space Serialization Runbooks"
+	case checkpoint.PhaseProjectExport:
+		return "Project Serialization Runbooks"
+	case checkpoint.PhaseStartSpaceExport:
+		return "Migrate Space Level Resources"
+	default:
+		return "Migrate Projects"
+	}
+}
+
+// stepForPhase returns the wizard step that runs the phase
+func stepForPhase(phase checkpoint.Phase, wiz wizard.Wizard, state state.State) wizard.WizardStep {
+	switch phase {
+	case checkpoint.PhaseExtractSecrets:
+		return ExtractSecrets{Wizard: wiz, BaseStep: BaseStep{State: state}}
+	case checkpoint.PhaseStepTemplates:
+		return StepTemplateStep{Wizard: wiz, BaseStep: BaseStep{State: state}}
+	case checkpoint.PhaseSpaceExport:
+		return SpaceExportStep{Wizard: wiz, BaseStep: BaseStep{State: state}}
+	case checkpoint.PhaseProjectExport:
+		return ProjectExportStep{Wizard: wiz, BaseStep: BaseStep{State: state}}
+	case checkpoint.PhaseStartSpaceExport:
+		return StartSpaceExportStep{Wizard: wiz, BaseStep: BaseStep{State: state}}
+	default:
+		return StartProjectExportStep{Wizard: wiz, BaseStep: BaseStep{State: state}}
+	}
+}
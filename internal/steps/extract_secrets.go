@@ -1,6 +1,7 @@
 package steps
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -10,12 +11,17 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
+	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
 	"github.com/mcasperson/OctoterraWizard/internal/logutil"
+	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
 	"github.com/mcasperson/OctoterraWizard/internal/sensitivevariables"
 	"github.com/mcasperson/OctoterraWizard/internal/state"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
 	"github.com/mcasperson/OctoterraWizard/internal/validators"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+	"github.com/samber/lo"
 )
 
 // ExtractSecrets provides a step in the wizard to extract secrets from the Octopus database
@@ -28,10 +34,22 @@ type ExtractSecrets struct {
 	username         *widget.Entry
 	password         *widget.Entry
 	masterKey        *widget.Entry
+	authType         *widget.Select
+	instance         *widget.Entry
+	encrypt          *widget.Select
+	trustServerCert  *widget.Check
+	tenantId         *widget.Entry
+	clientId         *widget.Entry
+	secretsFile      *widget.Entry
+	passphrase       *widget.Entry
+	continueOnError  *widget.Check
 	result           *widget.Label
+	report           *widget.Entry
+	saveReport       *widget.Button
 	next             *widget.Button
 	previous         *widget.Button
 	extractVariables *widget.Button
+	uploadSecrets    *widget.Button
 	extractDone      bool
 }
 
@@ -75,10 +93,41 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 	s.previous = previous
 	s.result = widget.NewLabel("")
 
+	s.report = widget.NewEntry()
+	s.report.SetMinRowsVisible(8)
+	s.report.MultiLine = true
+	s.report.Disable()
+	s.report.Hide()
+
+	// extractionResult is the result of the last extraction, which is saved by the saveReport button
+	extractionResult := sensitivevariables.Result{}
+
 	validation := func(input string) {
 		s.extractVariables.Disable()
+		s.uploadSecrets.Disable()
+
+		// A secrets file must be encrypted with a passphrase
+		if s.secretsFile.Text != "" && s.passphrase.Text == "" {
+			return
+		}
+
+		if s.secretsFile.Text != "" {
+			s.uploadSecrets.Enable()
+		}
 
-		if s.dbServer.Text == "" || s.database.Text == "" || s.port.Text == "" || s.masterKey.Text == "" || s.password.Text == "" || s.username.Text == "" {
+		// A named instance is found with the SQL Server Browser, so the port is optional
+		if s.dbServer.Text == "" || s.database.Text == "" || (s.port.Text == "" && s.instance.Text == "") || s.masterKey.Text == "" {
+			return
+		}
+
+		authType := databaseAuthTypeFromLabel(s.authType.Selected)
+		credentialsValid := authType == sensitivevariables.DatabaseAuthAzureManagedIdentity ||
+			(authType == sensitivevariables.DatabaseAuthSqlServer && s.username.Text != "" && s.password.Text != "") ||
+			(authType == sensitivevariables.DatabaseAuthWindows && (s.username.Text == "" || s.password.Text != "")) ||
+			(authType == sensitivevariables.DatabaseAuthAzureServicePrincipal && s.tenantId.Text != "" && s.clientId.Text != "" && s.password.Text != "") ||
+			(authType == sensitivevariables.DatabaseAuthAzureInteractive && s.clientId.Text != "")
+
+		if !credentialsValid {
 			return
 		}
 
@@ -89,9 +138,15 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 	heading.TextStyle = fyne.TextStyle{Bold: true}
 
 	introText := widget.NewLabel(strutil.TrimMultilineWhitespace(`
-		Enter the Octopus database server, port, database name, username, password, and master key.
+		Enter the Octopus database server, port or named instance, database name, credentials, and master key.
+		The database can be accessed with a SQL Server login, a Windows account, or Azure AD for Azure SQL.
 		The master key is used to decrypt the sensitive values stored in the database.
-		Skip this step if you are migrating from a cloud instance, as you do not have database access.`))
+		Skip this step if you are migrating from a cloud instance, as you do not have database access.
+		The sensitive values are saved in the SpaceSensitiveVars library variable set in the source space.
+		Enter a secrets file to save the values to a local file encrypted with the passphrase instead.
+		The secrets file can then be uploaded to the source or destination space when required.
+		Select "Continue on error" to extract the remaining values when a value can not be decrypted.
+		Values that could not be extracted are listed in the extraction report.`))
 	linkUrl, _ := url.Parse("https://octopus.com/docs/security/data-encryption")
 	link := widget.NewHyperlink("Learn about the master key.", linkUrl)
 
@@ -129,6 +184,89 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 	s.masterKey.SetPlaceHolder("xxxxxxxxxxxxxxxxxxxxxxxxxx")
 	s.masterKey.SetText(s.State.DatabaseMasterKey)
 
+	instanceLabel := widget.NewLabel("Octopus Database Instance (Optional)")
+	s.instance = widget.NewEntry()
+	s.instance.SetPlaceHolder("SQLEXPRESS")
+	s.instance.SetText(s.State.DatabaseInstance)
+
+	tenantIdLabel := widget.NewLabel("Azure Tenant ID")
+	s.tenantId = widget.NewEntry()
+	s.tenantId.SetPlaceHolder("00000000-0000-0000-0000-000000000000")
+	s.tenantId.SetText(s.State.DatabaseAzureTenantId)
+
+	clientIdLabel := widget.NewLabel("Azure Client ID")
+	s.clientId = widget.NewEntry()
+	s.clientId.SetPlaceHolder("00000000-0000-0000-0000-000000000000")
+	s.clientId.SetText(s.State.DatabaseAzureClientId)
+
+	encryptLabel := widget.NewLabel("Encryption")
+	s.encrypt = widget.NewSelect(databaseEncryptLabels, func(selected string) {})
+	s.encrypt.SetSelected(databaseEncryptLabel(s.State.DatabaseEncrypt))
+
+	s.trustServerCert = widget.NewCheck("Trust the server certificate", func(value bool) {})
+	s.trustServerCert.SetChecked(s.State.DatabaseTrustServerCertificate)
+
+	authHelp := widget.NewLabel("")
+	authHelp.Wrapping = fyne.TextWrapWord
+
+	authFields := map[string][]fyne.CanvasObject{
+		sensitivevariables.DatabaseAuthSqlServer:             {usernameLabel, s.username, passwordLabel, s.password},
+		sensitivevariables.DatabaseAuthWindows:               {usernameLabel, s.username, passwordLabel, s.password},
+		sensitivevariables.DatabaseAuthAzureServicePrincipal: {tenantIdLabel, s.tenantId, clientIdLabel, s.clientId, passwordLabel, s.password},
+		sensitivevariables.DatabaseAuthAzureManagedIdentity:  {clientIdLabel, s.clientId},
+		sensitivevariables.DatabaseAuthAzureInteractive:      {usernameLabel, s.username, clientIdLabel, s.clientId},
+	}
+
+	authTypeLabel := widget.NewLabel("Authentication")
+	s.authType = widget.NewSelect(databaseAuthTypeLabels, func(selected string) {
+		authType := databaseAuthTypeFromLabel(selected)
+
+		// Fields are shared between authentication methods, so hide them all before showing the selected fields
+		for _, fields := range authFields {
+			for _, field := range fields {
+				field.Hide()
+			}
+		}
+
+		for _, field := range authFields[authType] {
+			field.Show()
+		}
+
+		authHelp.SetText(databaseAuthHelp[authType])
+
+		switch authType {
+		case sensitivevariables.DatabaseAuthWindows:
+			usernameLabel.SetText("Windows Account (DOMAIN\\user)")
+			passwordLabel.SetText("Windows Password")
+		case sensitivevariables.DatabaseAuthAzureServicePrincipal:
+			clientIdLabel.SetText("Azure Client ID")
+			passwordLabel.SetText("Azure Client Secret")
+		case sensitivevariables.DatabaseAuthAzureManagedIdentity:
+			clientIdLabel.SetText("Azure Client ID (Optional)")
+		case sensitivevariables.DatabaseAuthAzureInteractive:
+			usernameLabel.SetText("Azure Username (Optional)")
+			clientIdLabel.SetText("Azure Application ID")
+		default:
+			usernameLabel.SetText("Octopus Database Username")
+			passwordLabel.SetText("Octopus Database Password")
+		}
+
+		validation("")
+	})
+
+	secretsFileLabel := widget.NewLabel("Secrets File (Optional)")
+	s.secretsFile = widget.NewEntry()
+	s.secretsFile.SetPlaceHolder("/secure/octoterrawiz-secrets.json")
+	s.secretsFile.SetText(s.State.SecretsFile)
+
+	passphraseLabel := widget.NewLabel("Secrets File Passphrase")
+	s.passphrase = widget.NewPasswordEntry()
+	s.passphrase.SetPlaceHolder("xxxxxxxxxxxxxxxxxxxxxxxxxx")
+	s.passphrase.SetText(s.State.SecretsPassphrase)
+
+	s.continueOnError = widget.NewCheck("Continue on error", func(value bool) {})
+	s.continueOnError.SetChecked(s.State.ContinueOnExtractionError)
+
 	s.extractVariables = widget.NewButton("Extract sensitive values", func() {
 		next.Disable()
 		previous.Disable()
@@ -139,9 +277,22 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 		s.database.Disable()
 		s.port.Disable()
 		s.masterKey.Disable()
+		s.authType.Disable()
+		s.instance.Disable()
+		s.encrypt.Disable()
+		s.trustServerCert.Disable()
+		s.tenantId.Disable()
+		s.clientId.Disable()
+		s.secretsFile.Disable()
+		s.passphrase.Disable()
+		s.continueOnError.Disable()
 		s.extractVariables.Disable()
+		s.uploadSecrets.Disable()
+		s.saveReport.Disable()
+		s.report.Hide()
 		s.result.SetText("🔵 Extracting sensitive values.")
 		s.extractDone = true
+		s.State = s.getState()
 
 		go func() {
 			s.Execute(
@@ -155,13 +306,36 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 						s.database.Enable()
 						s.port.Enable()
 						s.masterKey.Enable()
+						s.authType.Enable()
+						s.instance.Enable()
+						s.encrypt.Enable()
+						s.trustServerCert.Enable()
+						s.tenantId.Enable()
+						s.clientId.Enable()
+						s.secretsFile.Enable()
+						s.passphrase.Enable()
+						s.continueOnError.Enable()
 						s.extractVariables.Enable()
+						validation("")
 						infinite.Hide()
 					})
 				},
-				func() {
+				func(result sensitivevariables.Result) {
 					fyne.Do(func() {
-						s.result.SetText("🟢 Sensitive values have been extracted.")
+						extractionResult = result
+						failures := len(result.Failures())
+
+						if failures != 0 {
+							s.result.SetText(fmt.Sprintf("🟢 %d sensitive values have been extracted, and %d could not be extracted.", len(result.Records)-failures, failures))
+						} else if s.State.SecretsFile != "" {
+							s.result.SetText(fmt.Sprintf("🟢 %d sensitive values have been extracted to %s.", len(result.Records), s.State.SecretsFile))
+						} else {
+							s.result.SetText(fmt.Sprintf("🟢 %d sensitive values have been extracted.", len(result.Records)))
+						}
+
+						s.report.SetText(result.String())
+						s.report.Show()
+						s.saveReport.Enable()
 					})
 				},
 				func(err error) {
@@ -176,6 +350,72 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 		}()
 	})
 
+	s.uploadSecrets = widget.NewButton("Upload secrets file", func() {
+		s.State = s.getState()
+		targets := widget.NewRadioGroup([]string{SecretsTargetSource, SecretsTargetDestination}, func(value string) {})
+		targets.SetSelected(SecretsTargetSource)
+
+		dialog.NewCustomConfirm("Upload the secrets file?", "Upload", "Cancel",
+			container.New(layout.NewVBoxLayout(),
+				widget.NewLabel("Save the values in the secrets file to the "+sensitivevariables.SecretsLibraryVariableSetName+" library variable set in the space:"),
+				targets),
+			func(upload bool) {
+				if !upload {
+					return
+				}
+
+				s.result.SetText("🔵 Uploading the secrets file.")
+				infinite.Show()
+				s.uploadSecrets.Disable()
+
+				go func() {
+					err := UploadSecretsFile(s.State, targets.Selected)
+
+					if err != nil {
+						if err := logutil.WriteTextToFile("extract_secrets_error.txt", err.Error()); err != nil {
+							fmt.Println("Failed to write error to file")
+						}
+					}
+
+					fyne.Do(func() {
+						infinite.Hide()
+						validation("")
+
+						if err != nil {
+							s.result.SetText("🔴 An error was raised while attempting to upload the secrets file. " + err.Error())
+						} else {
+							s.result.SetText("🟢 The secrets file has been uploaded to the " + targets.Selected + " space.")
+						}
+					})
+				}()
+			}, s.Wizard.Window).Show()
+	})
+
+	s.saveReport = widget.NewButton("Save extraction report", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			defer writer.Close()
+
+			if err := extractionResult.WriteCsv(writer); err != nil {
+				if err := logutil.WriteTextToFile("extract_secrets_error.txt", err.Error()); err != nil {
+					fmt.Println("Failed to write error to file")
+				}
+
+				s.result.SetText("🔴 An error was raised while attempting to save the extraction report. " + err.Error())
+				return
+			}
+
+			s.result.SetText("🟢 The extraction report has been saved to " + writer.URI().Path() + ".")
+		}, s.Wizard.Window)
+		saveDialog.SetFileName("extraction-report.csv")
+		saveDialog.Show()
+	})
+	s.saveReport.Disable()
+
+	s.authType.SetSelected(databaseAuthTypeLabel(sensitivevariables.DatabaseAuthType(s.State)))
+
 	validation("")
 
 	s.dbServer.OnChanged = validation
@@ -184,10 +424,17 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 	s.username.OnChanged = validation
 	s.password.OnChanged = validation
 	s.masterKey.OnChanged = validation
+	s.instance.OnChanged = validation
+	s.tenantId.OnChanged = validation
+	s.clientId.OnChanged = validation
+	s.secretsFile.OnChanged = validation
+	s.passphrase.OnChanged = validation
+
+	formLayout := container.New(layout.NewFormLayout(), serverLabel, s.dbServer, portLabel, s.port, instanceLabel, s.instance, databaseLabel, s.database, authTypeLabel, s.authType, tenantIdLabel, s.tenantId, clientIdLabel, s.clientId, usernameLabel, s.username, passwordLabel, s.password, encryptLabel, s.encrypt, masterKeyPassword, s.masterKey, secretsFileLabel, s.secretsFile, passphraseLabel, s.passphrase)
 
-	formLayout := container.New(layout.NewFormLayout(), serverLabel, s.dbServer, portLabel, s.port, databaseLabel, s.database, usernameLabel, s.username, passwordLabel, s.password, masterKeyPassword, s.masterKey)
+	buttons := container.New(layout.NewHBoxLayout(), s.extractVariables, s.uploadSecrets, s.saveReport)
 
-	middle := container.New(layout.NewVBoxLayout(), heading, introText, link, formLayout, s.extractVariables, infinite, s.result)
+	middle := container.New(layout.NewVBoxLayout(), heading, introText, link, formLayout, authHelp, s.trustServerCert, s.continueOnError, buttons, infinite, s.result, s.report)
 
 	content := container.NewBorder(nil, bottom, nil, nil, middle)
 
@@ -196,37 +443,74 @@ func (s ExtractSecrets) GetContainer(parent fyne.Window) *fyne.Container {
 
 func (s ExtractSecrets) getState() state.State {
 	return state.State{
-		BackendType:                   s.State.BackendType,
-		Server:                        s.State.Server,
-		ServerExternal:                "",
-		ApiKey:                        s.State.ApiKey,
-		Space:                         s.State.Space,
-		DestinationServer:             s.State.DestinationServer,
-		DestinationServerExternal:     "",
-		DestinationApiKey:             s.State.DestinationApiKey,
-		DestinationSpace:              s.State.DestinationSpace,
-		AwsAccessKey:                  s.State.AwsAccessKey,
-		AwsSecretKey:                  s.State.AwsSecretKey,
-		AwsS3Bucket:                   s.State.AwsS3Bucket,
-		AwsS3BucketRegion:             s.State.AwsS3BucketRegion,
-		PromptForDelete:               s.State.PromptForDelete,
-		UseContainerImages:            s.State.UseContainerImages,
-		AzureResourceGroupName:        s.State.AzureResourceGroupName,
-		AzureStorageAccountName:       s.State.AzureStorageAccountName,
-		AzureContainerName:            s.State.AzureContainerName,
-		AzureSubscriptionId:           s.State.AzureSubscriptionId,
-		AzureTenantId:                 s.State.AzureTenantId,
-		AzureApplicationId:            s.State.AzureApplicationId,
-		AzurePassword:                 s.State.AzurePassword,
-		ExcludeAllLibraryVariableSets: false,
-		EnableVariableSpreading:       false,
-		DatabaseServer:                strings.TrimSpace(s.dbServer.Text),
-		DatabaseUser:                  strings.TrimSpace(s.username.Text),
-		DatabasePass:                  strings.TrimSpace(s.password.Text),
-		DatabasePort:                  strings.TrimSpace(s.port.Text),
-		DatabaseName:                  strings.TrimSpace(s.database.Text),
-		DatabaseMasterKey:             strings.TrimSpace(s.masterKey.Text),
-		EnableProjectRenaming:         s.State.EnableProjectRenaming,
+		BackendType:                    s.State.BackendType,
+		Server:                         s.State.Server,
+		ServerExternal:                 "",
+		ApiKey:                         s.State.ApiKey,
+		Space:                          s.State.Space,
+		DestinationServer:              s.State.DestinationServer,
+		DestinationServerExternal:      "",
+		DestinationApiKey:              s.State.DestinationApiKey,
+		DestinationSpace:               s.State.DestinationSpace,
+		AwsAccessKey:                   s.State.AwsAccessKey,
+		AwsSecretKey:                   s.State.AwsSecretKey,
+		AwsS3Bucket:                    s.State.AwsS3Bucket,
+		AwsS3BucketRegion:              s.State.AwsS3BucketRegion,
+		PromptForDelete:                s.State.PromptForDelete,
+		UseContainerImages:             s.State.UseContainerImages,
+		AzureResourceGroupName:         s.State.AzureResourceGroupName,
+		AzureStorageAccountName:        s.State.AzureStorageAccountName,
+		AzureContainerName:             s.State.AzureContainerName,
+		AzureSubscriptionId:            s.State.AzureSubscriptionId,
+		AzureTenantId:                  s.State.AzureTenantId,
+		AzureApplicationId:             s.State.AzureApplicationId,
+		AzurePassword:                  s.State.AzurePassword,
+		ExcludeAllLibraryVariableSets:  s.State.ExcludeAllLibraryVariableSets,
+		EnableVariableSpreading:        s.State.EnableVariableSpreading,
+		DatabaseServer:                 strings.TrimSpace(s.dbServer.Text),
+		DatabaseUser:                   strings.TrimSpace(s.username.Text),
+		DatabasePass:                   strings.TrimSpace(s.password.Text),
+		DatabasePort:                   strings.TrimSpace(s.port.Text),
+		DatabaseName:                   strings.TrimSpace(s.database.Text),
+		DatabaseMasterKey:              strings.TrimSpace(s.masterKey.Text),
+		DatabaseAuthType:               databaseAuthTypeFromLabel(s.authType.Selected),
+		DatabaseInstance:               strings.TrimSpace(s.instance.Text),
+		DatabaseEncrypt:                databaseEncryptFromLabel(s.encrypt.Selected),
+		DatabaseTrustServerCertificate: s.trustServerCert.Checked,
+		DatabaseAzureTenantId:          strings.TrimSpace(s.tenantId.Text),
+		DatabaseAzureClientId:          strings.TrimSpace(s.clientId.Text),
+		EnableProjectRenaming:          s.State.EnableProjectRenaming,
+		IncludeProjectGroups:           s.State.IncludeProjectGroups,
+		ExcludeProjectGroups:           s.State.ExcludeProjectGroups,
+		IncludeProjectNamePattern:      s.State.IncludeProjectNamePattern,
+		ExcludeProjectNamePattern:      s.State.ExcludeProjectNamePattern,
+		IncludeProjectIds:              s.State.IncludeProjectIds,
+		ExcludeProjectIds:              s.State.ExcludeProjectIds,
+		MaxConcurrentRunbooks:          s.State.MaxConcurrentRunbooks,
+		TaskTimeoutMinutes:             s.State.TaskTimeoutMinutes,
+		GoogleCloudBucket:              s.State.GoogleCloudBucket,
+		GoogleCloudCredentials:         s.State.GoogleCloudCredentials,
+		HttpAddress:                    s.State.HttpAddress,
+		HttpUsername:                   s.State.HttpUsername,
+		HttpPassword:                   s.State.HttpPassword,
+		PostgresConnectionString:       s.State.PostgresConnectionString,
+		AwsS3Endpoint:                  s.State.AwsS3Endpoint,
+		AwsS3UsePathStyle:              s.State.AwsS3UsePathStyle,
+		AwsCaBundle:                    s.State.AwsCaBundle,
+		AwsProfile:                     s.State.AwsProfile,
+		AwsRoleArn:                     s.State.AwsRoleArn,
+		AwsRoleSessionName:             s.State.AwsRoleSessionName,
+		AwsRoleExternalId:              s.State.AwsRoleExternalId,
+		AwsWebIdentityTokenFile:        s.State.AwsWebIdentityTokenFile,
+		AzureAuthType:                  s.State.AzureAuthType,
+		AzureCertificatePath:           s.State.AzureCertificatePath,
+		AzureCertificatePassword:       s.State.AzureCertificatePassword,
+		AzureFederatedTokenFile:        s.State.AzureFederatedTokenFile,
+		AzureLocation:                  s.State.AzureLocation,
+		CreateBackendStorage:           s.State.CreateBackendStorage,
+		SecretsFile:                    strings.TrimSpace(s.secretsFile.Text),
+		SecretsPassphrase:              s.passphrase.Text,
+		ContinueOnExtractionError:      s.continueOnError.Checked,
 	}
 }
 
@@ -236,18 +520,18 @@ func (s *ExtractSecrets) SaveSecretsVariable() error {
 	return nil
 }
 
-func (s ExtractSecrets) Execute(doneCallback func(), successCallback func(), errCallback func(error)) {
+func (s ExtractSecrets) Execute(doneCallback func(), successCallback func(sensitivevariables.Result), errCallback func(error)) {
 	defer doneCallback()
 
-	if err := validators.ValidateDatabase(s.getState()); err != nil {
+	if err := validators.ValidateDatabase(s.State); err != nil {
 		if err := logutil.WriteTextToFile("extract_secrets_error.txt", err.Error()); err != nil {
 			errCallback(err)
 			return
 		}
 	}
 
-	newState := s.getState()
-	variableValue, err := sensitivevariables.ExtractVariables(newState.DatabaseServer, newState.DatabasePort, newState.DatabaseName, newState.DatabaseUser, newState.DatabasePass, newState.DatabaseMasterKey)
+	newState := s.State
+	scope, err := s.extractionScope()
 
 	if err != nil {
 		if err := logutil.WriteTextToFile("extract_secrets_error.txt", err.Error()); err != nil {
@@ -255,8 +539,28 @@ func (s ExtractSecrets) Execute(doneCallback func(), successCallback func(), err
 		}
 		errCallback(err)
 		return
+	}
+
+	variableValue, err := sensitivevariables.ExtractVariables(sensitivevariables.ConnectionFromState(newState), newState.DatabaseMasterKey, scope, newState.ContinueOnExtractionError)
+
+	if err != nil {
+		if err := logutil.WriteTextToFile("extract_secrets_error.txt", err.Error()); err != nil {
+			fmt.Println("Failed to write error to file")
+		}
+		errCallback(err)
+		return
+	} else if newState.SecretsFile != "" {
+		// The values are kept out of Octopus until the file is uploaded with UploadSecretsFile
+		if err := sensitivevariables.WriteSecretsFile(newState.SecretsFile, variableValue.Values, newState.SecretsPassphrase); err != nil {
+			if err := logutil.WriteTextToFile("extract_secrets_error.txt", err.Error()); err != nil {
+				fmt.Println("Failed to write error to file")
+			}
+
+			errCallback(err)
+			return
+		}
 	} else {
-		if err := sensitivevariables.CreateSecretsLibraryVariableSet(variableValue, newState); err != nil {
+		if err := sensitivevariables.CreateSecretsLibraryVariableSet(variableValue.Values, newState); err != nil {
 			if err := logutil.WriteTextToFile("extract_secrets_error.txt", err.Error()); err != nil {
 				fmt.Println("Failed to write error to file")
 			}
@@ -266,5 +570,118 @@ func (s ExtractSecrets) Execute(doneCallback func(), successCallback func(), err
 		}
 	}
 
-	successCallback()
+	completePhase(s.State, checkpoint.PhaseExtractSecrets)
+	successCallback(variableValue)
+}
+
+// extractionScope limits the extraction to the source space, and to the projects selected for migration
+// if any project selection rules are defined
+func (s ExtractSecrets) extractionScope() (sensitivevariables.Scope, error) {
+	scope := sensitivevariables.Scope{SpaceId: strings.TrimSpace(s.State.Space)}
+
+	if infrastructure.ProjectRules(s.State).IsEmpty() {
+		return scope, nil
+	}
+
+	myclient, err := octoclient.CreateClient(s.State)
+
+	if err != nil {
+		return sensitivevariables.Scope{}, errors.Join(errors.New("failed to create client"), err)
+	}
+
+	selectedProjects, err := infrastructure.GetSelectedProjects(myclient, s.State)
+
+	if err != nil {
+		return sensitivevariables.Scope{}, errors.Join(errors.New("failed to get the selected projects"), err)
+	}
+
+	scope.ProjectIds = lo.Map(selectedProjects, func(item *projects.Project, index int) string {
+		return item.ID
+	})
+
+	// An empty list would extract every project, so use an ID that matches no project instead
+	if len(scope.ProjectIds) == 0 {
+		scope.ProjectIds = []string{""}
+	}
+
+	return scope, nil
+}
+
+// Spaces that an encrypted secrets file can be uploaded to
+const (
+	SecretsTargetSource      = "source"
+	SecretsTargetDestination = "destination"
+)
+
+// UploadSecretsFile decrypts the secrets file saved by Execute and saves the values in the library variable set
+// read by the migration runbooks, in either the source or destination space
+func UploadSecretsFile(state state.State, target string) error {
+	values, err := sensitivevariables.ReadSecretsFile(state.SecretsFile, state.SecretsPassphrase)
+
+	if err != nil {
+		return err
+	}
+
+	switch target {
+	case SecretsTargetSource:
+		return sensitivevariables.CreateSecretsLibraryVariableSet(values, state)
+	case SecretsTargetDestination:
+		return sensitivevariables.CreateDestinationSecretsLibraryVariableSet(values, state)
+	default:
+		return errors.New("the secrets file can only be uploaded to the \"" + SecretsTargetSource + "\" or \"" + SecretsTargetDestination + "\" space")
+	}
+}
+
+// databaseAuthTypeLabels are the labels displayed for each of the sensitivevariables.DatabaseAuthTypes
+var databaseAuthTypeLabels = []string{"SQL Server Login", "Windows Authentication", "Azure AD Service Principal", "Azure AD Managed Identity", "Azure AD Interactive"}
+
+var databaseAuthHelp = map[string]string{
+	sensitivevariables.DatabaseAuthSqlServer:             "",
+	sensitivevariables.DatabaseAuthWindows:               "Leave the account empty to use the Windows account running the wizard. Other platforms must enter a DOMAIN\\user account, which is authenticated with NTLM.",
+	sensitivevariables.DatabaseAuthAzureServicePrincipal: "The service principal must be a user in the Azure SQL database.",
+	sensitivevariables.DatabaseAuthAzureManagedIdentity:  "The wizard must run in Azure. Enter a client ID to use a user-assigned identity instead of the system-assigned identity.",
+	sensitivevariables.DatabaseAuthAzureInteractive:      "A browser is opened to sign in to Azure AD with the application.",
+}
+
+func databaseAuthTypeLabel(authType string) string {
+	for i, value := range sensitivevariables.DatabaseAuthTypes {
+		if value == authType {
+			return databaseAuthTypeLabels[i]
+		}
+	}
+
+	return databaseAuthTypeLabels[0]
+}
+
+func databaseAuthTypeFromLabel(label string) string {
+	for i, value := range databaseAuthTypeLabels {
+		if value == label {
+			return sensitivevariables.DatabaseAuthTypes[i]
+		}
+	}
+
+	return sensitivevariables.DatabaseAuthSqlServer
+}
+
+// databaseEncryptLabels are the labels displayed for each of the sensitivevariables.DatabaseEncryptModes
+var databaseEncryptLabels = []string{"Default", "Required", "Optional", "Strict", "Disabled"}
+
+func databaseEncryptLabel(encrypt string) string {
+	for i, value := range sensitivevariables.DatabaseEncryptModes {
+		if strings.EqualFold(value, strings.TrimSpace(encrypt)) {
+			return databaseEncryptLabels[i]
+		}
+	}
+
+	return databaseEncryptLabels[0]
+}
+
+func databaseEncryptFromLabel(label string) string {
+	for i, value := range databaseEncryptLabels {
+		if value == label {
+			return sensitivevariables.DatabaseEncryptModes[i]
+		}
+	}
+
+	return sensitivevariables.DatabaseEncryptDefault
 }
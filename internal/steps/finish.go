@@ -1,11 +1,18 @@
 package steps
 
 import (
+	"fmt"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+	"github.com/mcasperson/OctoterraWizard/internal/config"
+	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
+	"github.com/mcasperson/OctoterraWizard/internal/logutil"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/verification"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
 )
 
@@ -37,9 +44,133 @@ func (s FinishStep) GetContainer(parent fyne.Window) *fyne.Container {
 		* Build information
 		* Email settings
 	`))
-	middle := container.New(layout.NewVBoxLayout(), heading, intro)
+
+	saveIntro := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		Save the settings used by this migration to a YAML or JSON file to repeat the migration with the --config flag.
+		Secrets are not saved to the file. They are replaced with references to environment variables that must be defined when the file is loaded.
+	`))
+	result := widget.NewLabel("")
+	saveConfig := widget.NewButton("Save Migration Settings", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+
+			defer writer.Close()
+
+			if err := s.saveConfig(writer); err != nil {
+				if err := logutil.WriteTextToFile("finish_error.txt", err.Error()); err != nil {
+					fmt.Println("Failed to write error to file")
+				}
+
+				result.SetText("🔴 Failed to save the migration settings: " + err.Error())
+				return
+			}
+
+			result.SetText("🟢 Saved the migration settings to " + writer.URI().Path())
+		}, parent)
+		saveDialog.SetFileName("octoterrawiz.yaml")
+		saveDialog.Show()
+	})
+
+	verifyIntro := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		Verify the migration by comparing the environments, lifecycles, feeds, accounts, library variable sets, projects,
+		runbooks, tenants, targets, and variables in the source and destination spaces.
+		Resources are matched by name. The values of sensitive variables can not be compared.
+	`))
+	verifyResult := widget.NewLabel("")
+	infinite := widget.NewProgressBarInfinite()
+	infinite.Hide()
+	infinite.Start()
+	reportText := widget.NewEntry()
+	reportText.SetMinRowsVisible(10)
+	reportText.MultiLine = true
+	reportText.Disable()
+	reportText.Hide()
+
+	var report verification.Report
+	var saveReport *widget.Button
+	saveReport = widget.NewButton("Save Verification Report", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+
+			defer writer.Close()
+
+			if err := report.Write(writer.URI().Path(), writer); err != nil {
+				if err := logutil.WriteTextToFile("finish_error.txt", err.Error()); err != nil {
+					fmt.Println("Failed to write error to file")
+				}
+
+				verifyResult.SetText("🔴 Failed to save the verification report: " + err.Error())
+				return
+			}
+
+			verifyResult.SetText("🟢 Saved the verification report to " + writer.URI().Path())
+		}, parent)
+		saveDialog.SetFileName("verification.html")
+		saveDialog.Show()
+	})
+	saveReport.Hide()
+
+	var verify *widget.Button
+	verify = widget.NewButton("Verify Migration", func() {
+		verify.Disable()
+		saveReport.Hide()
+		reportText.Hide()
+		infinite.Show()
+
+		go func() {
+			verificationReport, err := infrastructure.VerifyMigration(s.State, func(message string) {
+				fyne.Do(func() {
+					verifyResult.SetText(message)
+				})
+			})
+
+			if err != nil {
+				if err := logutil.WriteTextToFile("finish_error.txt", err.Error()); err != nil {
+					fmt.Println("Failed to write error to file")
+				}
+			}
+
+			fyne.Do(func() {
+				verify.Enable()
+				infinite.Hide()
+
+				if err != nil {
+					verifyResult.SetText("🔴 Failed to verify the migration: " + err.Error())
+					return
+				}
+
+				report = verificationReport
+				if len(report.Differences) == 0 {
+					verifyResult.SetText("🟢 No differences were found between the source and destination spaces.")
+				} else {
+					verifyResult.SetText(fmt.Sprintf("🔵 Found %d missing, %d extra and %d different resources in the destination space.",
+						report.Count(verification.StatusMissing), report.Count(verification.StatusExtra), report.Count(verification.StatusDifferent)))
+				}
+				reportText.SetText(report.String())
+				reportText.Show()
+				saveReport.Show()
+			})
+		}()
+	})
+
+	middle := container.New(layout.NewVBoxLayout(), heading, intro, saveIntro, saveConfig, result, verifyIntro, verify, infinite, verifyResult, reportText, saveReport)
 
 	content := container.NewBorder(nil, nil, nil, nil, middle)
 
 	return content
 }
+
+func (s FinishStep) saveConfig(writer fyne.URIWriteCloser) error {
+	content, err := config.Marshal(writer.URI().Path(), config.FromState(s.State))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(content)
+	return err
+}
@@ -0,0 +1,165 @@
+package steps
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mcasperson/OctoterraWizard/internal/logutil"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
+	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+)
+
+type GoogleCloudTerraformStateStep struct {
+	BaseStep
+	Wizard      wizard.Wizard
+	bucket      *widget.Entry
+	credentials *widget.Entry
+	result      *widget.Label
+	infinite    *widget.ProgressBarInfinite
+	logs        *widget.Entry
+	previous    *widget.Button
+	next        *widget.Button
+}
+
+func (s GoogleCloudTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container {
+
+	bottom, previous, next := s.BuildNavigation(func() {
+		s.Wizard.ShowWizardStep(BackendSelectionStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.getState()}})
+	}, func() {
+		s.result.SetText("🔵 Validating the Google Cloud credentials and storage bucket.")
+		s.infinite.Show()
+		s.bucket.Disable()
+		s.credentials.Disable()
+		s.logs.Hide()
+		s.logs.SetText("")
+		s.next.Disable()
+		s.previous.Disable()
+
+		defer s.infinite.Hide()
+		defer s.bucket.Enable()
+		defer s.credentials.Enable()
+		defer s.next.Enable()
+		defer s.previous.Enable()
+
+		validationFailed := false
+		if err := validators.TestGoogleCloudBucket(s.getState()); err != nil {
+			if err := logutil.WriteTextToFile("gcs_terraform_state_error.txt", err.Error()); err != nil {
+				fmt.Println("Failed to write error to file")
+			}
+
+			s.result.SetText("🔴 Unable to access the bucket. Please check that the bucket exists and that the service account can access it.")
+			s.logs.SetText(err.Error())
+			s.logs.Show()
+			validationFailed = true
+		}
+
+		nexCallback := func(proceed bool) {
+			if proceed {
+				s.Wizard.ShowWizardStep(AdvancedOptionsStep{
+					Wizard:   s.Wizard,
+					BaseStep: BaseStep{State: s.getState()}})
+			}
+		}
+
+		if validationFailed {
+			dialog.NewConfirm("Google Cloud Validation failed", "Validation of the Google Cloud details failed. Do you wish to continue anyway?", nexCallback, s.Wizard.Window).Show()
+		} else {
+			nexCallback(true)
+		}
+	})
+	s.next = next
+	s.previous = previous
+	next.Disable()
+
+	heading := widget.NewLabel("Google Cloud Storage Terraform State")
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+
+	label1 := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		Terraform manages its state in a Google Cloud Storage bucket. Please provide the name of the bucket that will be used to store the Terraform state,
+		and the JSON key of a service account that can read and write objects in the bucket.
+		The JSON key is used to create a Google Cloud account in Octopus that is used by the runbooks.
+	`))
+
+	linkUrl, _ := url.Parse("https://developer.hashicorp.com/terraform/language/settings/backends/gcs")
+	link := widget.NewHyperlink("Learn more about the Google Cloud Storage Terraform backend.", linkUrl)
+
+	s.result = widget.NewLabel("")
+
+	s.infinite = widget.NewProgressBarInfinite()
+	s.infinite.Hide()
+	s.infinite.Start()
+
+	s.logs = widget.NewEntry()
+	s.logs.SetMinRowsVisible(20)
+	s.logs.MultiLine = true
+	s.logs.Disable()
+	s.logs.Hide()
+
+	bucketLabel := widget.NewLabel("Bucket Name")
+	s.bucket = widget.NewEntry()
+	s.bucket.SetPlaceHolder("my-bucket")
+	s.bucket.SetText(s.State.GoogleCloudBucket)
+
+	credentialsLabel := widget.NewLabel("Service Account JSON Key")
+	s.credentials = widget.NewMultiLineEntry()
+	s.credentials.SetPlaceHolder("{\"type\": \"service_account\", ...}")
+	s.credentials.SetMinRowsVisible(6)
+	s.credentials.SetText(s.State.GoogleCloudCredentials)
+
+	validation := func(input string) {
+		if s.bucket != nil && s.bucket.Text != "" && s.credentials != nil && s.credentials.Text != "" {
+			next.Enable()
+		} else {
+			next.Disable()
+		}
+	}
+
+	validation("")
+
+	s.bucket.OnChanged = validation
+	s.credentials.OnChanged = validation
+
+	loadKey := widget.NewButton("Load JSON Key File", func() {
+		dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+
+			defer reader.Close()
+
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				s.result.SetText("🔴 Failed to read the JSON key file: " + err.Error())
+				return
+			}
+
+			s.credentials.SetText(string(content))
+		}, parent).Show()
+	})
+
+	formLayout := container.New(layout.NewFormLayout(), bucketLabel, s.bucket, credentialsLabel, s.credentials, widget.NewLabel(""), loadKey)
+
+	middle := container.New(layout.NewVBoxLayout(), heading, label1, link, formLayout, s.infinite, s.result, s.logs)
+
+	content := container.NewBorder(nil, bottom, nil, nil, middle)
+
+	return content
+}
+
+func (s GoogleCloudTerraformStateStep) getState() state.State {
+	newState := s.State
+	newState.GoogleCloudBucket = strings.TrimSpace(s.bucket.Text)
+	newState.GoogleCloudCredentials = strings.TrimSpace(s.credentials.Text)
+	return newState
+}
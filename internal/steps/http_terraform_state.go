@@ -0,0 +1,154 @@
+package steps
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mcasperson/OctoterraWizard/internal/logutil"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
+	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+)
+
+type HttpTerraformStateStep struct {
+	BaseStep
+	Wizard   wizard.Wizard
+	address  *widget.Entry
+	username *widget.Entry
+	password *widget.Entry
+	result   *widget.Label
+	infinite *widget.ProgressBarInfinite
+	logs     *widget.Entry
+	previous *widget.Button
+	next     *widget.Button
+}
+
+func (s HttpTerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container {
+
+	bottom, previous, next := s.BuildNavigation(func() {
+		s.Wizard.ShowWizardStep(BackendSelectionStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.getState()}})
+	}, func() {
+		s.result.SetText("🔵 Validating the HTTP backend address and credentials.")
+		s.infinite.Show()
+		s.address.Disable()
+		s.username.Disable()
+		s.password.Disable()
+		s.logs.Hide()
+		s.logs.SetText("")
+		s.next.Disable()
+		s.previous.Disable()
+
+		defer s.infinite.Hide()
+		defer s.address.Enable()
+		defer s.username.Enable()
+		defer s.password.Enable()
+		defer s.next.Enable()
+		defer s.previous.Enable()
+
+		validationFailed := false
+		if err := validators.TestHttpBackend(s.getState()); err != nil {
+			if err := logutil.WriteTextToFile("http_terraform_state_error.txt", err.Error()); err != nil {
+				fmt.Println("Failed to write error to file")
+			}
+
+			s.result.SetText("🔴 Unable to connect to the HTTP backend. Please check the address, username, and password.")
+			s.logs.SetText(err.Error())
+			s.logs.Show()
+			validationFailed = true
+		}
+
+		nexCallback := func(proceed bool) {
+			if proceed {
+				s.Wizard.ShowWizardStep(AdvancedOptionsStep{
+					Wizard:   s.Wizard,
+					BaseStep: BaseStep{State: s.getState()}})
+			}
+		}
+
+		if validationFailed {
+			dialog.NewConfirm("HTTP Backend Validation failed", "Validation of the HTTP backend details failed. Do you wish to continue anyway?", nexCallback, s.Wizard.Window).Show()
+		} else {
+			nexCallback(true)
+		}
+	})
+	s.next = next
+	s.previous = previous
+	next.Disable()
+
+	heading := widget.NewLabel("HTTP Terraform State")
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+
+	label1 := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		Terraform manages its state with a REST service, such as the GitLab managed Terraform state.
+		Please provide the base address of the service. Each state is saved to the base address followed by the name of the space or project,
+		for example https://gitlab.example.com/api/v4/projects/1/terraform/state/Project_Web_App.
+		The state is locked and unlocked using the same address.
+	`))
+
+	linkUrl, _ := url.Parse("https://developer.hashicorp.com/terraform/language/settings/backends/http")
+	link := widget.NewHyperlink("Learn more about the HTTP Terraform backend.", linkUrl)
+
+	s.result = widget.NewLabel("")
+
+	s.infinite = widget.NewProgressBarInfinite()
+	s.infinite.Hide()
+	s.infinite.Start()
+
+	s.logs = widget.NewEntry()
+	s.logs.SetMinRowsVisible(20)
+	s.logs.MultiLine = true
+	s.logs.Disable()
+	s.logs.Hide()
+
+	addressLabel := widget.NewLabel("Base Address")
+	s.address = widget.NewEntry()
+	s.address.SetPlaceHolder("https://gitlab.example.com/api/v4/projects/1/terraform/state")
+	s.address.SetText(s.State.HttpAddress)
+
+	usernameLabel := widget.NewLabel("Username")
+	s.username = widget.NewEntry()
+	s.username.SetPlaceHolder("")
+	s.username.SetText(s.State.HttpUsername)
+
+	passwordLabel := widget.NewLabel("Password")
+	s.password = widget.NewPasswordEntry()
+	s.password.SetPlaceHolder("")
+	s.password.SetText(s.State.HttpPassword)
+
+	validation := func(input string) {
+		if s.address != nil && s.address.Text != "" {
+			next.Enable()
+		} else {
+			next.Disable()
+		}
+	}
+
+	validation("")
+
+	s.address.OnChanged = validation
+
+	formLayout := container.New(layout.NewFormLayout(), addressLabel, s.address, usernameLabel, s.username, passwordLabel, s.password)
+
+	middle := container.New(layout.NewVBoxLayout(), heading, label1, link, formLayout, s.infinite, s.result, s.logs)
+
+	content := container.NewBorder(nil, bottom, nil, nil, middle)
+
+	return content
+}
+
+func (s HttpTerraformStateStep) getState() state.State {
+	newState := s.State
+	newState.HttpAddress = strings.TrimRight(strings.TrimSpace(s.address.Text), "/")
+	newState.HttpUsername = strings.TrimSpace(s.username.Text)
+	newState.HttpPassword = s.password.Text
+	return newState
+}
@@ -89,6 +89,55 @@ variable "terraform_state_bucket_region" {
   sensitive   = false
   description = "The S3 bucket region used to save Terraform state"
 }
+variable "terraform_state_aws_s3_endpoint" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The URL of an S3 compatible storage service, like MinIO, used to save Terraform state"
+  default     = ""
+}
+variable "terraform_state_aws_s3_use_path_style" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "Whether to use path-style addressing to access the S3 bucket"
+  default     = "False"
+}
+variable "terraform_state_aws_ca_bundle" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The path on the worker to a PEM file with the certificate authorities trusted when accessing the S3 bucket"
+  default     = ""
+}
+variable "terraform_state_aws_credentials" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "How the runbooks access the S3 bucket. One of AccessKey, WebIdentity, or Worker"
+  default     = "AccessKey"
+}
+variable "terraform_state_aws_role_arn" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The ARN of the role assumed to access the S3 bucket"
+  default     = ""
+}
+variable "terraform_state_aws_role_session_name" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The session name used when assuming the role"
+  default     = "octoterrawiz"
+}
+variable "terraform_state_aws_role_external_id" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The external ID used when assuming the role"
+  default     = ""
+}
 variable "octopus_project_name" {
   type        = string
   nullable    = false
@@ -116,6 +165,60 @@ variable "terraform_state_azure_storage_container" {
   description = "The Azure storage account container used by the terraform state"
 }
 
+variable "terraform_state_azure_credentials" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "How the runbooks access the storage account. One of ClientSecret, Certificate, OIDC, or ManagedIdentity"
+  default     = "ClientSecret"
+}
+
+variable "terraform_state_azure_application_id" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The azure account application id"
+  default     = ""
+}
+
+variable "terraform_state_azure_subscription_id" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The azure account subscription id"
+  default     = ""
+}
+
+variable "terraform_state_azure_tenant_id" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The azure account tenant id"
+  default     = ""
+}
+
+variable "terraform_state_azure_certificate_path" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The path on the worker to the certificate used to access the storage account"
+  default     = ""
+}
+
+variable "terraform_state_google_bucket" {
+  type        = string
+  nullable    = true
+  sensitive   = false
+  description = "The Google Cloud Storage bucket used to save Terraform state"
+}
+
+variable "terraform_state_http_address" {
+  type        = string
+  nullable    = true
+  sensitive   = false
+  description = "The base address of the HTTP backend used to save Terraform state"
+}
+
 variable "terraform_backend" {
   type        = string
   nullable    = false
@@ -124,6 +227,48 @@ variable "terraform_backend" {
   default     = "AWS S3"
 }
 
+locals {
+  # Additional backend settings used when the state is saved in S3 compatible storage, like MinIO.
+  # These services do not implement the AWS token and metadata services, so the related checks are skipped.
+  s3_compatible_init_params = join(" ", compact([
+    var.terraform_state_aws_s3_endpoint == "" ? "" : "-backend-config=\"endpoint=${var.terraform_state_aws_s3_endpoint}\" -backend-config=\"skip_credentials_validation=true\" -backend-config=\"skip_region_validation=true\" -backend-config=\"skip_requesting_account_id=true\" -backend-config=\"skip_metadata_api_check=true\" -backend-config=\"skip_s3_checksum=true\"",
+    lower(var.terraform_state_aws_s3_use_path_style) == "true" ? "-backend-config=\"use_path_style=true\"" : "",
+    var.terraform_state_aws_ca_bundle == "" ? "" : "-backend-config=\"custom_ca_bundle=${var.terraform_state_aws_ca_bundle}\"",
+  ]))
+}
+
+locals {
+  # Backend settings used when the runbooks can not authenticate with an Octopus Azure account.
+  # Octopus accounts do not support client certificates, so the certificate is read from the same path on the worker.
+  # Managed identities belong to the worker running the runbooks.
+  azure_auth_init_params = lookup({
+    "Certificate" = join(" ", compact([
+      "-backend-config=\"subscription_id=${var.terraform_state_azure_subscription_id}\"",
+      "-backend-config=\"tenant_id=${var.terraform_state_azure_tenant_id}\"",
+      "-backend-config=\"client_id=${var.terraform_state_azure_application_id}\"",
+      "-backend-config=\"client_certificate_path=${var.terraform_state_azure_certificate_path}\"",
+      "#{if Terraform.Azure.CertificatePassword}-backend-config=\"client_certificate_password=#{Terraform.Azure.CertificatePassword}\"#{/if}",
+    ]))
+    "ManagedIdentity" = join(" ", compact([
+      "-backend-config=\"use_msi=true\"",
+      "-backend-config=\"subscription_id=${var.terraform_state_azure_subscription_id}\"",
+      var.terraform_state_azure_tenant_id == "" ? "" : "-backend-config=\"tenant_id=${var.terraform_state_azure_tenant_id}\"",
+      var.terraform_state_azure_application_id == "" ? "" : "-backend-config=\"client_id=${var.terraform_state_azure_application_id}\"",
+    ]))
+  }, var.terraform_state_azure_credentials, "")
+}
+
+locals {
+  # Maps the backend selected in the wizard to the name of the backend block added to the serialized modules
+  terraform_backend_types = {
+    "AWS S3"               = "s3"
+    "Azure Storage"        = "azurerm"
+    "Google Cloud Storage" = "gcs"
+    "HTTP"                 = "http"
+    "PostgreSQL"           = "pg"
+  }
+}
+
 variable "use_container_images" {
   type        = string
   nullable    = false
@@ -233,7 +378,7 @@ resource "octopusdeploy_runbook_process" "runbook" {
       properties                         = {
         "SerializeProject.ThisInstance.Server.Url" = "#{Octopus.Source.Server}"
         "Octopus.Action.Template.Id" = var.octopus_serialize_actiontemplateid
-        "SerializeProject.ThisInstance.Terraform.Backend" = var.terraform_backend == "AWS S3" ? "s3" : "azurerm"
+        "SerializeProject.ThisInstance.Terraform.Backend" = lookup(local.terraform_backend_types, var.terraform_backend, "azurerm")
         "Octopus.Action.Template.Version" = "1"
         "SerializeProject.Exported.Project.Name" = "#{Octopus.Project.Name}"
         "Octopus.Action.Script.Syntax" = "Python"
@@ -248,7 +393,7 @@ resource "octopusdeploy_runbook_process" "runbook" {
         "SerializeProject.Project.Space.DefaultSecrets" = var.default_secret_variables
         "SerializeProject.ThisInstance.Api.Key" = "#{Octopus.Source.ApiKey}"
         "SerializeProject.Exported.Project.IncludeStepTemplates" = "True"
-        "SerializeProject.Exported.Project.IgnoredAccounts" = "Octoterra AWS Account,Octoterra Azure Account"
+        "SerializeProject.Exported.Project.IgnoredAccounts" = "Octoterra AWS Account,Octoterra Azure Account,Octoterra Google Cloud Account"
         "SerializeProject.Exported.Project.IgnoredTenants" = ""
         "SerializeProject.Exported.Project.ExcludeCacProjectValues" = "True"
         "SerializeProject.Exported.Project.LookupProjectLinkTenants" = "True"
@@ -312,12 +457,12 @@ resource "octopusdeploy_runbook_process" "deploy_project_aws" {
         "OctoterraApply.AWS.Account" = "Terraform.AWS.Account"
         "OctoterraApply.AWS.S3.BucketKey" = "Project_#{if OctoterraWiz.Destination.ProjectName}#{OctoterraWiz.Destination.ProjectName | Replace \"[^A-Za-z0-9]\" \"_\"}#{/if}#{unless OctoterraWiz.Destination.ProjectName}#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\"}#{/unless}"
         "Octopus.Action.Terraform.Workspace" = "#{OctoterraApply.Terraform.Workspace.Name}"
-        "Octopus.Action.AwsAccount.UseInstanceRole" = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole" = var.terraform_state_aws_credentials == "Worker" ? "True" : "False"
         "Octopus.Action.AwsAccount.Variable" = "#{OctoterraApply.AWS.Account}"
         "Octopus.Action.Aws.Region" = "#{OctoterraApply.AWS.S3.BucketRegion}"
         "Octopus.Action.Template.Id" = var.octopus_deploys3_actiontemplateid
         "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
-        "Octopus.Action.Terraform.AdditionalInitParams" = "-backend-config=\"bucket=#{OctoterraApply.AWS.S3.BucketName}\" -backend-config=\"region=#{OctoterraApply.AWS.S3.BucketRegion}\" -backend-config=\"key=#{OctoterraApply.AWS.S3.BucketKey}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+        "Octopus.Action.Terraform.AdditionalInitParams" = "-backend-config=\"bucket=#{OctoterraApply.AWS.S3.BucketName}\" -backend-config=\"region=#{OctoterraApply.AWS.S3.BucketRegion}\" -backend-config=\"key=#{OctoterraApply.AWS.S3.BucketKey}\" ${local.s3_compatible_init_params} #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
         "Octopus.Action.Terraform.TemplateDirectory" = "space_population"
         "Octopus.Action.Package.DownloadOnTentacle" = "False"
         "Octopus.Action.Terraform.AllowPluginDownloads" = "True"
@@ -336,7 +481,11 @@ resource "octopusdeploy_runbook_process" "deploy_project_aws" {
         "Octopus.Action.Template.Version" = "1"
         "Octopus.Action.GoogleCloud.UseVMServiceAccount" = "True"
         "Octopus.Action.Terraform.ManagedAccount" = "AWS"
-        "Octopus.Action.Aws.AssumeRole" = "False"
+        "Octopus.Action.Aws.AssumeRole" = var.terraform_state_aws_role_arn != "" && var.terraform_state_aws_credentials != "WebIdentity" ? "True" : "False"
+        "Octopus.Action.Aws.AssumedRoleArn" = var.terraform_state_aws_role_arn
+        "Octopus.Action.Aws.AssumedRoleSession" = var.terraform_state_aws_role_session_name
+        "Octopus.Action.Aws.AssumeRoleExternalId" = var.terraform_state_aws_role_external_id
+        "Octopus.Action.Aws.AssumeRoleSessionDurationSeconds" = "3600"
         "OctoterraApply.Terraform.Package.Id" = jsonencode({
           "PackageId" = "${replace(var.octopus_project_name, "/[^A-Za-z0-9]/", "_")}"
           "FeedId" = "${data.octopusdeploy_feeds.built_in_feed.feeds[0].id}"
@@ -425,12 +574,12 @@ resource "octopusdeploy_runbook_process" "deploy_project_azure" {
         "Octopus.Action.Script.ScriptSource"                    = "Package"
         "Octopus.Action.Terraform.GoogleCloudAccount"           = "False"
         "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
-        "Octopus.Action.Terraform.AzureAccount"                 = "True"
+        "Octopus.Action.Terraform.AzureAccount"                 = contains(["ClientSecret", "OIDC"], var.terraform_state_azure_credentials) ? "True" : "False"
         "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
         "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "True"
         "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
         "Octopus.Action.Terraform.ManagedAccount"               = "None"
-        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"resource_group_name=#{OctoterraApply.Azure.Storage.ResourceGroup}\" -backend-config=\"storage_account_name=#{OctoterraApply.Azure.Storage.AccountName}\" -backend-config=\"container_name=#{OctoterraApply.Azure.Storage.Container}\" -backend-config=\"key=#{OctoterraApply.Azure.Storage.Key}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"resource_group_name=#{OctoterraApply.Azure.Storage.ResourceGroup}\" -backend-config=\"storage_account_name=#{OctoterraApply.Azure.Storage.AccountName}\" -backend-config=\"container_name=#{OctoterraApply.Azure.Storage.Container}\" -backend-config=\"key=#{OctoterraApply.Azure.Storage.Key}\" ${local.azure_auth_init_params} #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
         "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
       }
 
@@ -458,4 +607,232 @@ resource "octopusdeploy_runbook_process" "deploy_project_azure" {
     target_roles = []
   }
 
-}
\ No newline at end of file
+}
+
+resource "octopusdeploy_runbook_process" "deploy_project_gcs" {
+  runbook_id = octopusdeploy_runbook.deploy_project.id
+  count      = var.terraform_backend == "Google Cloud Storage" ? 1 : 0
+
+  step {
+    condition           = "Success"
+    name                = "Octopus - Populate Octoterra Space (Google Cloud Storage Backend)"
+    package_requirement = "LetOctopusDecide"
+    start_trigger       = "StartAfterPrevious"
+
+    action {
+      action_type                        = "Octopus.TerraformApply"
+      name                               = "Octopus - Populate Octoterra Space (Google Cloud Storage Backend)"
+      condition                          = "Success"
+      run_on_server                      = true
+      is_disabled                        = false
+      can_be_used_for_project_versioning = true
+      is_required                        = false
+      # Use the ubuntu worker pool if it is present, or use the default otherwise
+      worker_pool_id                     = length(data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools) == 0 ? "" : data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools[0].id
+      properties = {
+        "Octopus.Action.RunOnServer"                            = "true"
+        "Octopus.Action.Terraform.AllowPluginDownloads"         = "True"
+        "Octopus.Action.Package.DownloadOnTentacle"             = "False"
+        "Octopus.Action.Terraform.FileSubstitution"             = "**/project_variable_sensitive*.tf\n**/terraform.tfvars"
+        "Octopus.Action.Terraform.TemplateDirectory"            = "space_population"
+        "Octopus.Action.Script.ScriptSource"                    = "Package"
+        "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
+        "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
+        "Octopus.Action.Aws.AssumeRole"                         = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
+        "Octopus.Action.Terraform.AzureAccount"                 = "False"
+        "Octopus.Action.GoogleCloud.ImpersonateServiceAccount"  = "False"
+        "Octopus.Action.Terraform.ManagedAccount"               = "None"
+        "OctoterraApply.Octopus.ServerUrl"                      = "#{Octopus.Destination.Server}"
+        "OctoterraApply.Octopus.ApiKey"                         = "#{Octopus.Destination.ApiKey}"
+        "OctoterraApply.Octopus.SpaceID"                        = "#{Octopus.Destination.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalActionParams"       = "-var=octopus_server=#{OctoterraApply.Octopus.ServerUrl} -var=octopus_apikey=#{OctoterraApply.Octopus.ApiKey} -var=octopus_space_id=#{OctoterraApply.Octopus.SpaceID} #{if OctoterraApply.Terraform.AdditionalApplyParams}#{OctoterraApply.Terraform.AdditionalApplyParams}#{/if}"
+        "OctopusUseBundledTooling"                              = "False"
+        "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
+        "Octopus.Action.Terraform.Workspace"                    = "#{OctoterraApply.Terraform.Workspace.Name}"
+        "OctoterraApply.Terraform.Workspace.Name"               = "#{OctoterraApply.Octopus.SpaceID}"
+        "Octopus.Action.Terraform.GoogleCloudAccount"           = "True"
+        "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "False"
+        "Octopus.Action.GoogleCloudAccount.Variable"            = "#{OctoterraApply.Google.Account}"
+        "OctoterraApply.Google.Account"                         = "Terraform.Google.Account"
+        "OctoterraApply.Google.Storage.Bucket"                  = var.terraform_state_google_bucket
+        "OctoterraApply.Google.Storage.Prefix"                  = "Project_#{if OctoterraWiz.Destination.ProjectName}#{OctoterraWiz.Destination.ProjectName | Replace \"[^A-Za-z0-9]\" \"_\"}#{/if}#{unless OctoterraWiz.Destination.ProjectName}#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\"}#{/unless}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"bucket=#{OctoterraApply.Google.Storage.Bucket}\" -backend-config=\"prefix=#{OctoterraApply.Google.Storage.Prefix}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+        "OctoterraApply.Terraform.AdditionalApplyParams"        = "#{if OctoterraWiz.Destination.ProjectName}\"-var=project_#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\" | ToLower}_name=#{OctoterraWiz.Destination.ProjectName}\"#{/if}"
+      }
+
+      container {
+        feed_id = lower(var.use_container_images) == "true" ? data.octopusdeploy_feeds.docker_feed.feeds[0].id : ""
+        image   = lower(var.use_container_images) == "true" ? "ghcr.io/octopusdeploylabs/terraform-workertools" : ""
+      }
+
+      environments = []
+      excluded_environments = []
+      channels = []
+      tenant_tags = []
+
+      primary_package {
+        package_id           = replace(var.octopus_project_name, "/[^A-Za-z0-9]/", "_")
+        acquisition_location = "Server"
+        feed_id              = data.octopusdeploy_feeds.built_in_feed.feeds[0].id
+        properties           = { SelectionMode = "immediate" }
+      }
+
+      features = []
+    }
+
+    properties = {}
+    target_roles = []
+  }
+
+}
+
+resource "octopusdeploy_runbook_process" "deploy_project_http" {
+  runbook_id = octopusdeploy_runbook.deploy_project.id
+  count      = var.terraform_backend == "HTTP" ? 1 : 0
+
+  step {
+    condition           = "Success"
+    name                = "Octopus - Populate Octoterra Space (HTTP Backend)"
+    package_requirement = "LetOctopusDecide"
+    start_trigger       = "StartAfterPrevious"
+
+    action {
+      action_type                        = "Octopus.TerraformApply"
+      name                               = "Octopus - Populate Octoterra Space (HTTP Backend)"
+      condition                          = "Success"
+      run_on_server                      = true
+      is_disabled                        = false
+      can_be_used_for_project_versioning = true
+      is_required                        = false
+      # Use the ubuntu worker pool if it is present, or use the default otherwise
+      worker_pool_id                     = length(data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools) == 0 ? "" : data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools[0].id
+      properties = {
+        "Octopus.Action.RunOnServer"                            = "true"
+        "Octopus.Action.Terraform.AllowPluginDownloads"         = "True"
+        "Octopus.Action.Package.DownloadOnTentacle"             = "False"
+        "Octopus.Action.Terraform.FileSubstitution"             = "**/project_variable_sensitive*.tf\n**/terraform.tfvars"
+        "Octopus.Action.Terraform.TemplateDirectory"            = "space_population"
+        "Octopus.Action.Script.ScriptSource"                    = "Package"
+        "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
+        "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
+        "Octopus.Action.Aws.AssumeRole"                         = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
+        "Octopus.Action.Terraform.AzureAccount"                 = "False"
+        "Octopus.Action.GoogleCloud.ImpersonateServiceAccount"  = "False"
+        "Octopus.Action.Terraform.ManagedAccount"               = "None"
+        "OctoterraApply.Octopus.ServerUrl"                      = "#{Octopus.Destination.Server}"
+        "OctoterraApply.Octopus.ApiKey"                         = "#{Octopus.Destination.ApiKey}"
+        "OctoterraApply.Octopus.SpaceID"                        = "#{Octopus.Destination.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalActionParams"       = "-var=octopus_server=#{OctoterraApply.Octopus.ServerUrl} -var=octopus_apikey=#{OctoterraApply.Octopus.ApiKey} -var=octopus_space_id=#{OctoterraApply.Octopus.SpaceID} #{if OctoterraApply.Terraform.AdditionalApplyParams}#{OctoterraApply.Terraform.AdditionalApplyParams}#{/if}"
+        "OctopusUseBundledTooling"                              = "False"
+        "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
+        "Octopus.Action.Terraform.GoogleCloudAccount"           = "False"
+        "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "True"
+        "OctoterraApply.Http.Address"                           = var.terraform_state_http_address
+        # The http backend does not support workspaces, so the destination space is included in the state address
+        "OctoterraApply.Http.Key"                               = "Project_#{if OctoterraWiz.Destination.ProjectName}#{OctoterraWiz.Destination.ProjectName | Replace \"[^A-Za-z0-9]\" \"_\"}#{/if}#{unless OctoterraWiz.Destination.ProjectName}#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\"}#{/unless}_#{OctoterraApply.Octopus.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"address=#{OctoterraApply.Http.Address}/#{OctoterraApply.Http.Key}\" -backend-config=\"lock_address=#{OctoterraApply.Http.Address}/#{OctoterraApply.Http.Key}\" -backend-config=\"unlock_address=#{OctoterraApply.Http.Address}/#{OctoterraApply.Http.Key}\" #{if Terraform.Http.Username}-backend-config=\"username=#{Terraform.Http.Username}\" -backend-config=\"password=#{Terraform.Http.Password}\"#{/if} #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+        "OctoterraApply.Terraform.AdditionalApplyParams"        = "#{if OctoterraWiz.Destination.ProjectName}\"-var=project_#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\" | ToLower}_name=#{OctoterraWiz.Destination.ProjectName}\"#{/if}"
+      }
+
+      container {
+        feed_id = lower(var.use_container_images) == "true" ? data.octopusdeploy_feeds.docker_feed.feeds[0].id : ""
+        image   = lower(var.use_container_images) == "true" ? "ghcr.io/octopusdeploylabs/terraform-workertools" : ""
+      }
+
+      environments = []
+      excluded_environments = []
+      channels = []
+      tenant_tags = []
+
+      primary_package {
+        package_id           = replace(var.octopus_project_name, "/[^A-Za-z0-9]/", "_")
+        acquisition_location = "Server"
+        feed_id              = data.octopusdeploy_feeds.built_in_feed.feeds[0].id
+        properties           = { SelectionMode = "immediate" }
+      }
+
+      features = []
+    }
+
+    properties = {}
+    target_roles = []
+  }
+
+}
+
+resource "octopusdeploy_runbook_process" "deploy_project_pg" {
+  runbook_id = octopusdeploy_runbook.deploy_project.id
+  count      = var.terraform_backend == "PostgreSQL" ? 1 : 0
+
+  step {
+    condition           = "Success"
+    name                = "Octopus - Populate Octoterra Space (PostgreSQL Backend)"
+    package_requirement = "LetOctopusDecide"
+    start_trigger       = "StartAfterPrevious"
+
+    action {
+      action_type                        = "Octopus.TerraformApply"
+      name                               = "Octopus - Populate Octoterra Space (PostgreSQL Backend)"
+      condition                          = "Success"
+      run_on_server                      = true
+      is_disabled                        = false
+      can_be_used_for_project_versioning = true
+      is_required                        = false
+      # Use the ubuntu worker pool if it is present, or use the default otherwise
+      worker_pool_id                     = length(data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools) == 0 ? "" : data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools[0].id
+      properties = {
+        "Octopus.Action.RunOnServer"                            = "true"
+        "Octopus.Action.Terraform.AllowPluginDownloads"         = "True"
+        "Octopus.Action.Package.DownloadOnTentacle"             = "False"
+        "Octopus.Action.Terraform.FileSubstitution"             = "**/project_variable_sensitive*.tf\n**/terraform.tfvars"
+        "Octopus.Action.Terraform.TemplateDirectory"            = "space_population"
+        "Octopus.Action.Script.ScriptSource"                    = "Package"
+        "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
+        "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
+        "Octopus.Action.Aws.AssumeRole"                         = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
+        "Octopus.Action.Terraform.AzureAccount"                 = "False"
+        "Octopus.Action.GoogleCloud.ImpersonateServiceAccount"  = "False"
+        "Octopus.Action.Terraform.ManagedAccount"               = "None"
+        "OctoterraApply.Octopus.ServerUrl"                      = "#{Octopus.Destination.Server}"
+        "OctoterraApply.Octopus.ApiKey"                         = "#{Octopus.Destination.ApiKey}"
+        "OctoterraApply.Octopus.SpaceID"                        = "#{Octopus.Destination.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalActionParams"       = "-var=octopus_server=#{OctoterraApply.Octopus.ServerUrl} -var=octopus_apikey=#{OctoterraApply.Octopus.ApiKey} -var=octopus_space_id=#{OctoterraApply.Octopus.SpaceID} #{if OctoterraApply.Terraform.AdditionalApplyParams}#{OctoterraApply.Terraform.AdditionalApplyParams}#{/if}"
+        "OctopusUseBundledTooling"                              = "False"
+        "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
+        "Octopus.Action.Terraform.Workspace"                    = "#{OctoterraApply.Terraform.Workspace.Name}"
+        "OctoterraApply.Terraform.Workspace.Name"               = "#{OctoterraApply.Octopus.SpaceID}"
+        "Octopus.Action.Terraform.GoogleCloudAccount"           = "False"
+        "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "True"
+        "OctoterraApply.Postgres.Schema"                        = "project_#{if OctoterraWiz.Destination.ProjectName}#{OctoterraWiz.Destination.ProjectName | Replace \"[^A-Za-z0-9]\" \"_\" | ToLower}#{/if}#{unless OctoterraWiz.Destination.ProjectName}#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\" | ToLower}#{/unless}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"conn_str=#{Terraform.Postgres.ConnectionString}\" -backend-config=\"schema_name=#{OctoterraApply.Postgres.Schema}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+        "OctoterraApply.Terraform.AdditionalApplyParams"        = "#{if OctoterraWiz.Destination.ProjectName}\"-var=project_#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\" | ToLower}_name=#{OctoterraWiz.Destination.ProjectName}\"#{/if}"
+      }
+
+      container {
+        feed_id = lower(var.use_container_images) == "true" ? data.octopusdeploy_feeds.docker_feed.feeds[0].id : ""
+        image   = lower(var.use_container_images) == "true" ? "ghcr.io/octopusdeploylabs/terraform-workertools" : ""
+      }
+
+      environments = []
+      excluded_environments = []
+      channels = []
+      tenant_tags = []
+
+      primary_package {
+        package_id           = replace(var.octopus_project_name, "/[^A-Za-z0-9]/", "_")
+        acquisition_location = "Server"
+        feed_id              = data.octopusdeploy_feeds.built_in_feed.feeds[0].id
+        properties           = { SelectionMode = "immediate" }
+      }
+
+      features = []
+    }
+
+    properties = {}
+    target_roles = []
+  }
+
+}
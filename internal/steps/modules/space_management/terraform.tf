@@ -92,6 +92,55 @@ variable "terraform_state_bucket_region" {
   sensitive   = false
   description = "The S3 bucket region used to save Terraform state"
 }
+variable "terraform_state_aws_s3_endpoint" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The URL of an S3 compatible storage service, like MinIO, used to save Terraform state"
+  default     = ""
+}
+variable "terraform_state_aws_s3_use_path_style" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "Whether to use path-style addressing to access the S3 bucket"
+  default     = "False"
+}
+variable "terraform_state_aws_ca_bundle" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The path on the worker to a PEM file with the certificate authorities trusted when accessing the S3 bucket"
+  default     = ""
+}
+variable "terraform_state_aws_credentials" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "How the runbooks access the S3 bucket. One of AccessKey, WebIdentity, or Worker"
+  default     = "AccessKey"
+}
+variable "terraform_state_aws_role_arn" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The ARN of the role assumed to access the S3 bucket"
+  default     = ""
+}
+variable "terraform_state_aws_role_session_name" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The session name used when assuming the role"
+  default     = "octoterrawiz"
+}
+variable "terraform_state_aws_role_external_id" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The external ID used when assuming the role"
+  default     = ""
+}
 variable "terraform_state_aws_accesskey" {
   type        = string
   nullable    = true
@@ -154,6 +203,72 @@ variable "terraform_state_azure_storage_container" {
   description = "The Azure storage account container used by the terraform state"
 }
 
+variable "terraform_state_azure_credentials" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "How the runbooks access the storage account. One of ClientSecret, Certificate, OIDC, or ManagedIdentity"
+  default     = "ClientSecret"
+}
+
+variable "terraform_state_azure_certificate_path" {
+  type        = string
+  nullable    = false
+  sensitive   = false
+  description = "The path on the worker to the certificate used to access the storage account"
+  default     = ""
+}
+
+variable "terraform_state_azure_certificate_password" {
+  type        = string
+  nullable    = false
+  sensitive   = true
+  description = "The password of the certificate used to access the storage account"
+  default     = ""
+}
+
+variable "terraform_state_google_bucket" {
+  type        = string
+  nullable    = true
+  sensitive   = false
+  description = "The Google Cloud Storage bucket used to save Terraform state"
+}
+
+variable "terraform_state_google_credentials" {
+  type        = string
+  nullable    = true
+  sensitive   = true
+  description = "The JSON key of the Google Cloud service account used to access the bucket"
+}
+
+variable "terraform_state_http_address" {
+  type        = string
+  nullable    = true
+  sensitive   = false
+  description = "The base address of the HTTP backend used to save Terraform state"
+}
+
+variable "terraform_state_http_username" {
+  type        = string
+  nullable    = true
+  sensitive   = false
+  description = "The username used to access the HTTP backend"
+}
+
+variable "terraform_state_http_password" {
+  type        = string
+  nullable    = true
+  sensitive   = true
+  description = "The password used to access the HTTP backend"
+}
+
+variable "terraform_state_postgres_connection_string" {
+  type        = string
+  nullable    = true
+  sensitive   = true
+  description = "The connection string of the PostgreSQL database used to save Terraform state"
+}
+
 variable "terraform_backend" {
   type        = string
   nullable    = false
@@ -162,6 +277,48 @@ variable "terraform_backend" {
   default     = "AWS S3"
 }
 
+locals {
+  # Additional backend settings used when the state is saved in S3 compatible storage, like MinIO.
+  # These services do not implement the AWS token and metadata services, so the related checks are skipped.
+  s3_compatible_init_params = join(" ", compact([
+    var.terraform_state_aws_s3_endpoint == "" ? "" : "-backend-config=\"endpoint=${var.terraform_state_aws_s3_endpoint}\" -backend-config=\"skip_credentials_validation=true\" -backend-config=\"skip_region_validation=true\" -backend-config=\"skip_requesting_account_id=true\" -backend-config=\"skip_metadata_api_check=true\" -backend-config=\"skip_s3_checksum=true\"",
+    lower(var.terraform_state_aws_s3_use_path_style) == "true" ? "-backend-config=\"use_path_style=true\"" : "",
+    var.terraform_state_aws_ca_bundle == "" ? "" : "-backend-config=\"custom_ca_bundle=${var.terraform_state_aws_ca_bundle}\"",
+  ]))
+}
+
+locals {
+  # Backend settings used when the runbooks can not authenticate with an Octopus Azure account.
+  # Octopus accounts do not support client certificates, so the certificate is read from the same path on the worker.
+  # Managed identities belong to the worker running the runbooks.
+  azure_auth_init_params = lookup({
+    "Certificate" = join(" ", compact([
+      "-backend-config=\"subscription_id=${var.terraform_state_azure_subscription_id}\"",
+      "-backend-config=\"tenant_id=${var.terraform_state_azure_tenant_id}\"",
+      "-backend-config=\"client_id=${var.terraform_state_azure_application_id}\"",
+      "-backend-config=\"client_certificate_path=${var.terraform_state_azure_certificate_path}\"",
+      "#{if Terraform.Azure.CertificatePassword}-backend-config=\"client_certificate_password=#{Terraform.Azure.CertificatePassword}\"#{/if}",
+    ]))
+    "ManagedIdentity" = join(" ", compact([
+      "-backend-config=\"use_msi=true\"",
+      "-backend-config=\"subscription_id=${var.terraform_state_azure_subscription_id}\"",
+      var.terraform_state_azure_tenant_id == "" ? "" : "-backend-config=\"tenant_id=${var.terraform_state_azure_tenant_id}\"",
+      var.terraform_state_azure_application_id == "" ? "" : "-backend-config=\"client_id=${var.terraform_state_azure_application_id}\"",
+    ]))
+  }, var.terraform_state_azure_credentials, "")
+}
+
+locals {
+  # Maps the backend selected in the wizard to the name of the backend block added to the serialized modules
+  terraform_backend_types = {
+    "AWS S3"               = "s3"
+    "Azure Storage"        = "azurerm"
+    "Google Cloud Storage" = "gcs"
+    "HTTP"                 = "http"
+    "PostgreSQL"           = "pg"
+  }
+}
+
 variable "default_secret_variables" {
   type        = string
   nullable    = false
@@ -225,7 +382,7 @@ data "octopusdeploy_worker_pools" "ubuntu_worker_pool" {
 }
 
 resource "octopusdeploy_aws_account" "account_aws_account" {
-  count                             = var.terraform_backend == "AWS S3" ? 1 : 0
+  count                             = var.terraform_backend == "AWS S3" && var.terraform_state_aws_credentials == "AccessKey" ? 1 : 0
   name                              = "Octoterra AWS Account"
   description                       = ""
   environments                      = null
@@ -236,8 +393,21 @@ resource "octopusdeploy_aws_account" "account_aws_account" {
   secret_key                        = var.terraform_state_aws_secretkey
 }
 
+# A web identity token from the local machine can not be used by the runbooks, so an OpenID Connect account
+# is created instead. Octopus issues the token, and the role must trust the Octopus server.
+resource "octopusdeploy_aws_openid_connect_account" "account_aws_oidc" {
+  count                             = var.terraform_backend == "AWS S3" && var.terraform_state_aws_credentials == "WebIdentity" ? 1 : 0
+  name                              = "Octoterra AWS Account"
+  description                       = ""
+  environments                      = []
+  tenants                           = []
+  tenanted_deployment_participation = "TenantedOrUntenanted"
+  role_arn                          = var.terraform_state_aws_role_arn
+  session_duration                  = 3600
+}
+
 resource "octopusdeploy_azure_service_principal" "account_azure" {
-  count                             = var.terraform_backend == "Azure Storage" ? 1 : 0
+  count                             = var.terraform_backend == "Azure Storage" && var.terraform_state_azure_credentials == "ClientSecret" ? 1 : 0
   description                       = "Octoterra Azure Account"
   name                              = "Octoterra Azure Account"
   environments = []
@@ -249,6 +419,29 @@ resource "octopusdeploy_azure_service_principal" "account_azure" {
   tenant_id                         = var.terraform_state_azure_tenant_id
 }
 
+# Federated tokens can not be used by the runbooks, so Octopus issues its own tokens to the application instead.
+# The application must have a federated credential trusting the Octopus server.
+resource "octopusdeploy_azure_openid_connect" "account_azure_oidc" {
+  count                             = var.terraform_backend == "Azure Storage" && var.terraform_state_azure_credentials == "OIDC" ? 1 : 0
+  description                       = "Octoterra Azure Account"
+  name                              = "Octoterra Azure Account"
+  environments                      = []
+  tenants                           = []
+  tenanted_deployment_participation = "TenantedOrUntenanted"
+  application_id                    = var.terraform_state_azure_application_id
+  subscription_id                   = var.terraform_state_azure_subscription_id
+  tenant_id                         = var.terraform_state_azure_tenant_id
+}
+
+resource "octopusdeploy_gcp_account" "account_google" {
+  count                             = var.terraform_backend == "Google Cloud Storage" ? 1 : 0
+  description                       = "Octoterra Google Cloud Account"
+  name                              = "Octoterra Google Cloud Account"
+  environments = []
+  tenants = []
+  tenanted_deployment_participation = "TenantedOrUntenanted"
+  json_key                          = base64encode(var.terraform_state_google_credentials)
+}
 
 resource "octopusdeploy_project_group" "octoterra" {
   name = "Octoterra"
@@ -326,8 +519,8 @@ resource "octopusdeploy_variable" "aws_account" {
   is_sensitive = false
   is_editable  = true
   owner_id     = octopusdeploy_library_variable_set.octopus_library_variable_set.id
-  value        = octopusdeploy_aws_account.account_aws_account[0].id
-  count        = var.terraform_backend == "AWS S3" ? 1 : 0
+  value        = one(concat(octopusdeploy_aws_account.account_aws_account[*].id, octopusdeploy_aws_openid_connect_account.account_aws_oidc[*].id))
+  count        = var.terraform_backend == "AWS S3" && var.terraform_state_aws_credentials != "Worker" ? 1 : 0
 }
 
 resource "octopusdeploy_variable" "azure_account" {
@@ -337,8 +530,63 @@ resource "octopusdeploy_variable" "azure_account" {
   is_sensitive = false
   is_editable  = true
   owner_id     = octopusdeploy_library_variable_set.octopus_library_variable_set.id
-  value        = octopusdeploy_azure_service_principal.account_azure[0].id
-  count        = var.terraform_backend == "Azure Storage" ? 1 : 0
+  value        = one(concat(octopusdeploy_azure_service_principal.account_azure[*].id, octopusdeploy_azure_openid_connect.account_azure_oidc[*].id))
+  count        = var.terraform_backend == "Azure Storage" && contains(["ClientSecret", "OIDC"], var.terraform_state_azure_credentials) ? 1 : 0
+}
+
+resource "octopusdeploy_variable" "azure_certificate_password" {
+  name            = "Terraform.Azure.CertificatePassword"
+  type            = "Sensitive"
+  description     = "Octoterra Azure certificate password"
+  is_sensitive    = true
+  is_editable     = true
+  owner_id        = octopusdeploy_library_variable_set.octopus_library_variable_set.id
+  sensitive_value = var.terraform_state_azure_certificate_password
+  count           = var.terraform_backend == "Azure Storage" && var.terraform_state_azure_credentials == "Certificate" ? 1 : 0
+}
+
+resource "octopusdeploy_variable" "google_account" {
+  name         = "Terraform.Google.Account"
+  type         = "GoogleCloudAccount"
+  description  = "Octoterra Google Cloud account"
+  is_sensitive = false
+  is_editable  = true
+  owner_id     = octopusdeploy_library_variable_set.octopus_library_variable_set.id
+  value        = octopusdeploy_gcp_account.account_google[0].id
+  count        = var.terraform_backend == "Google Cloud Storage" ? 1 : 0
+}
+
+resource "octopusdeploy_variable" "http_username" {
+  name         = "Terraform.Http.Username"
+  type         = "String"
+  description  = "Octoterra HTTP backend username"
+  is_sensitive = false
+  is_editable  = true
+  owner_id     = octopusdeploy_library_variable_set.octopus_library_variable_set.id
+  value        = var.terraform_state_http_username
+  count        = var.terraform_backend == "HTTP" ? 1 : 0
+}
+
+resource "octopusdeploy_variable" "http_password" {
+  name            = "Terraform.Http.Password"
+  type            = "Sensitive"
+  description     = "Octoterra HTTP backend password"
+  is_sensitive    = true
+  is_editable     = true
+  owner_id        = octopusdeploy_library_variable_set.octopus_library_variable_set.id
+  sensitive_value = var.terraform_state_http_password
+  count           = var.terraform_backend == "HTTP" ? 1 : 0
+}
+
+resource "octopusdeploy_variable" "postgres_connection_string" {
+  name            = "Terraform.Postgres.ConnectionString"
+  type            = "Sensitive"
+  description     = "Octoterra PostgreSQL backend connection string"
+  is_sensitive    = true
+  is_editable     = true
+  owner_id        = octopusdeploy_library_variable_set.octopus_library_variable_set.id
+  sensitive_value = var.terraform_state_postgres_connection_string
+  count           = var.terraform_backend == "PostgreSQL" ? 1 : 0
 }
 
 resource "octopusdeploy_docker_container_registry" "feed_docker" {
@@ -424,7 +672,7 @@ resource "octopusdeploy_runbook_process" "runbook" {
         "Octopus.Action.RunOnServer"                                 = "true"
         "Octopus.Action.Script.ScriptSource"                         = "Inline"
         "Octopus.Action.Script.Syntax"                               = "Python"
-        "SerializeSpace.ThisInstance.Terraform.Backend"              = var.terraform_backend == "AWS S3" ? "s3" : "azurerm"
+        "SerializeSpace.ThisInstance.Terraform.Backend"              = lookup(local.terraform_backend_types, var.terraform_backend, "azurerm")
         "SerializeSpace.Exported.Space.IgnoreTargets"                = "False"
         "SerializeSpace.Exported.Space.Id"                           = "#{Octopus.Space.Id}"
         "Octopus.Action.Template.Version"                            = "1"
@@ -437,7 +685,7 @@ resource "octopusdeploy_runbook_process" "runbook" {
         "SerializeSpace.ThisInstance.Server.Url"                     = "#{Octopus.Source.Server}"
         "Octopus.Action.Template.Id"                                 = var.octopus_serialize_actiontemplateid
         "SerializeSpace.Exported.Space.IncludeStepTemplates"         = "True"
-        "SerializeSpace.Exported.Space.IgnoredAccounts"              = "Octoterra AWS Account.*,Octoterra Azure Account.*"
+        "SerializeSpace.Exported.Space.IgnoredAccounts"              = "Octoterra AWS Account.*,Octoterra Azure Account.*,Octoterra Google Cloud Account.*"
         "Octopus.Action.AutoRetry.MaximumCount"                      = "3"
         "SerializeSpace.Exported.Space.OctopusManagedTerraformVars" = "OctoterraWiz.Terraform.Vars"
       }
@@ -498,13 +746,13 @@ resource "octopusdeploy_runbook_process" "deploy_space_aws" {
         "OctoterraApply.AWS.Account"                            = "Terraform.AWS.Account"
         "OctoterraApply.AWS.S3.BucketKey"                       = "Project_#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\"}"
         "Octopus.Action.Terraform.Workspace"                    = "#{OctoterraApply.Terraform.Workspace.Name}"
-        "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole"             = var.terraform_state_aws_credentials == "Worker" ? "True" : "False"
         "Octopus.Action.AwsAccount.Variable"                    = "#{OctoterraApply.AWS.Account}"
         "Octopus.Action.Aws.Region"                             = "#{OctoterraApply.AWS.S3.BucketRegion}"
         "Octopus.Action.Template.Id"                            = var.octopus_deploys3_actiontemplateid
         "Octopus.Action.Template.Version"                       = "1"
         "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
-        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"bucket=#{OctoterraApply.AWS.S3.BucketName}\" -backend-config=\"region=#{OctoterraApply.AWS.S3.BucketRegion}\" -backend-config=\"key=#{OctoterraApply.AWS.S3.BucketKey}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"bucket=#{OctoterraApply.AWS.S3.BucketName}\" -backend-config=\"region=#{OctoterraApply.AWS.S3.BucketRegion}\" -backend-config=\"key=#{OctoterraApply.AWS.S3.BucketKey}\" ${local.s3_compatible_init_params} #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
         "Octopus.Action.Terraform.TemplateDirectory"            = "space_population"
         "Octopus.Action.Package.DownloadOnTentacle"             = "False"
         "Octopus.Action.Terraform.AllowPluginDownloads"         = "True"
@@ -520,7 +768,11 @@ resource "octopusdeploy_runbook_process" "deploy_space_aws" {
         "Octopus.Action.Script.ScriptSource"                    = "Package"
         "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "True"
         "Octopus.Action.Terraform.ManagedAccount"               = "AWS"
-        "Octopus.Action.Aws.AssumeRole"                         = "False"
+        "Octopus.Action.Aws.AssumeRole"                         = var.terraform_state_aws_role_arn != "" && var.terraform_state_aws_credentials != "WebIdentity" ? "True" : "False"
+        "Octopus.Action.Aws.AssumedRoleArn"                     = var.terraform_state_aws_role_arn
+        "Octopus.Action.Aws.AssumedRoleSession"                 = var.terraform_state_aws_role_session_name
+        "Octopus.Action.Aws.AssumeRoleExternalId"               = var.terraform_state_aws_role_external_id
+        "Octopus.Action.Aws.AssumeRoleSessionDurationSeconds"   = "3600"
         "OctoterraApply.Terraform.Package.Id" = jsonencode({
           "PackageId" = replace(var.octopus_space_name, "/[^A-Za-z0-9]/", "_")
           "FeedId" = data.octopusdeploy_feeds.built_in_feed.feeds[0].id
@@ -607,12 +859,12 @@ resource "octopusdeploy_runbook_process" "deploy_space_azure" {
         "Octopus.Action.Script.ScriptSource"                    = "Package"
         "Octopus.Action.Terraform.GoogleCloudAccount"           = "False"
         "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
-        "Octopus.Action.Terraform.AzureAccount"                 = "True"
+        "Octopus.Action.Terraform.AzureAccount"                 = contains(["ClientSecret", "OIDC"], var.terraform_state_azure_credentials) ? "True" : "False"
         "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
         "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "True"
         "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
         "Octopus.Action.Terraform.ManagedAccount"               = "None"
-        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"resource_group_name=#{OctoterraApply.Azure.Storage.ResourceGroup}\" -backend-config=\"storage_account_name=#{OctoterraApply.Azure.Storage.AccountName}\" -backend-config=\"container_name=#{OctoterraApply.Azure.Storage.Container}\" -backend-config=\"key=#{OctoterraApply.Azure.Storage.Key}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"resource_group_name=#{OctoterraApply.Azure.Storage.ResourceGroup}\" -backend-config=\"storage_account_name=#{OctoterraApply.Azure.Storage.AccountName}\" -backend-config=\"container_name=#{OctoterraApply.Azure.Storage.Container}\" -backend-config=\"key=#{OctoterraApply.Azure.Storage.Key}\" ${local.azure_auth_init_params} #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
         "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
       }
 
@@ -640,4 +892,229 @@ resource "octopusdeploy_runbook_process" "deploy_space_azure" {
     target_roles = []
   }
 
-}
\ No newline at end of file
+}
+
+resource "octopusdeploy_runbook_process" "deploy_space_gcs" {
+  runbook_id = octopusdeploy_runbook.deploy_space.id
+  count      = var.terraform_backend == "Google Cloud Storage" ? 1 : 0
+
+  step {
+    condition           = "Success"
+    name                = "Octopus - Populate Octoterra Space (Google Cloud Storage Backend)"
+    package_requirement = "LetOctopusDecide"
+    start_trigger       = "StartAfterPrevious"
+
+    action {
+      action_type                        = "Octopus.TerraformApply"
+      name                               = "Octopus - Populate Octoterra Space (Google Cloud Storage Backend)"
+      condition                          = "Success"
+      run_on_server                      = true
+      is_disabled                        = false
+      can_be_used_for_project_versioning = true
+      is_required                        = false
+      # Use the ubuntu worker pool if it is present, or use the default otherwise
+      worker_pool_id                     = length(data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools) == 0 ? "" : data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools[0].id
+      properties = {
+        "Octopus.Action.RunOnServer"                            = "true"
+        "Octopus.Action.Terraform.AllowPluginDownloads"         = "True"
+        "Octopus.Action.Package.DownloadOnTentacle"             = "False"
+        "Octopus.Action.Terraform.FileSubstitution"             = "**/project_variable_sensitive*.tf\n**/terraform.tfvars"
+        "Octopus.Action.Terraform.TemplateDirectory"            = "space_population"
+        "Octopus.Action.Script.ScriptSource"                    = "Package"
+        "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
+        "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
+        "Octopus.Action.Aws.AssumeRole"                         = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
+        "Octopus.Action.Terraform.AzureAccount"                 = "False"
+        "Octopus.Action.GoogleCloud.ImpersonateServiceAccount"  = "False"
+        "Octopus.Action.Terraform.ManagedAccount"               = "None"
+        "OctoterraApply.Octopus.ServerUrl"                      = "#{Octopus.Destination.Server}"
+        "OctoterraApply.Octopus.ApiKey"                         = "#{Octopus.Destination.ApiKey}"
+        "OctoterraApply.Octopus.SpaceID"                        = "#{Octopus.Destination.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalActionParams"       = "-var=octopus_server=#{OctoterraApply.Octopus.ServerUrl} -var=octopus_apikey=#{OctoterraApply.Octopus.ApiKey} -var=octopus_space_id=#{OctoterraApply.Octopus.SpaceID} #{if OctoterraApply.Terraform.AdditionalApplyParams}#{OctoterraApply.Terraform.AdditionalApplyParams}#{/if}"
+        "OctopusUseBundledTooling"                              = "False"
+        "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
+        "Octopus.Action.Terraform.Workspace"                    = "#{OctoterraApply.Terraform.Workspace.Name}"
+        "OctoterraApply.Terraform.Workspace.Name"               = "#{OctoterraApply.Octopus.SpaceID}"
+        "Octopus.Action.Terraform.GoogleCloudAccount"           = "True"
+        "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "False"
+        "Octopus.Action.GoogleCloudAccount.Variable"            = "#{OctoterraApply.Google.Account}"
+        "OctoterraApply.Google.Account"                         = "Terraform.Google.Account"
+        "OctoterraApply.Google.Storage.Bucket"                  = var.terraform_state_google_bucket
+        "OctoterraApply.Google.Storage.Prefix"                  = "Project_#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\"}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"bucket=#{OctoterraApply.Google.Storage.Bucket}\" -backend-config=\"prefix=#{OctoterraApply.Google.Storage.Prefix}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+      }
+
+      container {
+        feed_id = lower(var.use_container_images) == "true" ? octopusdeploy_docker_container_registry.feed_docker.id : ""
+        image   = lower(var.use_container_images) == "true" ? "ghcr.io/octopusdeploylabs/terraform-workertools" : ""
+      }
+
+      environments = []
+      excluded_environments = []
+      channels = []
+      tenant_tags = []
+
+      primary_package {
+        package_id           = replace(var.octopus_space_name, "/[^A-Za-z0-9]/", "_")
+        acquisition_location = "Server"
+        feed_id              = data.octopusdeploy_feeds.built_in_feed.feeds[0].id
+        properties           = { SelectionMode = "immediate" }
+      }
+
+      features = []
+    }
+
+    properties = {}
+    target_roles = []
+  }
+
+}
+
+resource "octopusdeploy_runbook_process" "deploy_space_http" {
+  runbook_id = octopusdeploy_runbook.deploy_space.id
+  count      = var.terraform_backend == "HTTP" ? 1 : 0
+
+  step {
+    condition           = "Success"
+    name                = "Octopus - Populate Octoterra Space (HTTP Backend)"
+    package_requirement = "LetOctopusDecide"
+    start_trigger       = "StartAfterPrevious"
+
+    action {
+      action_type                        = "Octopus.TerraformApply"
+      name                               = "Octopus - Populate Octoterra Space (HTTP Backend)"
+      condition                          = "Success"
+      run_on_server                      = true
+      is_disabled                        = false
+      can_be_used_for_project_versioning = true
+      is_required                        = false
+      # Use the ubuntu worker pool if it is present, or use the default otherwise
+      worker_pool_id                     = length(data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools) == 0 ? "" : data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools[0].id
+      properties = {
+        "Octopus.Action.RunOnServer"                            = "true"
+        "Octopus.Action.Terraform.AllowPluginDownloads"         = "True"
+        "Octopus.Action.Package.DownloadOnTentacle"             = "False"
+        "Octopus.Action.Terraform.FileSubstitution"             = "**/project_variable_sensitive*.tf\n**/terraform.tfvars"
+        "Octopus.Action.Terraform.TemplateDirectory"            = "space_population"
+        "Octopus.Action.Script.ScriptSource"                    = "Package"
+        "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
+        "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
+        "Octopus.Action.Aws.AssumeRole"                         = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
+        "Octopus.Action.Terraform.AzureAccount"                 = "False"
+        "Octopus.Action.GoogleCloud.ImpersonateServiceAccount"  = "False"
+        "Octopus.Action.Terraform.ManagedAccount"               = "None"
+        "OctoterraApply.Octopus.ServerUrl"                      = "#{Octopus.Destination.Server}"
+        "OctoterraApply.Octopus.ApiKey"                         = "#{Octopus.Destination.ApiKey}"
+        "OctoterraApply.Octopus.SpaceID"                        = "#{Octopus.Destination.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalActionParams"       = "-var=octopus_server=#{OctoterraApply.Octopus.ServerUrl} -var=octopus_apikey=#{OctoterraApply.Octopus.ApiKey} -var=octopus_space_id=#{OctoterraApply.Octopus.SpaceID} #{if OctoterraApply.Terraform.AdditionalApplyParams}#{OctoterraApply.Terraform.AdditionalApplyParams}#{/if}"
+        "OctopusUseBundledTooling"                              = "False"
+        "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
+        "Octopus.Action.Terraform.GoogleCloudAccount"           = "False"
+        "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "True"
+        "OctoterraApply.Http.Address"                           = var.terraform_state_http_address
+        # The http backend does not support workspaces, so the destination space is included in the state address
+        "OctoterraApply.Http.Key"                               = "Project_#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\"}_#{OctoterraApply.Octopus.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"address=#{OctoterraApply.Http.Address}/#{OctoterraApply.Http.Key}\" -backend-config=\"lock_address=#{OctoterraApply.Http.Address}/#{OctoterraApply.Http.Key}\" -backend-config=\"unlock_address=#{OctoterraApply.Http.Address}/#{OctoterraApply.Http.Key}\" #{if Terraform.Http.Username}-backend-config=\"username=#{Terraform.Http.Username}\" -backend-config=\"password=#{Terraform.Http.Password}\"#{/if} #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+      }
+
+      container {
+        feed_id = lower(var.use_container_images) == "true" ? octopusdeploy_docker_container_registry.feed_docker.id : ""
+        image   = lower(var.use_container_images) == "true" ? "ghcr.io/octopusdeploylabs/terraform-workertools" : ""
+      }
+
+      environments = []
+      excluded_environments = []
+      channels = []
+      tenant_tags = []
+
+      primary_package {
+        package_id           = replace(var.octopus_space_name, "/[^A-Za-z0-9]/", "_")
+        acquisition_location = "Server"
+        feed_id              = data.octopusdeploy_feeds.built_in_feed.feeds[0].id
+        properties           = { SelectionMode = "immediate" }
+      }
+
+      features = []
+    }
+
+    properties = {}
+    target_roles = []
+  }
+
+}
+
+resource "octopusdeploy_runbook_process" "deploy_space_pg" {
+  runbook_id = octopusdeploy_runbook.deploy_space.id
+  count      = var.terraform_backend == "PostgreSQL" ? 1 : 0
+
+  step {
+    condition           = "Success"
+    name                = "Octopus - Populate Octoterra Space (PostgreSQL Backend)"
+    package_requirement = "LetOctopusDecide"
+    start_trigger       = "StartAfterPrevious"
+
+    action {
+      action_type                        = "Octopus.TerraformApply"
+      name                               = "Octopus - Populate Octoterra Space (PostgreSQL Backend)"
+      condition                          = "Success"
+      run_on_server                      = true
+      is_disabled                        = false
+      can_be_used_for_project_versioning = true
+      is_required                        = false
+      # Use the ubuntu worker pool if it is present, or use the default otherwise
+      worker_pool_id                     = length(data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools) == 0 ? "" : data.octopusdeploy_worker_pools.ubuntu_worker_pool.worker_pools[0].id
+      properties = {
+        "Octopus.Action.RunOnServer"                            = "true"
+        "Octopus.Action.Terraform.AllowPluginDownloads"         = "True"
+        "Octopus.Action.Package.DownloadOnTentacle"             = "False"
+        "Octopus.Action.Terraform.FileSubstitution"             = "**/project_variable_sensitive*.tf\n**/terraform.tfvars"
+        "Octopus.Action.Terraform.TemplateDirectory"            = "space_population"
+        "Octopus.Action.Script.ScriptSource"                    = "Package"
+        "Octopus.Action.Terraform.RunAutomaticFileSubstitution" = "False"
+        "Octopus.Action.Terraform.PlanJsonOutput"               = "False"
+        "Octopus.Action.Aws.AssumeRole"                         = "False"
+        "Octopus.Action.AwsAccount.UseInstanceRole"             = "False"
+        "Octopus.Action.Terraform.AzureAccount"                 = "False"
+        "Octopus.Action.GoogleCloud.ImpersonateServiceAccount"  = "False"
+        "Octopus.Action.Terraform.ManagedAccount"               = "None"
+        "OctoterraApply.Octopus.ServerUrl"                      = "#{Octopus.Destination.Server}"
+        "OctoterraApply.Octopus.ApiKey"                         = "#{Octopus.Destination.ApiKey}"
+        "OctoterraApply.Octopus.SpaceID"                        = "#{Octopus.Destination.SpaceID}"
+        "Octopus.Action.Terraform.AdditionalActionParams"       = "-var=octopus_server=#{OctoterraApply.Octopus.ServerUrl} -var=octopus_apikey=#{OctoterraApply.Octopus.ApiKey} -var=octopus_space_id=#{OctoterraApply.Octopus.SpaceID} #{if OctoterraApply.Terraform.AdditionalApplyParams}#{OctoterraApply.Terraform.AdditionalApplyParams}#{/if}"
+        "OctopusUseBundledTooling"                              = "False"
+        "Octopus.Action.AutoRetry.MaximumCount"                 = "3"
+        "Octopus.Action.Terraform.Workspace"                    = "#{OctoterraApply.Terraform.Workspace.Name}"
+        "OctoterraApply.Terraform.Workspace.Name"               = "#{OctoterraApply.Octopus.SpaceID}"
+        "Octopus.Action.Terraform.GoogleCloudAccount"           = "False"
+        "Octopus.Action.GoogleCloud.UseVMServiceAccount"        = "True"
+        "OctoterraApply.Postgres.Schema"                        = "project_#{Octopus.Project.Name | Replace \"[^A-Za-z0-9]\" \"_\" | ToLower}"
+        "Octopus.Action.Terraform.AdditionalInitParams"         = "-backend-config=\"conn_str=#{Terraform.Postgres.ConnectionString}\" -backend-config=\"schema_name=#{OctoterraApply.Postgres.Schema}\" #{if OctoterraApply.Terraform.AdditionalInitParams}#{OctoterraApply.Terraform.AdditionalInitParams}#{/if}"
+      }
+
+      container {
+        feed_id = lower(var.use_container_images) == "true" ? octopusdeploy_docker_container_registry.feed_docker.id : ""
+        image   = lower(var.use_container_images) == "true" ? "ghcr.io/octopusdeploylabs/terraform-workertools" : ""
+      }
+
+      environments = []
+      excluded_environments = []
+      channels = []
+      tenant_tags = []
+
+      primary_package {
+        package_id           = replace(var.octopus_space_name, "/[^A-Za-z0-9]/", "_")
+        acquisition_location = "Server"
+        feed_id              = data.octopusdeploy_feeds.built_in_feed.feeds[0].id
+        properties           = { SelectionMode = "immediate" }
+      }
+
+      features = []
+    }
+
+    properties = {}
+    target_roles = []
+  }
+
+}
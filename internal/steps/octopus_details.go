@@ -122,34 +122,71 @@ func (s OctopusDetails) GetContainer(parent fyne.Window) *fyne.Container {
 
 func (s OctopusDetails) getState() state.State {
 	return state.State{
-		BackendType:               s.State.BackendType,
-		Server:                    strings.TrimSpace(s.server.Text),
-		ServerExternal:            "",
-		ApiKey:                    strings.TrimSpace(s.apiKey.Text),
-		Space:                     strings.TrimSpace(s.spaceId.Text),
-		DestinationServer:         s.State.DestinationServer,
-		DestinationServerExternal: "",
-		DestinationApiKey:         s.State.DestinationApiKey,
-		DestinationSpace:          s.State.DestinationSpace,
-		AwsAccessKey:              s.State.AwsAccessKey,
-		AwsSecretKey:              s.State.AwsSecretKey,
-		AwsS3Bucket:               s.State.AwsS3Bucket,
-		AwsS3BucketRegion:         s.State.AwsS3BucketRegion,
-		PromptForDelete:           s.State.PromptForDelete,
-		UseContainerImages:        s.State.UseContainerImages,
-		AzureResourceGroupName:    s.State.AzureResourceGroupName,
-		AzureStorageAccountName:   s.State.AzureStorageAccountName,
-		AzureContainerName:        s.State.AzureContainerName,
-		AzureSubscriptionId:       s.State.AzureSubscriptionId,
-		AzureTenantId:             s.State.AzureTenantId,
-		AzureApplicationId:        s.State.AzureApplicationId,
-		AzurePassword:             s.State.AzurePassword,
-		DatabaseServer:            s.State.DatabaseServer,
-		DatabaseUser:              s.State.DatabaseUser,
-		DatabasePass:              s.State.DatabasePass,
-		DatabasePort:              s.State.DatabasePort,
-		DatabaseName:              s.State.DatabaseName,
-		DatabaseMasterKey:         s.State.DatabaseMasterKey,
-		EnableProjectRenaming:     s.State.EnableProjectRenaming,
+		BackendType:                    s.State.BackendType,
+		Server:                         strings.TrimSpace(s.server.Text),
+		ServerExternal:                 "",
+		ApiKey:                         strings.TrimSpace(s.apiKey.Text),
+		Space:                          strings.TrimSpace(s.spaceId.Text),
+		DestinationServer:              s.State.DestinationServer,
+		DestinationServerExternal:      "",
+		DestinationApiKey:              s.State.DestinationApiKey,
+		DestinationSpace:               s.State.DestinationSpace,
+		AwsAccessKey:                   s.State.AwsAccessKey,
+		AwsSecretKey:                   s.State.AwsSecretKey,
+		AwsS3Bucket:                    s.State.AwsS3Bucket,
+		AwsS3BucketRegion:              s.State.AwsS3BucketRegion,
+		PromptForDelete:                s.State.PromptForDelete,
+		UseContainerImages:             s.State.UseContainerImages,
+		AzureResourceGroupName:         s.State.AzureResourceGroupName,
+		AzureStorageAccountName:        s.State.AzureStorageAccountName,
+		AzureContainerName:             s.State.AzureContainerName,
+		AzureSubscriptionId:            s.State.AzureSubscriptionId,
+		AzureTenantId:                  s.State.AzureTenantId,
+		AzureApplicationId:             s.State.AzureApplicationId,
+		AzurePassword:                  s.State.AzurePassword,
+		DatabaseServer:                 s.State.DatabaseServer,
+		DatabaseUser:                   s.State.DatabaseUser,
+		DatabasePass:                   s.State.DatabasePass,
+		DatabasePort:                   s.State.DatabasePort,
+		DatabaseName:                   s.State.DatabaseName,
+		DatabaseMasterKey:              s.State.DatabaseMasterKey,
+		DatabaseAuthType:               s.State.DatabaseAuthType,
+		DatabaseInstance:               s.State.DatabaseInstance,
+		DatabaseEncrypt:                s.State.DatabaseEncrypt,
+		DatabaseTrustServerCertificate: s.State.DatabaseTrustServerCertificate,
+		DatabaseAzureTenantId:          s.State.DatabaseAzureTenantId,
+		DatabaseAzureClientId:          s.State.DatabaseAzureClientId,
+		EnableProjectRenaming:          s.State.EnableProjectRenaming,
+		IncludeProjectGroups:           s.State.IncludeProjectGroups,
+		ExcludeProjectGroups:           s.State.ExcludeProjectGroups,
+		IncludeProjectNamePattern:      s.State.IncludeProjectNamePattern,
+		ExcludeProjectNamePattern:      s.State.ExcludeProjectNamePattern,
+		IncludeProjectIds:              s.State.IncludeProjectIds,
+		ExcludeProjectIds:              s.State.ExcludeProjectIds,
+		MaxConcurrentRunbooks:          s.State.MaxConcurrentRunbooks,
+		TaskTimeoutMinutes:             s.State.TaskTimeoutMinutes,
+		GoogleCloudBucket:              s.State.GoogleCloudBucket,
+		GoogleCloudCredentials:         s.State.GoogleCloudCredentials,
+		HttpAddress:                    s.State.HttpAddress,
+		HttpUsername:                   s.State.HttpUsername,
+		HttpPassword:                   s.State.HttpPassword,
+		PostgresConnectionString:       s.State.PostgresConnectionString,
+		AwsS3Endpoint:                  s.State.AwsS3Endpoint,
+		AwsS3UsePathStyle:              s.State.AwsS3UsePathStyle,
+		AwsCaBundle:                    s.State.AwsCaBundle,
+		AwsProfile:                     s.State.AwsProfile,
+		AwsRoleArn:                     s.State.AwsRoleArn,
+		AwsRoleSessionName:             s.State.AwsRoleSessionName,
+		AwsRoleExternalId:              s.State.AwsRoleExternalId,
+		AwsWebIdentityTokenFile:        s.State.AwsWebIdentityTokenFile,
+		AzureAuthType:                  s.State.AzureAuthType,
+		AzureCertificatePath:           s.State.AzureCertificatePath,
+		AzureCertificatePassword:       s.State.AzureCertificatePassword,
+		AzureFederatedTokenFile:        s.State.AzureFederatedTokenFile,
+		AzureLocation:                  s.State.AzureLocation,
+		CreateBackendStorage:           s.State.CreateBackendStorage,
+		SecretsFile:                    s.State.SecretsFile,
+		SecretsPassphrase:              s.State.SecretsPassphrase,
+		ContinueOnExtractionError:      s.State.ContinueOnExtractionError,
 	}
 }
@@ -0,0 +1,215 @@
+package steps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mcasperson/OctoterraWizard/internal/data"
+	"github.com/mcasperson/OctoterraWizard/internal/logutil"
+	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+)
+
+// ChangeReport lists the changes the space and project export steps would make to the source space
+type ChangeReport struct {
+	// PreCleanChanges lists the existing resources that are deleted or renamed before the Terraform modules are applied
+	PreCleanChanges []string
+	// TerraformPlans captures the output of terraform plan, with the name of the module as the key
+	TerraformPlans []data.NameValuePair
+}
+
+// Merge combines two reports
+func (r ChangeReport) Merge(other ChangeReport) ChangeReport {
+	return ChangeReport{
+		PreCleanChanges: append(append([]string{}, r.PreCleanChanges...), other.PreCleanChanges...),
+		TerraformPlans:  append(append([]data.NameValuePair{}, r.TerraformPlans...), other.TerraformPlans...),
+	}
+}
+
+func (r ChangeReport) String() string {
+	report := strings.Builder{}
+
+	report.WriteString("Existing resources that will be deleted or renamed:\n")
+	if len(r.PreCleanChanges) == 0 {
+		report.WriteString("* None\n")
+	}
+
+	for _, change := range r.PreCleanChanges {
+		report.WriteString("* " + change + "\n")
+	}
+
+	for _, plan := range r.TerraformPlans {
+		report.WriteString("\nTerraform plan for " + plan.Name + ":\n")
+		report.WriteString(plan.Value + "\n")
+	}
+
+	return report.String()
+}
+
+// planModule saves the module to a temporary directory and returns the output of terraform plan
+func planModule(module string, vars []string) (string, error) {
+	dir, err := os.MkdirTemp("", "octoterra")
+	if err != nil {
+		return "", errors.Join(errors.New("failed to create a temporary directory"), err)
+	}
+
+	defer func(path string) {
+		if err := os.RemoveAll(path); err != nil {
+			// ignore this and move on
+			fmt.Println(err.Error())
+		}
+	}(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "terraform.tf"), []byte(module), 0644); err != nil {
+		return "", errors.Join(errors.New("failed to write the Terraform file"), err)
+	}
+
+	initCmd := exec.Command("terraform", "init", "-no-color")
+	initCmd.Dir = dir
+
+	var initOutput bytes.Buffer
+	initCmd.Stdout = &initOutput
+	initCmd.Stderr = &initOutput
+
+	if err := initCmd.Run(); err != nil {
+		return "", errors.Join(errors.New("terraform init failed"), errors.New(initOutput.String()))
+	}
+
+	planCmd := exec.Command("terraform", append([]string{"plan", "-no-color", "-input=false"}, vars...)...)
+	planCmd.Dir = dir
+
+	var planOutput bytes.Buffer
+	planCmd.Stdout = &planOutput
+	planCmd.Stderr = &planOutput
+
+	if err := planCmd.Run(); err != nil {
+		return "", errors.Join(errors.New("terraform plan failed"), errors.New(planOutput.String()))
+	}
+
+	return planOutput.String(), nil
+}
+
+// PlanChangesStep displays the changes made by the space and project export steps before they are made
+type PlanChangesStep struct {
+	BaseStep
+	Wizard wizard.Wizard
+	logs   *widget.Entry
+}
+
+func (s PlanChangesStep) GetContainer(parent fyne.Window) *fyne.Container {
+
+	bottom, previous, next := s.BuildNavigation(func() {
+		s.Wizard.ShowWizardStep(ProjectSelectionStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.State}})
+	}, func() {
+		s.Wizard.ShowWizardStep(SpaceExportStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.State}})
+	})
+
+	heading := widget.NewLabel("Preview Changes")
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+
+	intro := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		The next steps delete or rename any existing Octoterra resources and then apply Terraform modules to the source space.
+		Click the "Preview Changes" button to list these changes without making them.
+		This runs "terraform plan" for the space and for every project, so it can take some time.
+		This step is optional. Click "Next" to skip it.
+	`))
+	result := widget.NewLabel("")
+	infinite := widget.NewProgressBarInfinite()
+	infinite.Start()
+	infinite.Hide()
+	s.logs = widget.NewEntry()
+	s.logs.MultiLine = true
+	s.logs.SetMinRowsVisible(20)
+	s.logs.Hide()
+
+	var preview *widget.Button
+	preview = widget.NewButton("Preview Changes", func() {
+		preview.Disable()
+		previous.Disable()
+		next.Disable()
+		infinite.Show()
+		s.logs.Hide()
+		result.SetText("🔵 Generating the change report.")
+
+		go func() {
+			report, err := s.Execute(func(message string) {
+				fyne.Do(func() {
+					result.SetText(message)
+				})
+			})
+
+			fyne.Do(func() {
+				preview.Enable()
+				previous.Enable()
+				next.Enable()
+				infinite.Hide()
+				s.logs.Show()
+
+				if err != nil {
+					if err := logutil.WriteTextToFile("plan_changes_error.txt", err.Error()); err != nil {
+						fmt.Println("Failed to write error to file")
+					}
+
+					result.SetText("🔴 Failed to generate the change report")
+					s.logs.SetText(err.Error())
+					return
+				}
+
+				result.SetText("🟢 Generated the change report. No changes have been made.")
+				s.logs.SetText(report.String())
+			})
+		}()
+	})
+
+	middle := container.New(layout.NewVBoxLayout(), heading, intro, preview, infinite, result)
+
+	content := container.NewBorder(middle, bottom, nil, nil, s.logs)
+
+	return content
+}
+
+// Execute builds the combined change report for the space and project export steps
+func (s PlanChangesStep) Execute(statusCallback func(message string)) (ChangeReport, error) {
+	statusCallback("🔵 Planning the changes to the space.")
+	spaceReport, err := SpaceExportStep{BaseStep: BaseStep{State: s.State}}.Plan()
+
+	if err != nil {
+		return ChangeReport{}, err
+	}
+
+	projectReport, err := ProjectExportStep{BaseStep: BaseStep{State: s.State}}.Plan(statusCallback)
+
+	if err != nil {
+		return ChangeReport{}, err
+	}
+
+	report := spaceReport.Merge(projectReport)
+
+	if s.State.EnableVariableSpreading {
+		statusCallback("🔵 Previewing the spreading of sensitive variables.")
+		changes, err := SpreadVariablesStep{BaseStep: BaseStep{State: s.State}}.Preview()
+
+		if err != nil {
+			return ChangeReport{}, err
+		}
+
+		for _, change := range changes {
+			report.PreCleanChanges = append(report.PreCleanChanges, change.String())
+		}
+	}
+
+	return report, nil
+}
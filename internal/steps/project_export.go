@@ -19,12 +19,15 @@ import (
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/runbooks"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
+	"github.com/mcasperson/OctoterraWizard/internal/data"
 	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
 	"github.com/mcasperson/OctoterraWizard/internal/logutil"
 	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
 	"github.com/mcasperson/OctoterraWizard/internal/query"
 	"github.com/mcasperson/OctoterraWizard/internal/sensitivevariables"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
 )
 
@@ -162,7 +165,7 @@ func (s ProjectExportStep) Execute(prompt func(string, string, func(bool)), stat
 		return
 	}
 
-	allProjects, err := infrastructure.GetProjects(myclient)
+	allProjects, err := infrastructure.GetSelectedProjects(myclient, s.State)
 
 	if err != nil {
 		errCallback("🔴 Failed to get all the projects", err)
@@ -325,31 +328,8 @@ func (s ProjectExportStep) Execute(prompt func(string, string, func(bool)), stat
 			return
 		}
 
-		applyCmd := exec.Command("terraform",
-			"apply",
-			"-auto-approve",
-			"-no-color",
-			"-var=octopus_serialize_actiontemplateid="+serializeProjectTemplate,
-			"-var=octopus_deploys3_actiontemplateid="+deploySpaceTemplateS3,
-			"-var=octopus_deployazure_actiontemplateid="+deploySpaceTemplateAzureStorage,
-			"-var=octopus_server_external="+s.State.GetExternalServer(),
-			"-var=terraform_backend="+s.State.BackendType,
-			"-var=use_container_images="+fmt.Sprint(s.State.UseContainerImages),
-			"-var=default_secret_variables=false",
-			"-var=customise_destination_project_name="+fmt.Sprint(s.State.EnableProjectRenaming),
-			"-var=octopus_server="+s.State.Server,
-			"-var=octopus_apikey="+s.State.ApiKey,
-			"-var=octopus_space_id="+s.State.Space,
-			"-var=octopus_project_id="+project.ID,
-			"-var=terraform_state_bucket="+s.State.AwsS3Bucket,
-			"-var=terraform_state_bucket_region="+s.State.AwsS3BucketRegion,
-			"-var=terraform_state_azure_resource_group="+s.State.AzureResourceGroupName,
-			"-var=terraform_state_azure_storage_account="+s.State.AzureStorageAccountName,
-			"-var=terraform_state_azure_storage_container="+s.State.AzureContainerName,
-			"-var=octopus_destination_server="+s.State.DestinationServer,
-			"-var=octopus_destination_apikey="+s.State.DestinationApiKey,
-			"-var=octopus_destination_space_id="+s.State.DestinationSpace,
-			"-var=octopus_project_name="+project.Name)
+		applyArgs := append([]string{"apply", "-auto-approve", "-no-color"}, s.terraformVars(serializeProjectTemplate, deploySpaceTemplateS3, deploySpaceTemplateAzureStorage, project)...)
+		applyCmd := exec.Command("terraform", applyArgs...)
 		applyCmd.Dir = dir
 
 		var stdout, stderr bytes.Buffer
@@ -388,9 +368,142 @@ func (s ProjectExportStep) Execute(prompt func(string, string, func(bool)), stat
 
 	}
 
+	completePhase(s.State, checkpoint.PhaseProjectExport)
 	successCallback()
 }
 
+// Plan reports the changes made by Execute without modifying the projects
+func (s ProjectExportStep) Plan(statusCallback func(message string)) (ChangeReport, error) {
+	myclient, err := octoclient.CreateClient(s.State)
+
+	if err != nil {
+		return ChangeReport{}, errors.Join(errors.New("failed to create the client"), err)
+	}
+
+	allProjects, err := infrastructure.GetSelectedProjects(myclient, s.State)
+
+	if err != nil {
+		return ChangeReport{}, errors.Join(errors.New("failed to get all the projects"), err)
+	}
+
+	report := ChangeReport{}
+
+	for _, project := range allProjects {
+		if project.Name == spaceManagementProject {
+			continue
+		}
+
+		for _, runbookName := range []string{"__ 1. Serialize Project", "__ 2. Deploy Project"} {
+			runbookExists, _, err := s.runbookExists(myclient, project.ID, runbookName)
+
+			if err != nil {
+				return ChangeReport{}, errors.Join(errors.New("failed to find the runbook "+runbookName+" in project "+project.Name), err)
+			}
+
+			if runbookExists {
+				report.PreCleanChanges = append(report.PreCleanChanges, "Delete the runbook \""+runbookName+"\" in project "+project.Name)
+			}
+		}
+
+		variableExists, _, err := s.projectVariableExists(myclient, project.ID, "OctoterraWiz.Destination.ProjectName")
+
+		if err != nil {
+			return ChangeReport{}, errors.Join(errors.New("failed to find the variable OctoterraWiz.Destination.ProjectName in project "+project.Name), err)
+		}
+
+		if variableExists {
+			report.PreCleanChanges = append(report.PreCleanChanges, "Delete the variable \"OctoterraWiz.Destination.ProjectName\" in project "+project.Name)
+		}
+	}
+
+	varsLvsExists, _, err := query.LibraryVariableSetExists(myclient, sensitivevariables.SecretsLibraryVariableSetName)
+
+	if err != nil {
+		return ChangeReport{}, errors.Join(errors.New("failed to get the library variable set "+sensitivevariables.SecretsLibraryVariableSetName), err)
+	}
+
+	libraryVariableSets := []string{"Octoterra"}
+	if varsLvsExists {
+		libraryVariableSets = append(libraryVariableSets, sensitivevariables.SecretsLibraryVariableSetName)
+	}
+
+	for _, lvsName := range libraryVariableSets {
+		report.PreCleanChanges = append(report.PreCleanChanges, "Link the library variable set "+lvsName+" to all projects")
+	}
+
+	templateIds := []string{}
+	for _, templateName := range []string{
+		"Octopus - Serialize Project to Terraform",
+		"Octopus - Populate Octoterra Space (S3 Backend)",
+		"Octopus - Populate Octoterra Space (Azure Backend)"} {
+		templateId, err, _ := query.GetStepTemplateId(myclient, s.State, templateName)
+
+		if errors.Is(err, query.ErrStepTemplateNotFound) {
+			// The template is installed by an earlier step, so plan against a placeholder ID
+			templateId = "ActionTemplates-Placeholder"
+		} else if err != nil {
+			return ChangeReport{}, errors.Join(errors.New("failed to get the step template "+templateName), err)
+		}
+
+		templateIds = append(templateIds, templateId)
+	}
+
+	for index, project := range allProjects {
+		statusCallback("🔵 Planning the changes to project " + project.Name + " (" + fmt.Sprint(index+1) + " / " + fmt.Sprint(len(allProjects)) + ")")
+
+		plan, err := planModule(runbookModule, s.terraformVars(templateIds[0], templateIds[1], templateIds[2], project))
+
+		if err != nil {
+			return ChangeReport{}, errors.Join(errors.New("failed to plan the project "+project.Name), err)
+		}
+
+		report.TerraformPlans = append(report.TerraformPlans, data.NameValuePair{Name: project.Name, Value: plan})
+	}
+
+	return report, nil
+}
+
+// terraformVars returns the variables passed to the project management module
+func (s ProjectExportStep) terraformVars(serializeProjectTemplate string, deploySpaceTemplateS3 string, deploySpaceTemplateAzureStorage string, project *projects.Project) []string {
+	return []string{
+		"-var=octopus_serialize_actiontemplateid=" + serializeProjectTemplate,
+		"-var=octopus_deploys3_actiontemplateid=" + deploySpaceTemplateS3,
+		"-var=octopus_deployazure_actiontemplateid=" + deploySpaceTemplateAzureStorage,
+		"-var=octopus_server_external=" + s.State.GetExternalServer(),
+		"-var=terraform_backend=" + s.State.BackendType,
+		"-var=use_container_images=" + fmt.Sprint(s.State.UseContainerImages),
+		"-var=default_secret_variables=false",
+		"-var=customise_destination_project_name=" + fmt.Sprint(s.State.EnableProjectRenaming),
+		"-var=octopus_server=" + s.State.Server,
+		"-var=octopus_apikey=" + s.State.ApiKey,
+		"-var=octopus_space_id=" + s.State.Space,
+		"-var=octopus_project_id=" + project.ID,
+		"-var=terraform_state_bucket=" + s.State.AwsS3Bucket,
+		"-var=terraform_state_bucket_region=" + s.State.AwsS3BucketRegion,
+		"-var=terraform_state_aws_s3_endpoint=" + s.State.AwsS3Endpoint,
+		"-var=terraform_state_aws_s3_use_path_style=" + fmt.Sprint(s.State.AwsS3UsePathStyle),
+		"-var=terraform_state_aws_ca_bundle=" + s.State.AwsCaBundle,
+		"-var=terraform_state_aws_credentials=" + awsCredentialType(s.State),
+		"-var=terraform_state_aws_role_arn=" + s.State.AwsRoleArn,
+		"-var=terraform_state_aws_role_session_name=" + awsRoleSessionName(s.State),
+		"-var=terraform_state_aws_role_external_id=" + s.State.AwsRoleExternalId,
+		"-var=terraform_state_azure_resource_group=" + s.State.AzureResourceGroupName,
+		"-var=terraform_state_azure_storage_account=" + s.State.AzureStorageAccountName,
+		"-var=terraform_state_azure_storage_container=" + s.State.AzureContainerName,
+		"-var=terraform_state_azure_credentials=" + validators.AzureAuthType(s.State),
+		"-var=terraform_state_azure_application_id=" + s.State.AzureApplicationId,
+		"-var=terraform_state_azure_subscription_id=" + s.State.AzureSubscriptionId,
+		"-var=terraform_state_azure_tenant_id=" + s.State.AzureTenantId,
+		"-var=terraform_state_azure_certificate_path=" + s.State.AzureCertificatePath,
+		"-var=terraform_state_google_bucket=" + s.State.GoogleCloudBucket,
+		"-var=terraform_state_http_address=" + s.State.HttpAddress,
+		"-var=octopus_destination_server=" + s.State.DestinationServer,
+		"-var=octopus_destination_apikey=" + s.State.DestinationApiKey,
+		"-var=octopus_destination_space_id=" + s.State.DestinationSpace,
+		"-var=octopus_project_name=" + project.Name,
+	}
+}
+
 func (s ProjectExportStep) deleteRunbook(myclient *client.Client, runbook *runbooks.Runbook) error {
 	fmt.Println("Attempting to delete runbook " + runbook.ID)
 	if err := myclient.Runbooks.DeleteByID(runbook.ID); err != nil {
@@ -0,0 +1,166 @@
+package steps
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
+	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
+	"github.com/mcasperson/OctoterraWizard/internal/logutil"
+	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
+	"github.com/mcasperson/OctoterraWizard/internal/projectfilter"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+	"github.com/samber/lo"
+)
+
+type ProjectSelectionStep struct {
+	BaseStep
+	Wizard        wizard.Wizard
+	includeGroups *widget.Entry
+	excludeGroups *widget.Entry
+	includeNames  *widget.Entry
+	excludeNames  *widget.Entry
+	includeIds    *widget.Entry
+	excludeIds    *widget.Entry
+	result        *widget.Label
+	logs          *widget.Entry
+}
+
+func (s ProjectSelectionStep) GetContainer(parent fyne.Window) *fyne.Container {
+
+	bottom, previous, next := s.BuildNavigation(func() {
+		s.Wizard.ShowWizardStep(PromptRemovalStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.getState()}})
+	}, func() {
+		if _, err := projectfilter.New(infrastructure.ProjectRules(s.getState())); err != nil {
+			s.result.SetText("🔴 " + err.Error())
+			return
+		}
+
+		s.Wizard.ShowWizardStep(PlanChangesStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.getState()}})
+	})
+
+	heading := widget.NewLabel("Select Projects")
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+
+	intro := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		By default, all projects are migrated. You can limit the migration to a subset of projects by defining the rules below.
+		A project is migrated if it matches any include rule (or no include rules are defined), and does not match any exclude rule.
+		Project groups and project IDs are entered as comma separated lists. Project groups can be matched by name or ID.
+		Project names are matched with regular expressions.
+	`))
+
+	s.includeGroups = widget.NewEntry()
+	s.includeGroups.SetPlaceHolder("Websites, ProjectGroups-1")
+	s.includeGroups.SetText(projectfilter.JoinList(s.State.IncludeProjectGroups))
+
+	s.excludeGroups = widget.NewEntry()
+	s.excludeGroups.SetText(projectfilter.JoinList(s.State.ExcludeProjectGroups))
+
+	s.includeNames = widget.NewEntry()
+	s.includeNames.SetPlaceHolder("^Orders")
+	s.includeNames.SetText(s.State.IncludeProjectNamePattern)
+
+	s.excludeNames = widget.NewEntry()
+	s.excludeNames.SetText(s.State.ExcludeProjectNamePattern)
+
+	s.includeIds = widget.NewEntry()
+	s.includeIds.SetPlaceHolder("Projects-1, Projects-2")
+	s.includeIds.SetText(projectfilter.JoinList(s.State.IncludeProjectIds))
+
+	s.excludeIds = widget.NewEntry()
+	s.excludeIds.SetText(projectfilter.JoinList(s.State.ExcludeProjectIds))
+
+	formLayout := container.New(layout.NewFormLayout(),
+		widget.NewLabel("Include Project Groups"), s.includeGroups,
+		widget.NewLabel("Exclude Project Groups"), s.excludeGroups,
+		widget.NewLabel("Include Project Names"), s.includeNames,
+		widget.NewLabel("Exclude Project Names"), s.excludeNames,
+		widget.NewLabel("Include Project IDs"), s.includeIds,
+		widget.NewLabel("Exclude Project IDs"), s.excludeIds)
+
+	s.result = widget.NewLabel("")
+	infinite := widget.NewProgressBarInfinite()
+	infinite.Start()
+	infinite.Hide()
+	s.logs = widget.NewEntry()
+	s.logs.MultiLine = true
+	s.logs.SetMinRowsVisible(10)
+	s.logs.Hide()
+
+	var preview *widget.Button
+	preview = widget.NewButton("Preview Selected Projects", func() {
+		preview.Disable()
+		previous.Disable()
+		next.Disable()
+		infinite.Show()
+		s.logs.Hide()
+		s.result.SetText("🔵 Finding the selected projects.")
+
+		selectionState := s.getState()
+
+		go func() {
+			selectedProjects, err := s.getSelectedProjects(selectionState)
+
+			fyne.Do(func() {
+				preview.Enable()
+				previous.Enable()
+				next.Enable()
+				infinite.Hide()
+
+				if err != nil {
+					if err := logutil.WriteTextToFile("project_selection_error.txt", err.Error()); err != nil {
+						fmt.Println("Failed to write error to file")
+					}
+
+					s.result.SetText("🔴 Failed to find the selected projects")
+					s.logs.SetText(err.Error())
+					s.logs.Show()
+					return
+				}
+
+				s.result.SetText("🟢 " + fmt.Sprint(len(selectedProjects)) + " projects are selected for migration")
+				s.logs.SetText(strings.Join(lo.Map(selectedProjects, func(item *projects.Project, index int) string {
+					return item.Name + " (" + item.ID + ")"
+				}), "\n"))
+				s.logs.Show()
+			})
+		}()
+	})
+
+	middle := container.New(layout.NewVBoxLayout(), heading, intro, formLayout, preview, infinite, s.result)
+
+	content := container.NewBorder(middle, bottom, nil, nil, s.logs)
+
+	return content
+}
+
+func (s ProjectSelectionStep) getSelectedProjects(selectionState state.State) ([]*projects.Project, error) {
+	myclient, err := octoclient.CreateClient(selectionState)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return infrastructure.GetSelectedProjects(myclient, selectionState)
+}
+
+func (s ProjectSelectionStep) getState() state.State {
+	newState := s.State
+	newState.IncludeProjectGroups = projectfilter.SplitList(s.includeGroups.Text)
+	newState.ExcludeProjectGroups = projectfilter.SplitList(s.excludeGroups.Text)
+	newState.IncludeProjectNamePattern = strings.TrimSpace(s.includeNames.Text)
+	newState.ExcludeProjectNamePattern = strings.TrimSpace(s.excludeNames.Text)
+	newState.IncludeProjectIds = projectfilter.SplitList(s.includeIds.Text)
+	newState.ExcludeProjectIds = projectfilter.SplitList(s.excludeIds.Text)
+	return newState
+}
@@ -21,7 +21,7 @@ func (s PromptRemovalStep) GetContainer(parent fyne.Window) *fyne.Container {
 			Wizard:   s.Wizard,
 			BaseStep: BaseStep{State: s.State}})
 	}, func() {
-		s.Wizard.ShowWizardStep(SpaceExportStep{
+		s.Wizard.ShowWizardStep(ProjectSelectionStep{
 			Wizard:   s.Wizard,
 			BaseStep: BaseStep{State: s.State}})
 	})
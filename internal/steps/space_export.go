@@ -18,10 +18,13 @@ import (
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projectgroups"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/variables"
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
+	"github.com/mcasperson/OctoterraWizard/internal/data"
 	"github.com/mcasperson/OctoterraWizard/internal/logutil"
 	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
 	"github.com/mcasperson/OctoterraWizard/internal/query"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
 	"github.com/samber/lo"
 	"io"
@@ -60,7 +63,7 @@ type SpaceExportStep struct {
 func (s SpaceExportStep) GetContainer(parent fyne.Window) *fyne.Container {
 
 	bottom, thisPrevious, thisNext := s.BuildNavigation(func() {
-		s.Wizard.ShowWizardStep(PromptRemovalStep{
+		s.Wizard.ShowWizardStep(PlanChangesStep{
 			Wizard:   s.Wizard,
 			BaseStep: BaseStep{State: s.State}})
 	}, func() {
@@ -366,6 +369,32 @@ func (s SpaceExportStep) Execute(prompt func(string, string, func(bool)), handle
 		}
 	}
 
+	googleAccountExists, googleAccount, err := s.accountExists(myclient, "Octoterra Google Cloud Account")
+
+	if googleAccountExists && !attemptedAccountDelete {
+		deleteAccountFunc := func(b bool) {
+			if b {
+				if err := s.deleteAccount(myclient, googleAccount); err != nil {
+					if err := s.renameAccount(myclient, googleAccount); err != nil {
+						fmt.Println(err.Error())
+					}
+				}
+
+				if s.State.PromptForDelete {
+					s.Execute(prompt, handleError, handleSuccess, attemptedLvsDelete, true)
+				}
+			}
+		}
+
+		if s.State.PromptForDelete {
+			prompt("Account Exists", "The account Octoterra Google Cloud Account already exists. Do you want to delete it? It is usually safe to delete this resource.", deleteAccountFunc)
+			// We can't go further until the account is deleted
+			return
+		} else {
+			deleteAccountFunc(true)
+		}
+	}
+
 	// Find the step template ID
 	serializeSpaceTemplate, err, message := query.GetStepTemplateId(myclient, s.State, "Octopus - Serialize Space to Terraform")
 
@@ -422,36 +451,8 @@ func (s SpaceExportStep) Execute(prompt func(string, string, func(bool)), handle
 		handleError("🔴 Terraform init failed.", errors.New(err.Error()+"\n"+initStdout.String()+initCmd.String()))
 	}
 
-	applyCmd := exec.Command("terraform",
-		"apply",
-		"-auto-approve",
-		"-no-color",
-		"-var=octopus_serialize_actiontemplateid="+serializeSpaceTemplate,
-		"-var=octopus_deploys3_actiontemplateid="+deploySpaceTemplateS3,
-		"-var=octopus_deployazure_actiontemplateid="+deploySpaceTemplateAzureStorage,
-		"-var=terraform_backend="+s.State.BackendType,
-		"-var=default_secret_variables=false",
-		"-var=use_container_images="+fmt.Sprint(s.State.UseContainerImages),
-		"-var=octopus_server_external="+s.State.GetExternalServer(),
-		"-var=octopus_server="+s.State.Server,
-		"-var=octopus_apikey="+s.State.ApiKey,
-		"-var=octopus_space_id="+s.State.Space,
-		"-var=octopus_space_name="+spaceName,
-		"-var=terraform_state_bucket="+s.State.AwsS3Bucket,
-		"-var=terraform_state_bucket_region="+s.State.AwsS3BucketRegion,
-		"-var=terraform_state_aws_accesskey="+s.State.AwsAccessKey,
-		"-var=terraform_state_aws_secretkey="+s.State.AwsSecretKey,
-		"-var=terraform_state_azure_resource_group="+s.State.AzureResourceGroupName,
-		"-var=terraform_state_azure_storage_account="+s.State.AzureStorageAccountName,
-		"-var=terraform_state_azure_storage_container="+s.State.AzureContainerName,
-		"-var=terraform_state_azure_application_id="+s.State.AzureApplicationId,
-		"-var=terraform_state_azure_subscription_id="+s.State.AzureSubscriptionId,
-		"-var=terraform_state_azure_tenant_id="+s.State.AzureTenantId,
-		"-var=terraform_state_azure_password="+s.State.AzurePassword,
-		"-var=octopus_destination_server="+s.State.DestinationServer,
-		"-var=octopus_destination_apikey="+s.State.DestinationApiKey,
-		"-var=ignore_all_library_variable_sets="+fmt.Sprint(s.State.ExcludeAllLibraryVariableSets),
-		"-var=octopus_destination_space_id="+s.State.DestinationSpace)
+	applyArgs := append([]string{"apply", "-auto-approve", "-no-color"}, s.terraformVars(serializeSpaceTemplate, deploySpaceTemplateS3, deploySpaceTemplateAzureStorage, spaceName)...)
+	applyCmd := exec.Command("terraform", applyArgs...)
 	applyCmd.Dir = dir
 
 	var stdout, stderr bytes.Buffer
@@ -461,11 +462,157 @@ func (s SpaceExportStep) Execute(prompt func(string, string, func(bool)), handle
 	if err := applyCmd.Run(); err != nil {
 		handleError("🔴 Terraform apply failed", errors.New(stdout.String()+stderr.String()))
 	} else {
+		completePhase(s.State, checkpoint.PhaseSpaceExport)
 		handleSuccess("🟢 Terraform apply succeeded")
 		fmt.Println(stdout.String() + stderr.String())
 	}
 }
 
+// Plan reports the changes made by Execute without modifying the space
+func (s SpaceExportStep) Plan() (ChangeReport, error) {
+	myclient, err := octoclient.CreateClient(s.State)
+
+	if err != nil {
+		return ChangeReport{}, errors.Join(errors.New("failed to create the client"), err)
+	}
+
+	report := ChangeReport{}
+
+	projExists, _, err := s.projectExists(myclient)
+
+	if err != nil {
+		return ChangeReport{}, err
+	}
+
+	if projExists {
+		report.PreCleanChanges = append(report.PreCleanChanges, "Delete the project "+spaceManagementProject)
+	}
+
+	pgExists, _, err := s.projectGroupExists(myclient)
+
+	if err != nil {
+		return ChangeReport{}, err
+	}
+
+	if pgExists {
+		report.PreCleanChanges = append(report.PreCleanChanges, "Delete the project group Octoterra")
+	}
+
+	lvsExists, _, err := query.LibraryVariableSetExists(myclient, "Octoterra")
+
+	if err != nil {
+		return ChangeReport{}, errors.Join(errors.New("failed to get the library variable set Octoterra"), err)
+	}
+
+	if lvsExists {
+		report.PreCleanChanges = append(report.PreCleanChanges, "Unlink the library variable set Octoterra from all projects and delete it, or rename it if it was captured in a release")
+	}
+
+	feedExists, _, err := s.feedExists(myclient)
+
+	if err != nil {
+		return ChangeReport{}, err
+	}
+
+	if feedExists {
+		report.PreCleanChanges = append(report.PreCleanChanges, "Delete the feed Octoterra Docker Feed")
+	}
+
+	for _, accountName := range []string{"Octoterra AWS Account", "Octoterra Azure Account", "Octoterra Google Cloud Account"} {
+		accountExists, _, err := s.accountExists(myclient, accountName)
+
+		if err != nil {
+			return ChangeReport{}, err
+		}
+
+		if accountExists {
+			report.PreCleanChanges = append(report.PreCleanChanges, "Delete the account "+accountName+", or rename it if it is in use")
+		}
+	}
+
+	templateIds := []string{}
+	for _, templateName := range []string{
+		"Octopus - Serialize Space to Terraform",
+		"Octopus - Populate Octoterra Space (S3 Backend)",
+		"Octopus - Populate Octoterra Space (Azure Backend)"} {
+		templateId, err, _ := query.GetStepTemplateId(myclient, s.State, templateName)
+
+		if errors.Is(err, query.ErrStepTemplateNotFound) {
+			// The template is installed by an earlier step, so plan against a placeholder ID
+			report.PreCleanChanges = append(report.PreCleanChanges, "The step template "+templateName+" is not installed. It is installed by the Install Step Templates step.")
+			templateId = "ActionTemplates-Placeholder"
+		} else if err != nil {
+			return ChangeReport{}, errors.Join(errors.New("failed to get the step template "+templateName), err)
+		}
+
+		templateIds = append(templateIds, templateId)
+	}
+
+	spaceName, err := query.GetSpaceName(myclient, s.State)
+
+	if err != nil {
+		return ChangeReport{}, errors.Join(errors.New("failed to get the space name"), err)
+	}
+
+	plan, err := planModule(module, s.terraformVars(templateIds[0], templateIds[1], templateIds[2], spaceName))
+
+	if err != nil {
+		return ChangeReport{}, errors.Join(errors.New("failed to plan the "+spaceManagementProject+" project"), err)
+	}
+
+	report.TerraformPlans = append(report.TerraformPlans, data.NameValuePair{Name: spaceManagementProject, Value: plan})
+
+	return report, nil
+}
+
+// terraformVars returns the variables passed to the space management module
+func (s SpaceExportStep) terraformVars(serializeSpaceTemplate string, deploySpaceTemplateS3 string, deploySpaceTemplateAzureStorage string, spaceName string) []string {
+	return []string{
+		"-var=octopus_serialize_actiontemplateid=" + serializeSpaceTemplate,
+		"-var=octopus_deploys3_actiontemplateid=" + deploySpaceTemplateS3,
+		"-var=octopus_deployazure_actiontemplateid=" + deploySpaceTemplateAzureStorage,
+		"-var=terraform_backend=" + s.State.BackendType,
+		"-var=default_secret_variables=false",
+		"-var=use_container_images=" + fmt.Sprint(s.State.UseContainerImages),
+		"-var=octopus_server_external=" + s.State.GetExternalServer(),
+		"-var=octopus_server=" + s.State.Server,
+		"-var=octopus_apikey=" + s.State.ApiKey,
+		"-var=octopus_space_id=" + s.State.Space,
+		"-var=octopus_space_name=" + spaceName,
+		"-var=terraform_state_bucket=" + s.State.AwsS3Bucket,
+		"-var=terraform_state_bucket_region=" + s.State.AwsS3BucketRegion,
+		"-var=terraform_state_aws_accesskey=" + s.State.AwsAccessKey,
+		"-var=terraform_state_aws_secretkey=" + s.State.AwsSecretKey,
+		"-var=terraform_state_aws_s3_endpoint=" + s.State.AwsS3Endpoint,
+		"-var=terraform_state_aws_s3_use_path_style=" + fmt.Sprint(s.State.AwsS3UsePathStyle),
+		"-var=terraform_state_aws_ca_bundle=" + s.State.AwsCaBundle,
+		"-var=terraform_state_aws_credentials=" + awsCredentialType(s.State),
+		"-var=terraform_state_aws_role_arn=" + s.State.AwsRoleArn,
+		"-var=terraform_state_aws_role_session_name=" + awsRoleSessionName(s.State),
+		"-var=terraform_state_aws_role_external_id=" + s.State.AwsRoleExternalId,
+		"-var=terraform_state_azure_resource_group=" + s.State.AzureResourceGroupName,
+		"-var=terraform_state_azure_storage_account=" + s.State.AzureStorageAccountName,
+		"-var=terraform_state_azure_storage_container=" + s.State.AzureContainerName,
+		"-var=terraform_state_azure_application_id=" + s.State.AzureApplicationId,
+		"-var=terraform_state_azure_subscription_id=" + s.State.AzureSubscriptionId,
+		"-var=terraform_state_azure_tenant_id=" + s.State.AzureTenantId,
+		"-var=terraform_state_azure_password=" + s.State.AzurePassword,
+		"-var=terraform_state_azure_credentials=" + validators.AzureAuthType(s.State),
+		"-var=terraform_state_azure_certificate_path=" + s.State.AzureCertificatePath,
+		"-var=terraform_state_azure_certificate_password=" + s.State.AzureCertificatePassword,
+		"-var=terraform_state_google_bucket=" + s.State.GoogleCloudBucket,
+		"-var=terraform_state_google_credentials=" + s.State.GoogleCloudCredentials,
+		"-var=terraform_state_http_address=" + s.State.HttpAddress,
+		"-var=terraform_state_http_username=" + s.State.HttpUsername,
+		"-var=terraform_state_http_password=" + s.State.HttpPassword,
+		"-var=terraform_state_postgres_connection_string=" + s.State.PostgresConnectionString,
+		"-var=octopus_destination_server=" + s.State.DestinationServer,
+		"-var=octopus_destination_apikey=" + s.State.DestinationApiKey,
+		"-var=ignore_all_library_variable_sets=" + fmt.Sprint(s.State.ExcludeAllLibraryVariableSets),
+		"-var=octopus_destination_space_id=" + s.State.DestinationSpace,
+	}
+}
+
 func (s SpaceExportStep) deleteProjectGroup(myclient *client.Client, projectGroup *projectgroups.ProjectGroup) error {
 	if err := myclient.ProjectGroups.DeleteByID(projectGroup.ID); err != nil {
 		return err
@@ -534,10 +681,18 @@ func (s SpaceExportStep) renameAccount(myclient *client.Client, account accounts
 }
 
 func (s SpaceExportStep) projectExists(myclient *client.Client) (bool, *projects.Project, error) {
-	if project, err := projects.GetByName(myclient, myclient.GetSpaceID(), spaceManagementProject); err == nil {
-		return true, project, nil
+	if allProjects, err := myclient.Projects.GetAll(); err == nil {
+		filteredProjects := lo.Filter(allProjects, func(project *projects.Project, index int) bool {
+			return project.Name == spaceManagementProject
+		})
+
+		if len(filteredProjects) == 0 {
+			return false, nil, nil
+		}
+
+		return true, filteredProjects[0], nil
 	} else {
-		return false, nil, errors.Join(errors.New("failed to get project with name "+spaceManagementProject), err)
+		return false, nil, errors.Join(errors.New("failed to get all projects"), err)
 	}
 }
 
@@ -11,15 +11,22 @@ import (
 	"github.com/mcasperson/OctoterraWizard/internal/spreadvariables"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+	"github.com/samber/lo"
 	"net/url"
+	"strings"
 )
 
 type SpreadVariablesStep struct {
 	BaseStep
 	Wizard          wizard.Wizard
 	spreadVariables *widget.Button
+	undoSpreading   *widget.Button
+	previewChanges  *widget.Button
 	confirmChanges  *widget.Check
 	exportDone      bool
+	// excludedOwners and excludedVariables are the IDs of the owners and variables unselected in the preview
+	excludedOwners    []string
+	excludedVariables []string
 }
 
 func (s SpreadVariablesStep) GetContainer(parent fyne.Window) *fyne.Container {
@@ -75,6 +82,95 @@ func (s SpreadVariablesStep) GetContainer(parent fyne.Window) *fyne.Container {
 	infinite.Start()
 	infinite.Hide()
 	result := widget.NewLabel("")
+
+	// The preview lists the owners and variables that are spread. Unselected owners and variables are excluded.
+	changes := []spreadvariables.SpreadChange{}
+	owners := widget.NewCheckGroup([]string{}, func(selected []string) {})
+	owners.Hide()
+	variableChanges := widget.NewCheckGroup([]string{}, func(selected []string) {})
+	variableChanges.Hide()
+	ownersLabel := widget.NewLabel("Projects and library variable sets")
+	ownersLabel.TextStyle = fyne.TextStyle{Bold: true}
+	ownersLabel.Hide()
+	variablesLabel := widget.NewLabel("Sensitive variables")
+	variablesLabel.TextStyle = fyne.TextStyle{Bold: true}
+	variablesLabel.Hide()
+	skippedLabel := widget.NewLabel("")
+	skippedLabel.Wrapping = fyne.TextWrapWord
+	skippedLabel.Hide()
+
+	ownerLabel := func(change spreadvariables.SpreadChange) string {
+		return change.OwnerName + " (" + change.OwnerID + ")"
+	}
+
+	s.previewChanges = widget.NewButton("Preview Changes", func() {
+		infinite.Show()
+		s.previewChanges.Disable()
+		result.SetText("🔵 Previewing the changes. This can take a little while.")
+
+		go func() {
+			previewChanges, err := s.Preview()
+
+			// The values that can not be spread are only missing if they are not extracted from the database
+			skippedValues := []spreadvariables.SkippedValue{}
+			if err == nil && s.State.DatabaseServer == "" {
+				skippedValues, err = s.SkippedValues()
+			}
+
+			skippedProjects := []spreadvariables.SkippedProject{}
+			if err == nil {
+				skippedProjects, err = s.SkippedProjects()
+			}
+
+			if err != nil {
+				if err := logutil.WriteTextToFile("spread_variables_error.txt", err.Error()); err != nil {
+					fmt.Println("Failed to write error to file")
+				}
+			}
+
+			fyne.Do(func() {
+				infinite.Hide()
+				s.previewChanges.Enable()
+
+				if err != nil {
+					result.SetText("🔴 An error was raised while attempting to preview the changes.\n " + err.Error())
+					return
+				}
+
+				changes = previewChanges
+				owners.Options = lo.Uniq(lo.Map(changes, func(item spreadvariables.SpreadChange, index int) string {
+					return ownerLabel(item)
+				}))
+				owners.SetSelected(owners.Options)
+				variableChanges.Options = lo.Map(changes, func(item spreadvariables.SpreadChange, index int) string {
+					return item.String()
+				})
+				variableChanges.SetSelected(variableChanges.Options)
+
+				for _, object := range []fyne.CanvasObject{ownersLabel, owners, variablesLabel, variableChanges} {
+					object.Show()
+				}
+
+				skipped := append(
+					lo.Map(skippedProjects, func(item spreadvariables.SkippedProject, index int) string {
+						return item.String()
+					}),
+					lo.Map(skippedValues, func(item spreadvariables.SkippedValue, index int) string {
+						return item.String()
+					})...)
+
+				if len(skipped) != 0 {
+					skippedLabel.SetText("These sensitive values can not be spread:\n" + strings.Join(skipped, "\n"))
+					skippedLabel.Show()
+				} else {
+					skippedLabel.Hide()
+				}
+
+				result.SetText(fmt.Sprintf("🟢 %d sensitive variables will be spread. Unselect any projects, library variable sets, or variables that should not be spread.", len(changes)))
+			})
+		}()
+	})
+
 	s.spreadVariables = widget.NewButton("Spread Sensitive Variables (click the checkbox above to continue)", func() {
 		next.Disable()
 		previous.Disable()
@@ -84,6 +180,18 @@ func (s SpreadVariablesStep) GetContainer(parent fyne.Window) *fyne.Container {
 		result.SetText("🔵 Spreading sensitive variables. This can take a little while.")
 		s.exportDone = true
 
+		s.excludedOwners = []string{}
+		s.excludedVariables = []string{}
+		for _, change := range changes {
+			if !lo.Contains(owners.Selected, ownerLabel(change)) && !lo.Contains(s.excludedOwners, change.OwnerID) {
+				s.excludedOwners = append(s.excludedOwners, change.OwnerID)
+			}
+
+			if !lo.Contains(variableChanges.Selected, change.String()) {
+				s.excludedVariables = append(s.excludedVariables, change.VariableID)
+			}
+		}
+
 		go func() {
 			defer previous.Enable()
 			defer infinite.Hide()
@@ -100,16 +208,107 @@ func (s SpreadVariablesStep) GetContainer(parent fyne.Window) *fyne.Container {
 		}()
 	})
 	s.spreadVariables.Disable()
-	middle := container.New(layout.NewVBoxLayout(), heading, intro, intro2, intro3, intro4, link, s.confirmChanges, s.spreadVariables, infinite, result)
 
-	content := container.NewBorder(nil, bottom, nil, nil, middle)
+	intro5 := widget.NewLabel(strutil.TrimMultilineWhitespace(`If the migration fails or is abandoned, click "Undo Spreading" to restore the original names and scopes of the sensitive variables and delete the variables referencing them.`))
+	intro5.Wrapping = fyne.TextWrapWord
+
+	s.undoSpreading = widget.NewButton("Undo Spreading", func() {
+		dialog.NewConfirm(
+			"Undo spreading?",
+			"Do you want to restore the sensitive variables spread by a previous migration?",
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+
+				next.Disable()
+				previous.Disable()
+				infinite.Show()
+				s.undoSpreading.Disable()
+				s.spreadVariables.Disable()
+				result.SetText("🔵 Restoring the spread sensitive variables. This can take a little while.")
+
+				go func() {
+					report, err := s.ExecuteUnspread()
+
+					if err != nil {
+						if err := logutil.WriteTextToFile("unspread_variables_error.txt", err.Error()); err != nil {
+							fmt.Println("Failed to write error to file")
+						}
+					}
+
+					fyne.Do(func() {
+						previous.Enable()
+						next.Enable()
+						infinite.Hide()
+						s.undoSpreading.Enable()
+						if s.confirmChanges.Checked {
+							s.spreadVariables.Enable()
+						}
+
+						if err != nil {
+							result.SetText("🔴 An error was raised while attempting to restore the variables.\n " + err.Error())
+						} else if len(report.Failures()) != 0 {
+							result.SetText("🔴 Some sensitive variables could not be restored.\n" + report.String())
+						} else {
+							result.SetText("🟢 " + report.String())
+						}
+					})
+				}()
+			}, s.Wizard.Window).Show()
+	})
+
+	buttons := container.New(layout.NewHBoxLayout(), s.previewChanges, s.spreadVariables)
+
+	middle := container.New(layout.NewVBoxLayout(), heading, intro, intro2, intro3, intro4, link, s.confirmChanges, buttons, intro5, s.undoSpreading, infinite, result)
+
+	preview := container.NewVScroll(container.New(layout.NewVBoxLayout(), ownersLabel, owners, variablesLabel, variableChanges, skippedLabel))
+
+	content := container.NewBorder(middle, bottom, nil, nil, preview)
 
 	return content
 }
 
 func (s SpreadVariablesStep) Execute() error {
 	spreader := spreadvariables.VariableSpreader{
-		State: s.State,
+		State:             s.State,
+		ExcludedOwners:    s.excludedOwners,
+		ExcludedVariables: s.excludedVariables,
 	}
 	return spreader.SpreadAllVariables()
 }
+
+// Preview returns the changes Execute would make to the variables
+func (s SpreadVariablesStep) Preview() ([]spreadvariables.SpreadChange, error) {
+	spreader := spreadvariables.VariableSpreader{
+		State:             s.State,
+		ExcludedOwners:    s.excludedOwners,
+		ExcludedVariables: s.excludedVariables,
+	}
+	return spreader.PreviewAllVariables()
+}
+
+// ExecuteUnspread restores the sensitive variables spread by Execute
+func (s SpreadVariablesStep) ExecuteUnspread() (spreadvariables.UnspreadReport, error) {
+	spreader := spreadvariables.VariableSpreader{
+		State: s.State,
+	}
+	return spreader.UnspreadAllVariables()
+}
+
+// SkippedValues returns the sensitive values that Execute can not spread
+func (s SpreadVariablesStep) SkippedValues() ([]spreadvariables.SkippedValue, error) {
+	spreader := spreadvariables.VariableSpreader{
+		State: s.State,
+	}
+	return spreader.FindSkippedValues()
+}
+
+// SkippedProjects returns the version controlled projects that Execute does not spread
+func (s SpreadVariablesStep) SkippedProjects() ([]spreadvariables.SkippedProject, error) {
+	spreader := spreadvariables.VariableSpreader{
+		State:          s.State,
+		ExcludedOwners: s.excludedOwners,
+	}
+	return spreader.FindSkippedProjects()
+}
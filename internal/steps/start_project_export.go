@@ -11,16 +11,18 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/client"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/deployments"
 	environments2 "github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/environments"
 	"github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/projects"
-	"github.com/mcasperson/OctoterraWizard/internal/data"
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
+	"github.com/mcasperson/OctoterraWizard/internal/dependencies"
 	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
 	"github.com/mcasperson/OctoterraWizard/internal/logutil"
 	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
-	"github.com/mcasperson/OctoterraWizard/internal/octoerrors"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+	"github.com/mcasperson/OctoterraWizard/internal/workerpool"
 	"github.com/samber/lo"
 )
 
@@ -138,7 +140,7 @@ func (s StartProjectExportStep) GetContainer(parent fyne.Window) *fyne.Container
 							fmt.Println("Failed to write error to file")
 						}
 
-						result.SetText(fmt.Sprintf("🔴 Failed to publish and run the runbooks. The failed tasks are shown below. You can review the task details in the Octopus console to find more information."))
+						result.SetText(fmt.Sprintf("🔴 Failed to publish and run the runbooks. The failed tasks, and the end of their logs, are shown below. You can review the task details in the Octopus console to find more information."))
 						s.logs.SetText(err.Error())
 						s.logs.Show()
 						link.Show()
@@ -165,163 +167,250 @@ func (s StartProjectExportStep) Execute(statusCallback func(message string), don
 		return
 	}
 
-	filteredProjects, err := infrastructure.GetProjects(myclient)
+	allProjects, err := infrastructure.GetSelectedProjects(myclient, s.State)
 
 	if err != nil {
 		errCallback(errors.Join(errors.New("failed to get all projects"), err))
 		return
 	}
 
-	// We start by exporting projects that do not have "Deploy a release" steps
-	var filterErrors error = nil
-	regularProjects := lo.Filter(filteredProjects, func(project *projects.Project, index int) bool {
+	journal, err := LoadJournal(s.State)
 
-		var process *deployments.DeploymentProcess = nil
-
-		if project.IsVersionControlled {
-			if gitPersistence, ok := project.PersistenceSettings.(projects.GitPersistenceSettings); ok {
-
-				process, err = deployments.GetDeploymentProcessByGitRef(myclient, myclient.GetSpaceID(), project, "refs/heads/"+gitPersistence.DefaultBranch())
-
-				if err != nil {
-					// "bad packet length" has been seen on projects with invalid git configuration, so we just ignore it
-					if strings.Index(err.Error(), "bad packet length") == -1 {
-						filterErrors = errors.Join(filterErrors, errors.Join(errors.New("failed to get deployment process by gitref \"refs/heads/"+gitPersistence.DefaultBranch()+"\" for project "+project.Name), err))
-					}
-					return false
-				}
-			}
-		} else {
-			process, err = deployments.GetDeploymentProcessByID(myclient, myclient.GetSpaceID(), project.DeploymentProcessID)
+	if err != nil {
+		errCallback(errors.Join(errors.New("failed to load the migration checkpoints"), err))
+		return
+	}
 
-			if err != nil {
-				filterErrors = errors.Join(filterErrors, errors.Join(errors.New("failed to get deployment process by ID "+project.DeploymentProcessID+" for project "+project.Name), err))
-				return false
-			}
-		}
+	// Projects that were migrated by a previous run are not migrated again
+	filteredProjects := lo.Filter(allProjects, func(project *projects.Project, index int) bool {
+		return !journal.IsProjectMigrated(project.ID)
+	})
 
-		if process == nil {
-			return false
-		}
+	if skipped := len(allProjects) - len(filteredProjects); skipped != 0 {
+		statusCallback("🔵 Skipping " + fmt.Sprint(skipped) + " projects that were already migrated")
+	}
 
-		return !lo.ContainsBy(process.Steps, func(step *deployments.DeploymentStep) bool {
-			return lo.ContainsBy(step.Actions, func(action *deployments.DeploymentAction) bool {
-				return action.ActionType == "Octopus.DeployRelease"
-			})
-		})
-	})
+	/*
+		Projects with "Deploy a release" steps reference the child projects via a data source in the Terraform module,
+		so the child projects must be migrated first. The projects are sorted into waves where each project only
+		depends on projects in earlier waves.
+	*/
+	projectWaves, projectDependencies, err := s.getProjectWaves(myclient, filteredProjects)
 
-	if filterErrors != nil {
-		errCallback(filterErrors)
+	if err != nil {
+		errCallback(err)
 		return
 	}
 
-	runAndTaskError := s.serializeProjects(regularProjects, runbookEnvironment, statusCallback)
-	runAndTaskError = errors.Join(runAndTaskError, s.deployProjects(regularProjects, runbookEnvironment, statusCallback))
+	var runAndTaskError error = nil
+	for waveIndex, wave := range projectWaves {
+		// Projects whose child projects failed to migrate will also fail, so skip them
+		readyProjects := lo.Filter(wave, func(project *projects.Project, index int) bool {
+			failedDependencies := lo.Filter(projectDependencies[project.ID], func(dependency string, index int) bool {
+				return lo.ContainsBy(filteredProjects, func(item *projects.Project) bool {
+					return item.ID == dependency
+				}) && !journal.IsProjectMigrated(dependency)
+			})
 
-	/*
-		Now we export projects that have "Deploy a release" steps. This ensures any child projects are available to
-		be queried via a data source in the Terraform module.
-	*/
-	deployReleaseProjects := lo.Filter(filteredProjects, func(project *projects.Project, index int) bool {
-		return !lo.ContainsBy(regularProjects, func(regularProject *projects.Project) bool {
-			return project.ID == regularProject.ID
+			if len(failedDependencies) != 0 {
+				runAndTaskError = errors.Join(runAndTaskError, errors.New("skipped project "+project.Name+" because the projects it deploys ("+strings.Join(s.getProjectNames(filteredProjects, failedDependencies), ", ")+") failed to migrate"))
+				return false
+			}
+
+			return true
 		})
-	})
 
-	/*
-		It is possible that a project has a "Deploy a release" step but also has a "Deploy a release" step in a child project.
-		So there is a deeper level of dependencies here. However, we rely on the step retry functionality in Octopus to
-		allow the "top level" project to be exported first, and then the child project to be exported later.
-		Maybe we need to be clever here and try to order these projects more intelligently, but for now we just rely on
-		the retry functionality.
-	*/
-	runAndTaskError = errors.Join(runAndTaskError, s.serializeProjects(deployReleaseProjects, runbookEnvironment, statusCallback))
-	runAndTaskError = errors.Join(runAndTaskError, s.deployProjects(deployReleaseProjects, runbookEnvironment, statusCallback))
+		statusCallback("🔵 Migrating " + fmt.Sprint(len(readyProjects)) + " projects in wave " + fmt.Sprint(waveIndex+1) + "/" + fmt.Sprint(len(projectWaves)))
+
+		runAndTaskError = errors.Join(runAndTaskError, s.serializeProjects(readyProjects, runbookEnvironment, journal, statusCallback))
+		runAndTaskError = errors.Join(runAndTaskError, s.deployProjects(readyProjects, runbookEnvironment, journal, statusCallback))
+	}
 
 	if runAndTaskError != nil {
 		errCallback(runAndTaskError)
 		return
 	}
 
+	if err := journal.CompletePhase(checkpoint.PhaseStartProjectExport); err != nil {
+		fmt.Println(err.Error())
+	}
+
 	successCallback()
 }
 
-func (s StartProjectExportStep) serializeProjects(filteredProjects []*projects.Project, runbookEnvironment string, statusCallback func(message string)) error {
-	var runAndTaskError error = nil
+// getProjectWaves sorts the projects into waves based on the projects deployed by their "Deploy a release" steps.
+// The dependencies of each project, keyed by project ID, are also returned.
+func (s StartProjectExportStep) getProjectWaves(myclient *client.Client, filteredProjects []*projects.Project) ([][]*projects.Project, map[string][]string, error) {
+	projectDependencies := map[string][]string{}
+	var processErrors error = nil
 
 	for _, project := range filteredProjects {
-		if err := infrastructure.PublishRunbook(s.State, "__ 1. Serialize Project", project.Name); err != nil {
-			return errors.Join(errors.New("failed to publish runbook \"__ 1. Serialize Project\" for project "+project.Name), err)
-		}
+		process, err := s.getDeploymentProcess(myclient, project)
 
-		statusCallback("🔵 Published __ 1. Serialize Project runbook in project " + project.Name)
-	}
+		if err != nil {
+			processErrors = errors.Join(processErrors, err)
+			continue
+		}
 
-	tasks := []data.NameValuePair{}
+		if process == nil {
+			continue
+		}
 
-	for _, project := range filteredProjects {
-		if taskId, err := infrastructure.RunRunbook(s.State, "__ 1. Serialize Project", project.Name, runbookEnvironment); err != nil {
+		for _, step := range process.Steps {
+			for _, action := range step.Actions {
+				if action.ActionType != "Octopus.DeployRelease" {
+					continue
+				}
 
-			var failedRunbookRun octoerrors.RunbookRunFailedError
-			if errors.As(err, &failedRunbookRun) {
-				runAndTaskError = errors.Join(runAndTaskError, errors.Join(errors.New("failed to run runbook \"__ 1. Serialize Project\" in project "+project.Name), failedRunbookRun))
-			} else {
-				return errors.Join(errors.New("failed to run runbook \"__ 1. Serialize Project\" for project "+project.Name), err)
+				if childProjectId := action.Properties["Octopus.Action.DeployRelease.ProjectId"].Value; childProjectId != "" {
+					projectDependencies[project.ID] = append(projectDependencies[project.ID], childProjectId)
+				}
 			}
-		} else {
-			tasks = append(tasks, data.NameValuePair{Name: project.Name, Value: taskId})
 		}
 	}
 
-	serializeIndex := 0
-	statusCallback("🔵 Started running the __ 1. Serialize Project runbooks (" + fmt.Sprint(serializeIndex) + "/" + fmt.Sprint(len(tasks)) + ")")
-	for _, task := range tasks {
-		if err := infrastructure.WaitForTask(s.State, task.Value, func(message string) {
-			statusCallback("🔵 __ 1. Serialize Project for project " + task.Name + " is " + message + " (" + fmt.Sprint(serializeIndex) + "/" + fmt.Sprint(len(tasks)) + ")")
-		}); err != nil {
-			runAndTaskError = errors.Join(runAndTaskError, errors.Join(errors.New("failed to get task state for task "+task.Name), err))
+	if processErrors != nil {
+		return nil, nil, processErrors
+	}
+
+	projectIds := lo.Map(filteredProjects, func(item *projects.Project, index int) string {
+		return item.ID
+	})
+
+	waves, err := dependencies.Waves(projectIds, projectDependencies)
+
+	if err != nil {
+		var cycleError dependencies.CycleError
+		if errors.As(err, &cycleError) {
+			return nil, nil, errors.New("the \"Deploy a release\" steps in the projects " + strings.Join(s.getProjectNames(filteredProjects, cycleError.Nodes), ", ") + " deploy each other in a cycle, so the projects can not be migrated in order")
 		}
-		serializeIndex++
+
+		return nil, nil, err
 	}
 
-	return runAndTaskError
+	projectWaves := lo.Map(waves, func(wave []string, index int) []*projects.Project {
+		return lo.Map(wave, func(projectId string, index int) *projects.Project {
+			project, _ := lo.Find(filteredProjects, func(item *projects.Project) bool {
+				return item.ID == projectId
+			})
+			return project
+		})
+	})
+
+	return projectWaves, projectDependencies, nil
 }
 
-func (s StartProjectExportStep) deployProjects(filteredProjects []*projects.Project, runbookEnvironment string, statusCallback func(message string)) error {
-	var runAndTaskError error = nil
+// getDeploymentProcess returns the deployment process of a project, reading it from the default branch of
+// version controlled projects. A nil process is returned for projects where the process can not be read.
+func (s StartProjectExportStep) getDeploymentProcess(myclient *client.Client, project *projects.Project) (*deployments.DeploymentProcess, error) {
+	if project.IsVersionControlled {
+		gitPersistence, ok := project.PersistenceSettings.(projects.GitPersistenceSettings)
 
-	for _, project := range filteredProjects {
-		if err := infrastructure.PublishRunbook(s.State, "__ 2. Deploy Project", project.Name); err != nil {
-			return errors.Join(errors.New("failed to publish runbook \"__ 2. Deploy Project\" for project "+project.Name), err)
+		if !ok {
+			return nil, nil
 		}
-		statusCallback("🔵 Published __ 2. Deploy Space runbook in project " + project.Name)
+
+		process, err := deployments.GetDeploymentProcessByGitRef(myclient, myclient.GetSpaceID(), project, "refs/heads/"+gitPersistence.DefaultBranch())
+
+		if err != nil {
+			// "bad packet length" has been seen on projects with invalid git configuration, so we just ignore it
+			if strings.Index(err.Error(), "bad packet length") == -1 {
+				return nil, errors.Join(errors.New("failed to get deployment process by gitref \"refs/heads/"+gitPersistence.DefaultBranch()+"\" for project "+project.Name), err)
+			}
+
+			return nil, nil
+		}
+
+		return process, nil
+	}
+
+	process, err := deployments.GetDeploymentProcessByID(myclient, myclient.GetSpaceID(), project.DeploymentProcessID)
+
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to get deployment process by ID "+project.DeploymentProcessID+" for project "+project.Name), err)
 	}
 
-	applyTasks := []data.NameValuePair{}
+	return process, nil
+}
+
+// getProjectNames maps project IDs to project names
+func (s StartProjectExportStep) getProjectNames(allProjects []*projects.Project, projectIds []string) []string {
+	return lo.Map(projectIds, func(projectId string, index int) string {
+		if project, ok := lo.Find(allProjects, func(item *projects.Project) bool {
+			return item.ID == projectId
+		}); ok {
+			return project.Name
+		}
+
+		return projectId
+	})
+}
+
+func (s StartProjectExportStep) serializeProjects(filteredProjects []*projects.Project, runbookEnvironment string, journal *checkpoint.Journal, statusCallback func(message string)) error {
+	return s.runProjectRunbooks("__ 1. Serialize Project", filteredProjects, runbookEnvironment, journal.RecordSerializeTask, statusCallback)
+}
+
+func (s StartProjectExportStep) deployProjects(filteredProjects []*projects.Project, runbookEnvironment string, journal *checkpoint.Journal, statusCallback func(message string)) error {
+	return s.runProjectRunbooks("__ 2. Deploy Project", filteredProjects, runbookEnvironment, journal.RecordDeployTask, statusCallback)
+}
+
+// runProjectRunbooks publishes the runbook in each project, and then runs the runbooks and waits for the tasks to
+// complete. No more than s.State.MaxConcurrentRunbooks runbooks are run at once. The error from each project is
+// collected and returned once all the runbooks have completed.
+func (s StartProjectExportStep) runProjectRunbooks(runbookName string, filteredProjects []*projects.Project, runbookEnvironment string, record func(string, string, string, string) error, statusCallback func(message string)) error {
 	for _, project := range filteredProjects {
-		if taskId, err := infrastructure.RunRunbook(s.State, "__ 2. Deploy Project", project.Name, runbookEnvironment); err != nil {
-			var failedRunbookRun octoerrors.RunbookRunFailedError
-			if errors.As(err, &failedRunbookRun) {
-				runAndTaskError = errors.Join(runAndTaskError, errors.Join(errors.New("failed to run runbook \"__ 2. Deploy Project\" in project "+project.Name), failedRunbookRun))
-			} else {
-				return errors.Join(errors.New("Failed to run runbook \"__ 2. Deploy Project\" for project "+project.Name), err)
-			}
-		} else {
-			applyTasks = append(applyTasks, data.NameValuePair{Name: project.Name, Value: taskId})
+		if err := infrastructure.PublishRunbook(s.State, runbookName, project.Name); err != nil {
+			return errors.Join(errors.New("failed to publish runbook \""+runbookName+"\" for project "+project.Name), err)
 		}
+
+		statusCallback("🔵 Published " + runbookName + " runbook in project " + project.Name)
 	}
 
-	applyIndex := 0
-	statusCallback("🔵 Started running the __ 2. Deploy Project runbooks (" + fmt.Sprint(applyIndex) + "/" + fmt.Sprint(len(applyTasks)) + ")")
-	for _, task := range applyTasks {
-		if err := infrastructure.WaitForTask(s.State, task.Value, func(message string) {
-			statusCallback("🔵 __ 2. Deploy Project for project " + task.Name + " is " + message + " (" + fmt.Sprint(applyIndex) + "/" + fmt.Sprint(len(applyTasks)) + ")")
-		}); err != nil {
-			runAndTaskError = errors.Join(runAndTaskError, errors.Join(errors.New("failed to get task state for task "+task.Name), err))
+	outcomes := workerpool.Run(filteredProjects, s.State.MaxConcurrentRunbooks, func(project *projects.Project) error {
+		taskId, err := infrastructure.RunRunbook(s.State, runbookName, project.Name, runbookEnvironment)
+
+		if err != nil {
+			return errors.Join(errors.New("failed to run runbook \""+runbookName+"\" in project "+project.Name), err)
 		}
-		applyIndex++
+
+		s.recordTask(record, project, taskId, "")
+
+		err = infrastructure.WaitForTask(s.State, taskId, func(message string) {})
+		s.recordTask(record, project, taskId, taskResult(err))
+
+		if err != nil {
+			return errors.Join(errors.New("failed to get task state for task "+project.Name), err)
+		}
+
+		return nil
+	}, func(progress workerpool.Progress) {
+		statusCallback("🔵 Running the " + runbookName + " runbooks: " +
+			fmt.Sprint(progress.Running) + " running, " +
+			fmt.Sprint(progress.Complete()) + "/" + fmt.Sprint(progress.Total) + " complete, " +
+			fmt.Sprint(progress.Failed) + " failed")
+	})
+
+	var runAndTaskError error = nil
+	for _, outcome := range outcomes {
+		runAndTaskError = errors.Join(runAndTaskError, outcome.Error)
 	}
 
 	return runAndTaskError
 }
+
+// recordTask saves the state of a project runbook task to the checkpoint journal. Running tasks
+// have an empty result, so an interrupted task is never treated as a success.
+func (s StartProjectExportStep) recordTask(record func(string, string, string, string) error, project *projects.Project, taskId string, result string) {
+	if err := record(project.ID, project.Name, taskId, result); err != nil {
+		fmt.Println(err.Error())
+	}
+}
+
+// taskResult converts the error returned when waiting for a task into the result saved in the checkpoint journal
+func taskResult(taskErr error) string {
+	if taskErr != nil {
+		return "Failed"
+	}
+
+	return checkpoint.TaskSuccess
+}
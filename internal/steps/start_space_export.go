@@ -11,6 +11,7 @@ import (
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 	environments2 "github.com/OctopusDeploy/go-octopusdeploy/v2/pkg/environments"
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
 	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
 	"github.com/mcasperson/OctoterraWizard/internal/logutil"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
@@ -93,6 +94,8 @@ func (s StartSpaceExportStep) GetContainer(parent fyne.Window) *fyne.Container {
 
 	environmentContainer := container.New(layout.NewHBoxLayout(), environmentsLabel, s.environments)
 
+	tailLogs := widget.NewCheck("Show the runbook logs while the runbooks are running", func(bool) {})
+
 	s.exportSpace = widget.NewButton("Export Space", func() {
 		s.exportDone = true
 		s.exportSpace.Disable()
@@ -104,6 +107,17 @@ func (s StartSpaceExportStep) GetContainer(parent fyne.Window) *fyne.Container {
 
 		result.SetText("🔵 Running the runbooks.")
 
+		var logCallback func(log string) = nil
+		if tailLogs.Checked {
+			s.logs.SetText("")
+			s.logs.Show()
+			logCallback = func(log string) {
+				fyne.Do(func() {
+					s.logs.SetText(log)
+				})
+			}
+		}
+
 		go func() {
 			s.Execute(
 				func(message string) {
@@ -111,11 +125,11 @@ func (s StartSpaceExportStep) GetContainer(parent fyne.Window) *fyne.Container {
 						result.SetText(message)
 					})
 				},
+				logCallback,
 				func() {
 					fyne.Do(func() {
 						next.Enable()
 						previous.Enable()
-						s.logs.Hide()
 						infinite.Hide()
 						s.exportSpace.Enable()
 					})
@@ -123,6 +137,7 @@ func (s StartSpaceExportStep) GetContainer(parent fyne.Window) *fyne.Container {
 				func() {
 					fyne.Do(func() {
 						result.SetText("🟢 Runbooks ran successfully.")
+						s.logs.Hide()
 					})
 				},
 				func(err error) {
@@ -144,14 +159,16 @@ func (s StartSpaceExportStep) GetContainer(parent fyne.Window) *fyne.Container {
 				s.environments.Selected)
 		}()
 	})
-	middle := container.New(layout.NewVBoxLayout(), heading, label1, environmentContainer, s.exportSpace, infinite, result, link, s.logs)
+	middle := container.New(layout.NewVBoxLayout(), heading, label1, environmentContainer, tailLogs, s.exportSpace, infinite, result, link, s.logs)
 
 	content := container.NewBorder(nil, bottom, nil, nil, middle)
 
 	return content
 }
 
-func (s StartSpaceExportStep) Execute(statusCallback func(message string), doneCallback func(), successCallback func(), errCallback func(error), runbookEnvironment string) {
+// Execute publishes and runs the space runbooks. When logCallback is not nil, it is passed the end of the log of the
+// running task.
+func (s StartSpaceExportStep) Execute(statusCallback func(message string), logCallback func(log string), doneCallback func(), successCallback func(), errCallback func(error), runbookEnvironment string) {
 
 	defer doneCallback()
 
@@ -166,9 +183,9 @@ func (s StartSpaceExportStep) Execute(statusCallback func(message string), doneC
 		errCallback(err)
 		return
 	} else {
-		if err := infrastructure.WaitForTask(s.State, taskId, func(message string) {
+		if err := s.waitForTask(taskId, func(message string) {
 			statusCallback("🔵 __ 1. Serialize Space is " + message)
-		}); err != nil {
+		}, logCallback); err != nil {
 			errCallback(errors.Join(errors.New("failed to get task status for task "+taskId), err))
 			return
 		}
@@ -185,17 +202,26 @@ func (s StartSpaceExportStep) Execute(statusCallback func(message string), doneC
 		errCallback(err)
 		return
 	} else {
-		if err := infrastructure.WaitForTask(s.State, taskId, func(message string) {
+		if err := s.waitForTask(taskId, func(message string) {
 			if message == "Success" {
 				statusCallback("🔵 __ 2. Deploy Space is " + message)
 			} else {
 				statusCallback("🔵 __ 2. Deploy Space is " + message + ". This runbook can take quite some time (many hours) for large spaces.")
 			}
-		}); err != nil {
+		}, logCallback); err != nil {
 			errCallback(errors.Join(errors.New("failed to get task status for task "+taskId), err))
 			return
 		}
 	}
 
+	completePhase(s.State, checkpoint.PhaseStartSpaceExport)
 	successCallback()
 }
+
+func (s StartSpaceExportStep) waitForTask(taskId string, statusCallback func(message string), logCallback func(log string)) error {
+	if logCallback == nil {
+		return infrastructure.WaitForTask(s.State, taskId, statusCallback)
+	}
+
+	return infrastructure.WaitForTaskWithLog(s.State, taskId, statusCallback, logCallback)
+}
@@ -7,6 +7,7 @@ import (
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+	"github.com/mcasperson/OctoterraWizard/internal/checkpoint"
 	"github.com/mcasperson/OctoterraWizard/internal/octoclient"
 	"github.com/mcasperson/OctoterraWizard/internal/query"
 	"github.com/mcasperson/OctoterraWizard/internal/strutil"
@@ -141,5 +142,7 @@ func (s StepTemplateStep) Execute() (string, error) {
 		return message, errors.Join(errors.New("failed to install step template"), err)
 	}
 
+	completePhase(s.State, checkpoint.PhaseStepTemplates)
+
 	return "🟢 Step templates installed.", nil
 }
@@ -0,0 +1,231 @@
+package steps
+
+import (
+	"errors"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+	"github.com/mcasperson/OctoterraWizard/internal/logutil"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/strutil"
+	"github.com/mcasperson/OctoterraWizard/internal/terraformstate"
+	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+)
+
+// stateStores create the stores for the backends whose Terraform state can be managed. The HTTP backend is not
+// included, as the protocol has no way to list the saved state.
+var stateStores = map[string]func(state state.State) (terraformstate.Store, error){
+	AwsS3:              terraformstate.NewS3Store,
+	AzureStorage:       terraformstate.NewAzureStore,
+	GoogleCloudStorage: terraformstate.NewGcsStore,
+	PostgreSQL:         terraformstate.NewPostgresStore,
+}
+
+// StateStoreSupported returns true if the Terraform state saved in the backend can be managed
+func StateStoreSupported(backendType string) bool {
+	_, ok := stateStores[NormalizeBackendType(backendType)]
+	return ok
+}
+
+// NewStateStore returns the store for the Terraform state saved in the configured backend
+func NewStateStore(state state.State) (terraformstate.Store, error) {
+	backendType := NormalizeBackendType(state.BackendType)
+	newStore, ok := stateStores[backendType]
+
+	if !ok {
+		return nil, errors.Join(terraformstate.ErrUnsupportedBackend, errors.New("the "+backendType+" backend has no way to list the saved state, so the state must be managed with the service that stores it"))
+	}
+
+	return newStore(state)
+}
+
+// TerraformStateStep lists the Terraform state saved by previous migrations, allowing the state to be
+// archived or deleted before the space is migrated again
+type TerraformStateStep struct {
+	BaseStep
+	Wizard wizard.Wizard
+}
+
+func (s TerraformStateStep) GetContainer(parent fyne.Window) *fyne.Container {
+
+	bottom, _, next := s.BuildNavigation(func() {
+		s.Wizard.ShowWizardStep(AdvancedOptionsStep{
+			Wizard:   s.Wizard,
+			BaseStep: BaseStep{State: s.State}})
+	}, func() {})
+	next.Hide()
+
+	heading := widget.NewLabel("Terraform State")
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+
+	label1 := widget.NewLabel(strutil.TrimMultilineWhitespace(`
+		These are the Terraform state files saved by previous migrations, listed by workspace and key.
+		The workspace is the ID of the destination space, and the key is derived from the name of the project.
+		Migrating a space again reuses the state in the workspace of the destination space.
+		Archived state files are moved to the ` + terraformstate.ArchivePrefix + ` folder, and deleted state files can not be recovered.
+	`))
+
+	result := widget.NewLabel("")
+
+	infinite := widget.NewProgressBarInfinite()
+	infinite.Hide()
+	infinite.Start()
+
+	logs := widget.NewEntry()
+	logs.SetMinRowsVisible(10)
+	logs.MultiLine = true
+	logs.Disable()
+	logs.Hide()
+
+	objects := map[string]terraformstate.Object{}
+	checks := widget.NewCheckGroup([]string{}, func(selected []string) {})
+
+	var refresh, archive, remove *widget.Button
+
+	setEnabled := func(enabled bool) {
+		for _, button := range []*widget.Button{refresh, archive, remove} {
+			if enabled {
+				button.Enable()
+			} else {
+				button.Disable()
+			}
+		}
+	}
+
+	showError := func(message string, err error) {
+		if err := logutil.WriteTextToFile("terraform_state_error.txt", err.Error()); err != nil {
+			fmt.Println("Failed to write error to file")
+		}
+
+		result.SetText("🔴 " + message)
+		logs.SetText(err.Error())
+		logs.Show()
+	}
+
+	// run calls the function with the store in the background, and then lists the state files again
+	run := func(message string, action func(store terraformstate.Store) error) {
+		result.SetText("🔵 " + message)
+		logs.Hide()
+		infinite.Show()
+		setEnabled(false)
+
+		go func() {
+			var stateObjects []terraformstate.Object
+			store, err := NewStateStore(s.State)
+
+			if err == nil {
+				err = action(store)
+			}
+
+			if err == nil {
+				stateObjects, err = store.List()
+			}
+
+			fyne.Do(func() {
+				infinite.Hide()
+				setEnabled(true)
+
+				if err != nil {
+					showError("Failed to manage the Terraform state.", err)
+					return
+				}
+
+				clear(objects)
+				options := []string{}
+				for _, object := range stateObjects {
+					objects[object.String()] = object
+					options = append(options, object.String())
+				}
+
+				checks.Options = options
+				checks.SetSelected([]string{})
+				checks.Refresh()
+
+				if len(options) == 0 {
+					result.SetText("🟢 No Terraform state files were found.")
+				} else {
+					result.SetText(fmt.Sprintf("🟢 Found %d Terraform state files.", len(options)))
+				}
+			})
+		}()
+	}
+
+	// selected returns the state files that were checked
+	selected := func() []terraformstate.Object {
+		selectedObjects := []terraformstate.Object{}
+		for _, option := range checks.Selected {
+			if object, ok := objects[option]; ok {
+				selectedObjects = append(selectedObjects, object)
+			}
+		}
+
+		return selectedObjects
+	}
+
+	refresh = widget.NewButton("Refresh", func() {
+		run("Listing the Terraform state files.", func(store terraformstate.Store) error { return nil })
+	})
+
+	archive = widget.NewButton("Archive Selected", func() {
+		selectedObjects := selected()
+		if len(selectedObjects) == 0 {
+			return
+		}
+
+		run("Archiving the Terraform state files.", func(store terraformstate.Store) error {
+			for _, object := range selectedObjects {
+				if err := store.Archive(object); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	})
+
+	remove = widget.NewButton("Delete Selected", func() {
+		selectedObjects := selected()
+		if len(selectedObjects) == 0 {
+			return
+		}
+
+		dialog.NewConfirm(
+			"Delete the Terraform state?",
+			fmt.Sprintf("Do you want to delete %d Terraform state files? Deleted state files can not be recovered.", len(selectedObjects)),
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+
+				run("Deleting the Terraform state files.", func(store terraformstate.Store) error {
+					for _, object := range selectedObjects {
+						if err := store.Delete(object); err != nil {
+							return err
+						}
+					}
+
+					return nil
+				})
+			}, s.Wizard.Window).Show()
+	})
+
+	buttons := container.New(layout.NewHBoxLayout(), refresh, archive, remove)
+
+	middle := container.New(layout.NewVBoxLayout(), heading, label1, buttons, infinite, result, logs)
+
+	content := container.NewBorder(middle, bottom, nil, nil, container.NewVScroll(checks))
+
+	if !StateStoreSupported(s.State.BackendType) {
+		result.SetText("🔴 The Terraform state can not be managed for the " + NormalizeBackendType(s.State.BackendType) + " backend. Archive or delete the state with the service that stores it.")
+		setEnabled(false)
+		return content
+	}
+
+	run("Listing the Terraform state files.", func(store terraformstate.Store) error { return nil })
+
+	return content
+}
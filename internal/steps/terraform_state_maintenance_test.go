@@ -0,0 +1,47 @@
+package steps
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/terraformstate"
+)
+
+func TestStateStoreSupported(t *testing.T) {
+	supported := map[string]bool{
+		AwsS3:              true,
+		AzureStorage:       true,
+		GoogleCloudStorage: true,
+		PostgreSQL:         true,
+		HttpBackend:        false,
+		// Unrecognized backends use Azure Storage
+		"":        true,
+		"unknown": true,
+	}
+
+	for backendType, expected := range supported {
+		if StateStoreSupported(backendType) != expected {
+			t.Fatalf("expected the state store support for %q to be %t", backendType, expected)
+		}
+	}
+}
+
+func TestNewStateStore(t *testing.T) {
+	if _, err := NewStateStore(state.State{BackendType: HttpBackend}); !errors.Is(err, terraformstate.ErrUnsupportedBackend) {
+		t.Fatalf("expected the HTTP backend to be unsupported, got %v", err)
+	}
+
+	store, err := NewStateStore(state.State{BackendType: PostgreSQL, PostgresConnectionString: "postgres://terraform@db.example.com/terraform"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if store == nil {
+		t.Fatal("expected a store for the PostgreSQL backend")
+	}
+
+	if _, err := NewStateStore(state.State{BackendType: PostgreSQL}); err == nil || errors.Is(err, terraformstate.ErrUnsupportedBackend) {
+		t.Fatalf("expected the PostgreSQL store to require a connection string, got %v", err)
+	}
+}
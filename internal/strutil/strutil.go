@@ -11,3 +11,14 @@ func TrimMultilineWhitespace(input string) string {
 		return trimmed, trimmed != ""
 	}), "\n")
 }
+
+// TailLines returns the last count lines of the input
+func TailLines(input string, count int) string {
+	lines := strings.Split(strings.TrimRight(input, "\r\n"), "\n")
+
+	if len(lines) <= count {
+		return strings.Join(lines, "\n")
+	}
+
+	return strings.Join(lines[len(lines)-count:], "\n")
+}
@@ -34,3 +34,29 @@ func TestTrimMultilineWhitespace(t *testing.T) {
 		}
 	}
 }
+
+func TestTailLines(t *testing.T) {
+	tests := []struct {
+		input    string
+		count    int
+		expected string
+	}{
+		{
+			input:    "line1\nline2\nline3\n",
+			count:    2,
+			expected: "line2\nline3",
+		},
+		{
+			input:    "line1\nline2",
+			count:    5,
+			expected: "line1\nline2",
+		},
+	}
+
+	for _, test := range tests {
+		result := TailLines(test.input, test.count)
+		if result != test.expected {
+			t.Errorf("TailLines(%q, %d) = %q; want %q", test.input, test.count, result, test.expected)
+		}
+	}
+}
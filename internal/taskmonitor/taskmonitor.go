@@ -0,0 +1,224 @@
+package taskmonitor
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTaskNotFound is returned when the server does not return a task that is being waited on
+var ErrTaskNotFound = errors.New("the task was not found")
+
+// ErrTimeout is returned when a task does not complete before the timeout
+var ErrTimeout = errors.New("the task did not complete within the timeout")
+
+// MaxFetchFailures is the number of consecutive failed queries before the waiting tasks are failed
+const MaxFetchFailures = 5
+
+// Status is the state of a task returned by the server
+type Status struct {
+	State       string
+	IsCompleted bool
+}
+
+// Fetch queries the status of many tasks in a single request, returning the statuses keyed by task ID
+type Fetch func(taskIds []string) (map[string]Status, error)
+
+// Monitor polls the status of all the tasks being waited on with a single query. The polling interval starts at
+// MinInterval, doubles each time a query finds no changes up to MaxInterval, and resets when any task changes state.
+type Monitor struct {
+	MinInterval time.Duration
+	MaxInterval time.Duration
+
+	fetch   Fetch
+	timeout time.Duration
+	mutex   sync.Mutex
+	waiters map[string][]*waiter
+	polling bool
+}
+
+type waiter struct {
+	callback  func(state string)
+	deadline  time.Time
+	lastState string
+	done      chan result
+}
+
+type result struct {
+	status Status
+	err    error
+}
+
+// New creates a monitor. Each call to Wait fails with ErrTimeout if the task does not complete within the timeout.
+func New(fetch Fetch, timeout time.Duration) *Monitor {
+	return &Monitor{
+		MinInterval: 2 * time.Second,
+		MaxInterval: 30 * time.Second,
+		fetch:       fetch,
+		timeout:     timeout,
+		waiters:     map[string][]*waiter{},
+	}
+}
+
+// Wait blocks until the task completes, returning the final status. The callback is called each time the state of
+// the task changes. Wait can be called concurrently, and all waiting tasks are queried together.
+func (m *Monitor) Wait(taskId string, callback func(state string)) (Status, error) {
+	taskWaiter := &waiter{
+		callback: callback,
+		deadline: time.Now().Add(m.timeout),
+		done:     make(chan result, 1),
+	}
+
+	m.mutex.Lock()
+	m.waiters[taskId] = append(m.waiters[taskId], taskWaiter)
+	if !m.polling {
+		m.polling = true
+		go m.poll()
+	}
+	m.mutex.Unlock()
+
+	taskResult := <-taskWaiter.done
+	return taskResult.status, taskResult.err
+}
+
+func (m *Monitor) poll() {
+	interval := m.MinInterval
+	failures := 0
+
+	for {
+		taskIds := m.taskIds()
+
+		if len(taskIds) == 0 {
+			return
+		}
+
+		statuses, err := m.fetch(taskIds)
+
+		if err != nil {
+			failures++
+			if failures >= MaxFetchFailures {
+				m.failAll(err)
+				failures = 0
+			}
+		} else {
+			failures = 0
+			if m.update(taskIds, statuses) {
+				interval = m.MinInterval
+			} else {
+				interval = min(interval*2, m.MaxInterval)
+			}
+		}
+
+		m.expire()
+
+		time.Sleep(interval)
+	}
+}
+
+// taskIds returns the IDs of the tasks being waited on, and stops polling if there are none
+func (m *Monitor) taskIds() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	taskIds := make([]string, 0, len(m.waiters))
+	for taskId := range m.waiters {
+		taskIds = append(taskIds, taskId)
+	}
+
+	if len(taskIds) == 0 {
+		m.polling = false
+	}
+
+	return taskIds
+}
+
+// update passes the statuses to the waiters, returning true if any task changed state. The callbacks and results
+// are delivered after the lock is released, so a callback can call Wait, and a slow callback does not block
+// other calls to Wait.
+func (m *Monitor) update(taskIds []string, statuses map[string]Status) bool {
+	changed, notifications := m.collectUpdates(taskIds, statuses)
+
+	for _, notification := range notifications {
+		notification()
+	}
+
+	return changed
+}
+
+// collectUpdates removes the completed waiters, and returns the callbacks and results to deliver in order
+func (m *Monitor) collectUpdates(taskIds []string, statuses map[string]Status) (bool, []func()) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	changed := false
+	notifications := []func(){}
+
+	for _, taskId := range taskIds {
+		status, ok := statuses[taskId]
+
+		for _, taskWaiter := range m.waiters[taskId] {
+			if !ok {
+				notifications = append(notifications, func() { taskWaiter.done <- result{err: ErrTaskNotFound} })
+				continue
+			}
+
+			if status.State != taskWaiter.lastState {
+				changed = true
+				taskWaiter.lastState = status.State
+				if taskWaiter.callback != nil {
+					notifications = append(notifications, func() { taskWaiter.callback(status.State) })
+				}
+			}
+
+			if status.IsCompleted {
+				notifications = append(notifications, func() { taskWaiter.done <- result{status: status} })
+			}
+		}
+
+		if !ok || status.IsCompleted {
+			delete(m.waiters, taskId)
+		}
+	}
+
+	return changed, notifications
+}
+
+// expire fails the waiters that have passed their deadline
+func (m *Monitor) expire() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+
+	for taskId, taskWaiters := range m.waiters {
+		remaining := []*waiter{}
+
+		for _, taskWaiter := range taskWaiters {
+			if now.After(taskWaiter.deadline) {
+				taskWaiter.done <- result{err: ErrTimeout}
+			} else {
+				remaining = append(remaining, taskWaiter)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(m.waiters, taskId)
+		} else {
+			m.waiters[taskId] = remaining
+		}
+	}
+}
+
+// failAll fails every waiter with the error returned by the query
+func (m *Monitor) failAll(err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for taskId, taskWaiters := range m.waiters {
+		for _, taskWaiter := range taskWaiters {
+			taskWaiter.done <- result{err: err}
+		}
+
+		delete(m.waiters, taskId)
+	}
+}
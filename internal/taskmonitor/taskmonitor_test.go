@@ -0,0 +1,155 @@
+package taskmonitor
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestMonitor(fetch Fetch, timeout time.Duration) *Monitor {
+	monitor := New(fetch, timeout)
+	monitor.MinInterval = time.Millisecond
+	monitor.MaxInterval = 5 * time.Millisecond
+	return monitor
+}
+
+func TestWaitQueriesTasksTogether(t *testing.T) {
+	var polls atomic.Int32
+	var maxBatch atomic.Int32
+
+	monitor := newTestMonitor(func(taskIds []string) (map[string]Status, error) {
+		poll := polls.Add(1)
+		if int32(len(taskIds)) > maxBatch.Load() {
+			maxBatch.Store(int32(len(taskIds)))
+		}
+
+		statuses := map[string]Status{}
+		for _, taskId := range taskIds {
+			// Every task completes after a few polls, and the last task fails
+			completed := poll > 5
+			state := "Executing"
+			if completed {
+				state = "Success"
+				if taskId == "ServerTasks-3" {
+					state = "Failed"
+				}
+			}
+			statuses[taskId] = Status{State: state, IsCompleted: completed}
+		}
+		return statuses, nil
+	}, time.Minute)
+
+	var wg sync.WaitGroup
+	results := make([]Status, 3)
+	for index, taskId := range []string{"ServerTasks-1", "ServerTasks-2", "ServerTasks-3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := monitor.Wait(taskId, nil)
+			if err != nil {
+				t.Error(err)
+			}
+			results[index] = status
+		}()
+	}
+	wg.Wait()
+
+	if maxBatch.Load() < 2 {
+		t.Fatalf("expected tasks to be queried together, the largest query had %d tasks", maxBatch.Load())
+	}
+
+	if results[0].State != "Success" || results[2].State != "Failed" || !results[2].IsCompleted {
+		t.Fatalf("unexpected results %+v", results)
+	}
+}
+
+func TestWaitReportsStateChanges(t *testing.T) {
+	states := []string{"Queued", "Queued", "Executing", "Executing", "Success"}
+	var poll atomic.Int32
+
+	monitor := newTestMonitor(func(taskIds []string) (map[string]Status, error) {
+		state := states[min(int(poll.Add(1))-1, len(states)-1)]
+		return map[string]Status{taskIds[0]: {State: state, IsCompleted: state == "Success"}}, nil
+	}, time.Minute)
+
+	reported := []string{}
+	if _, err := monitor.Wait("ServerTasks-1", func(state string) {
+		reported = append(reported, state)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reported) != 3 || reported[0] != "Queued" || reported[1] != "Executing" || reported[2] != "Success" {
+		t.Fatalf("unexpected states %v", reported)
+	}
+}
+
+func TestWaitErrors(t *testing.T) {
+	notFound := newTestMonitor(func(taskIds []string) (map[string]Status, error) {
+		return map[string]Status{}, nil
+	}, time.Minute)
+
+	if _, err := notFound.Wait("ServerTasks-1", nil); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("expected ErrTaskNotFound, got %v", err)
+	}
+
+	timeout := newTestMonitor(func(taskIds []string) (map[string]Status, error) {
+		return map[string]Status{taskIds[0]: {State: "Executing"}}, nil
+	}, 20*time.Millisecond)
+
+	if _, err := timeout.Wait("ServerTasks-1", nil); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected ErrTimeout, got %v", err)
+	}
+
+	fetchError := errors.New("server unavailable")
+	failing := newTestMonitor(func(taskIds []string) (map[string]Status, error) {
+		return nil, fetchError
+	}, time.Minute)
+
+	if _, err := failing.Wait("ServerTasks-1", nil); !errors.Is(err, fetchError) {
+		t.Fatalf("expected the fetch error, got %v", err)
+	}
+}
+
+func TestWaitCallbacksRunWithoutTheLock(t *testing.T) {
+	var poll atomic.Int32
+
+	monitor := newTestMonitor(func(taskIds []string) (map[string]Status, error) {
+		completed := poll.Add(1) > 2
+		statuses := map[string]Status{}
+		for _, taskId := range taskIds {
+			statuses[taskId] = Status{State: "Executing", IsCompleted: completed}
+		}
+		return statuses, nil
+	}, time.Minute)
+
+	done := make(chan error, 1)
+	if _, err := monitor.Wait("ServerTasks-1", func(state string) {
+		// A callback can wait on another task while the first task is being polled
+		go func() {
+			_, err := monitor.Wait("ServerTasks-2", nil)
+			done <- err
+		}()
+
+		locked := make(chan bool)
+		go func() {
+			monitor.mutex.Lock()
+			monitor.mutex.Unlock()
+			close(locked)
+		}()
+
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			t.Error("the monitor was locked while the callback was called")
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package terraformstate
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
+)
+
+// azureWorkspaceSeparator separates the key and workspace in the name of a blob. The azurerm backend saves the
+// state of a workspace other than the default to <key>env:<workspace>.
+const azureWorkspaceSeparator = "env:"
+
+type azureStore struct {
+	client    *azblob.Client
+	container string
+}
+
+// NewAzureStore returns the store for the Terraform state saved in an Azure storage container
+func NewAzureStore(state state.State) (Store, error) {
+	client, err := validators.NewAzureBlobClient(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return azureStore{client: client, container: state.AzureContainerName}, nil
+}
+
+func (s azureStore) List() ([]Object, error) {
+	objects := []Object{}
+	pager := s.client.NewListBlobsFlatPager(s.container, nil)
+
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to list the blobs in the container "+s.container), err)
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+
+			object, ok := parseAzurePath(*item.Name)
+			if !ok {
+				continue
+			}
+
+			if item.Properties != nil {
+				if item.Properties.ContentLength != nil {
+					object.Size = *item.Properties.ContentLength
+				}
+
+				if item.Properties.LastModified != nil {
+					object.LastModified = *item.Properties.LastModified
+				}
+			}
+
+			objects = append(objects, object)
+		}
+	}
+
+	sortObjects(objects)
+	return objects, nil
+}
+
+// Archive downloads and uploads the state rather than copying it on the server, as a server side copy of
+// a private blob requires a shared access signature. State files are small enough to be held in memory.
+func (s azureStore) Archive(object Object) error {
+	destination := archivePath(object, time.Now())
+
+	download, err := s.client.DownloadStream(context.Background(), s.container, object.Path, nil)
+	if err != nil {
+		return errors.Join(errors.New("failed to download "+object.Path), err)
+	}
+
+	defer download.Body.Close()
+
+	content, err := io.ReadAll(download.Body)
+	if err != nil {
+		return errors.Join(errors.New("failed to download "+object.Path), err)
+	}
+
+	if _, err := s.client.UploadBuffer(context.Background(), s.container, destination, content, nil); err != nil {
+		return errors.Join(errors.New("failed to copy "+object.Path+" to "+destination), err)
+	}
+
+	return s.Delete(object)
+}
+
+func (s azureStore) Delete(object Object) error {
+	if _, err := s.client.DeleteBlob(context.Background(), s.container, object.Path, nil); err != nil {
+		return errors.Join(errors.New("failed to delete "+object.Path), err)
+	}
+
+	return nil
+}
+
+// parseAzurePath extracts the workspace and key from the name of a blob in the container
+func parseAzurePath(path string) (Object, bool) {
+	key, workspace, found := strings.Cut(path, azureWorkspaceSeparator)
+	if !found {
+		return Object{Path: path, Key: path, Workspace: "default"}, isStateKey(path)
+	}
+
+	if workspace == "" {
+		return Object{}, false
+	}
+
+	return Object{Path: path, Key: key, Workspace: workspace}, isStateKey(key)
+}
@@ -0,0 +1,153 @@
+package terraformstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
+)
+
+// gcsStateSuffix is the suffix of the objects holding the state. The gcs backend saves the state of each workspace
+// to <prefix>/<workspace>.tfstate, and the runbooks use the key as the prefix.
+const gcsStateSuffix = ".tfstate"
+
+type gcsStore struct {
+	client  *http.Client
+	baseUrl string
+	bucket  string
+}
+
+type gcsObjectList struct {
+	Items []struct {
+		Name    string `json:"name"`
+		Size    string `json:"size"`
+		Updated string `json:"updated"`
+	} `json:"items"`
+	NextPageToken string `json:"nextPageToken"`
+}
+
+// NewGcsStore returns the store for the Terraform state saved in a Google Cloud Storage bucket
+func NewGcsStore(state state.State) (Store, error) {
+	client, err := validators.NewGoogleStorageClient(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcsStore{client: client, baseUrl: validators.GoogleStorageUrl, bucket: strings.TrimSpace(state.GoogleCloudBucket)}, nil
+}
+
+func (s gcsStore) List() ([]Object, error) {
+	objects := []Object{}
+	pageToken := ""
+
+	for {
+		query := url.Values{}
+		if pageToken != "" {
+			query.Set("pageToken", pageToken)
+		}
+
+		list := gcsObjectList{}
+		if err := s.request(http.MethodGet, s.bucketUrl()+"/o?"+query.Encode(), &list); err != nil {
+			return nil, errors.Join(errors.New("failed to list the objects in the bucket "+s.bucket), err)
+		}
+
+		for _, item := range list.Items {
+			object, ok := parseGcsPath(item.Name)
+			if !ok {
+				continue
+			}
+
+			object.Size, _ = strconv.ParseInt(item.Size, 10, 64)
+			object.LastModified, _ = time.Parse(time.RFC3339, item.Updated)
+			objects = append(objects, object)
+		}
+
+		if list.NextPageToken == "" {
+			break
+		}
+
+		pageToken = list.NextPageToken
+	}
+
+	sortObjects(objects)
+	return objects, nil
+}
+
+func (s gcsStore) Archive(object Object) error {
+	destination := archivePath(object, time.Now())
+
+	if err := s.request(http.MethodPost, s.objectUrl(object.Path)+"/copyTo/b/"+url.PathEscape(s.bucket)+"/o/"+url.PathEscape(destination), nil); err != nil {
+		return errors.Join(errors.New("failed to copy "+object.Path+" to "+destination), err)
+	}
+
+	return s.Delete(object)
+}
+
+func (s gcsStore) Delete(object Object) error {
+	if err := s.request(http.MethodDelete, s.objectUrl(object.Path), nil); err != nil {
+		return errors.Join(errors.New("failed to delete "+object.Path), err)
+	}
+
+	return nil
+}
+
+func (s gcsStore) bucketUrl() string {
+	return s.baseUrl + "/b/" + url.PathEscape(s.bucket)
+}
+
+func (s gcsStore) objectUrl(path string) string {
+	return s.bucketUrl() + "/o/" + url.PathEscape(path)
+}
+
+// request calls the JSON API, decoding the response into the result if it is not nil
+func (s gcsStore) request(method string, requestUrl string, result any) error {
+	req, err := http.NewRequest(method, requestUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("status code %d: %s", res.StatusCode, string(body))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// parseGcsPath extracts the workspace and key from the name of an object in the bucket
+func parseGcsPath(path string) (Object, bool) {
+	key, file, found := strings.Cut(path, "/")
+	if !found || !strings.HasSuffix(file, gcsStateSuffix) || strings.Contains(file, "/") {
+		return Object{}, false
+	}
+
+	workspace := strings.TrimSuffix(file, gcsStateSuffix)
+	if workspace == "" {
+		return Object{}, false
+	}
+
+	return Object{Path: path, Key: key, Workspace: workspace}, isStateKey(key)
+}
@@ -0,0 +1,175 @@
+package terraformstate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+)
+
+// postgresSchemaPrefix is the prefix of the schema_name configured by the runbooks for the space and each project.
+// The pg backend saves the state of each workspace to a row in the states table of the schema.
+const postgresSchemaPrefix = "project_"
+
+type postgresStore struct {
+	connectionString string
+}
+
+// NewPostgresStore returns the store for the Terraform state saved in a PostgreSQL database
+func NewPostgresStore(state state.State) (Store, error) {
+	connectionString := strings.TrimSpace(state.PostgresConnectionString)
+	if connectionString == "" {
+		return nil, errors.New("the PostgreSQL connection string is required")
+	}
+
+	return postgresStore{connectionString: connectionString}, nil
+}
+
+// List returns the state in each schema created by the runbooks. The pg backend does not record when the state
+// was modified, so the LastModified time is not set.
+func (s postgresStore) List() ([]Object, error) {
+	objects := []Object{}
+
+	err := s.withDatabase(func(ctx context.Context, db *sql.DB) error {
+		schemas, err := s.listSchemas(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		for _, schema := range schemas {
+			schemaObjects, err := s.listSchema(ctx, db, schema)
+			if err != nil {
+				return err
+			}
+
+			objects = append(objects, schemaObjects...)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sortObjects(objects)
+	return objects, nil
+}
+
+// listSchemas returns the schemas created by the pg backend for the runbooks
+func (s postgresStore) listSchemas(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT table_schema FROM information_schema.tables WHERE table_name = 'states' AND table_schema LIKE $1`, postgresSchemaPrefix+"%")
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to list the schemas in the database"), err)
+	}
+
+	defer rows.Close()
+
+	schemas := []string{}
+	for rows.Next() {
+		schema := ""
+		if err := rows.Scan(&schema); err != nil {
+			return nil, errors.Join(errors.New("failed to list the schemas in the database"), err)
+		}
+
+		schemas = append(schemas, schema)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Join(errors.New("failed to list the schemas in the database"), err)
+	}
+
+	return schemas, nil
+}
+
+func (s postgresStore) listSchema(ctx context.Context, db *sql.DB, schema string) ([]Object, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name, length(data) FROM `+pq.QuoteIdentifier(schema)+`.states`)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to list the state in the schema "+schema), err)
+	}
+
+	defer rows.Close()
+
+	objects := []Object{}
+	for rows.Next() {
+		workspace := ""
+		size := sql.NullInt64{}
+		if err := rows.Scan(&workspace, &size); err != nil {
+			return nil, errors.Join(errors.New("failed to list the state in the schema "+schema), err)
+		}
+
+		object, ok := parsePostgresState(schema, workspace)
+		if !ok {
+			continue
+		}
+
+		object.Size = size.Int64
+		objects = append(objects, object)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Join(errors.New("failed to list the state in the schema "+schema), err)
+	}
+
+	return objects, nil
+}
+
+// Archive renames the workspace of the state, as the rows of the states table can not be moved to a folder.
+// The archived workspace starts with the ArchivePrefix, so it is not listed as a state file.
+func (s postgresStore) Archive(object Object) error {
+	destination := archivePath(Object{Path: object.Workspace}, time.Now())
+
+	if err := s.exec(`UPDATE `+pq.QuoteIdentifier(object.Key)+`.states SET name = $1 WHERE name = $2`, destination, object.Workspace); err != nil {
+		return errors.Join(errors.New("failed to rename "+object.Path+" to "+destination), err)
+	}
+
+	return nil
+}
+
+func (s postgresStore) Delete(object Object) error {
+	if err := s.exec(`DELETE FROM `+pq.QuoteIdentifier(object.Key)+`.states WHERE name = $1`, object.Workspace); err != nil {
+		return errors.Join(errors.New("failed to delete "+object.Path), err)
+	}
+
+	return nil
+}
+
+func (s postgresStore) exec(query string, args ...any) error {
+	return s.withDatabase(func(ctx context.Context, db *sql.DB) error {
+		_, err := db.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+// withDatabase opens a connection to the database for the duration of the function
+func (s postgresStore) withDatabase(action func(ctx context.Context, db *sql.DB) error) error {
+	db, err := sql.Open("postgres", s.connectionString)
+	if err != nil {
+		return errors.Join(errors.New("failed to open the database connection"), err)
+	}
+
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Println(err.Error())
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	return action(ctx, db)
+}
+
+// parsePostgresState returns the state saved to a workspace in a schema created by the runbooks
+func parsePostgresState(schema string, workspace string) (Object, bool) {
+	if !strings.HasPrefix(schema, postgresSchemaPrefix) || workspace == "" || strings.HasPrefix(workspace, ArchivePrefix) {
+		return Object{}, false
+	}
+
+	return Object{Path: schema + "/" + workspace, Key: schema, Workspace: workspace}, true
+}
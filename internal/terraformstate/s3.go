@@ -0,0 +1,100 @@
+package terraformstate
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"github.com/mcasperson/OctoterraWizard/internal/validators"
+)
+
+// s3WorkspacePrefix is the default workspace_key_prefix of the S3 backend. The state of a workspace other than
+// the default is saved to env:/<workspace>/<key>.
+const s3WorkspacePrefix = "env:/"
+
+type s3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Store returns the store for the Terraform state saved in an S3 bucket
+func NewS3Store(state state.State) (Store, error) {
+	client, err := validators.NewS3Client(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3Store{client: client, bucket: strings.TrimSpace(state.AwsS3Bucket)}, nil
+}
+
+func (s s3Store) List() ([]Object, error) {
+	objects := []Object{}
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.Background())
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to list the objects in the bucket "+s.bucket), err)
+		}
+
+		for _, item := range page.Contents {
+			object, ok := parseS3Path(aws.ToString(item.Key))
+			if !ok {
+				continue
+			}
+
+			object.Size = aws.ToInt64(item.Size)
+			object.LastModified = aws.ToTime(item.LastModified)
+			objects = append(objects, object)
+		}
+	}
+
+	sortObjects(objects)
+	return objects, nil
+}
+
+func (s s3Store) Archive(object Object) error {
+	destination := archivePath(object, time.Now())
+
+	if _, err := s.client.CopyObject(context.Background(), &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(destination),
+		CopySource: aws.String(s.bucket + "/" + url.PathEscape(object.Path)),
+	}); err != nil {
+		return errors.Join(errors.New("failed to copy "+object.Path+" to "+destination), err)
+	}
+
+	return s.Delete(object)
+}
+
+func (s s3Store) Delete(object Object) error {
+	if _, err := s.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(object.Path),
+	}); err != nil {
+		return errors.Join(errors.New("failed to delete "+object.Path), err)
+	}
+
+	return nil
+}
+
+// parseS3Path extracts the workspace and key from the path of an object in the bucket
+func parseS3Path(path string) (Object, bool) {
+	if !strings.HasPrefix(path, s3WorkspacePrefix) {
+		return Object{Path: path, Key: path, Workspace: "default"}, isStateKey(path)
+	}
+
+	workspace, key, found := strings.Cut(strings.TrimPrefix(path, s3WorkspacePrefix), "/")
+	if !found || workspace == "" {
+		return Object{}, false
+	}
+
+	return Object{Path: path, Key: key, Workspace: workspace}, isStateKey(key)
+}
@@ -0,0 +1,98 @@
+// Package terraformstate lists, archives, and deletes the Terraform state saved by the Octoterra runbooks,
+// allowing a space to be migrated again from a clean state.
+package terraformstate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArchivePrefix is the prefix of the path that archived state files are copied to. Archived files are
+// not listed as state files.
+const ArchivePrefix = "octoterra-archive/"
+
+// ErrUnsupportedBackend is returned when the state saved in a backend can not be managed
+var ErrUnsupportedBackend = errors.New("the Terraform state can not be listed for this backend")
+
+// keyPrefix is the prefix of the backend key configured by the runbooks for the space and each project
+const keyPrefix = "Project_"
+
+// Object is a Terraform state file saved by the Octoterra runbooks
+type Object struct {
+	// Path is the name of the object in the bucket or container, or the schema and workspace of the PostgreSQL state
+	Path string
+	// Key is the backend key, prefix, or schema configured by the runbooks, like Project_Web_App
+	Key string
+	// Workspace is the Terraform workspace, which the runbooks set to the ID of the destination space
+	Workspace    string
+	Size         int64
+	LastModified time.Time
+}
+
+// Store is the storage holding the Terraform state
+type Store interface {
+	// List returns the state files saved by the Octoterra runbooks, ordered by workspace and key
+	List() ([]Object, error)
+	// Archive copies the state file to the ArchivePrefix and deletes the original
+	Archive(object Object) error
+	// Delete removes the state file
+	Delete(object Object) error
+}
+
+func (o Object) String() string {
+	// Some backends do not record when the state was modified
+	if o.LastModified.IsZero() {
+		return fmt.Sprintf("%s / %s (%s)", o.Workspace, o.Key, formatSize(o.Size))
+	}
+
+	return fmt.Sprintf("%s / %s (%s, modified %s)", o.Workspace, o.Key, formatSize(o.Size), o.LastModified.Local().Format("2006-01-02 15:04"))
+}
+
+// ForWorkspace returns the state files in the workspace. The runbooks use the ID of the destination space as the
+// workspace, so these are the state files reused when a space is migrated again.
+func ForWorkspace(objects []Object, workspace string) []Object {
+	filtered := []Object{}
+	for _, object := range objects {
+		if object.Workspace == workspace {
+			filtered = append(filtered, object)
+		}
+	}
+
+	return filtered
+}
+
+// archivePath returns the path an object is copied to when it is archived. The time is included so the same
+// state can be archived many times.
+func archivePath(object Object, archived time.Time) string {
+	return ArchivePrefix + archived.UTC().Format("20060102T150405Z") + "/" + object.Path
+}
+
+// isStateKey returns true if the key was configured by the Octoterra runbooks. Lock files saved
+// alongside the state are excluded.
+func isStateKey(key string) bool {
+	return strings.HasPrefix(key, keyPrefix) && !strings.Contains(key, "/") && !strings.HasSuffix(key, ".tflock")
+}
+
+func sortObjects(objects []Object) {
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Workspace != objects[j].Workspace {
+			return objects[i].Workspace < objects[j].Workspace
+		}
+
+		return objects[i].Key < objects[j].Key
+	})
+}
+
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
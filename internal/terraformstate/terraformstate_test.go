@@ -0,0 +1,202 @@
+package terraformstate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+)
+
+func TestParsePaths(t *testing.T) {
+	s3Paths := map[string]Object{
+		"env:/Spaces-2/Project_Web_App": {Path: "env:/Spaces-2/Project_Web_App", Key: "Project_Web_App", Workspace: "Spaces-2"},
+		"Project_Web_App":               {Path: "Project_Web_App", Key: "Project_Web_App", Workspace: "default"},
+	}
+
+	for path, expected := range s3Paths {
+		if object, ok := parseS3Path(path); !ok || object != expected {
+			t.Fatalf("expected %+v for %s, got %+v", expected, path, object)
+		}
+	}
+
+	azurePaths := map[string]Object{
+		"Project_Web_Appenv:Spaces-2": {Path: "Project_Web_Appenv:Spaces-2", Key: "Project_Web_App", Workspace: "Spaces-2"},
+		"Project_Web_App":             {Path: "Project_Web_App", Key: "Project_Web_App", Workspace: "default"},
+	}
+
+	for path, expected := range azurePaths {
+		if object, ok := parseAzurePath(path); !ok || object != expected {
+			t.Fatalf("expected %+v for %s, got %+v", expected, path, object)
+		}
+	}
+
+	for _, path := range []string{"env:/Spaces-2/terraform.tfstate", "env:/Spaces-2/Project_Web_App.tflock", "octoterra-archive/20240102T030405Z/env:/Spaces-2/Project_Web_App", "env:/"} {
+		if _, ok := parseS3Path(path); ok {
+			t.Fatalf("expected %s to be ignored", path)
+		}
+	}
+
+	gcsPaths := map[string]Object{
+		"Project_Web_App/Spaces-2.tfstate": {Path: "Project_Web_App/Spaces-2.tfstate", Key: "Project_Web_App", Workspace: "Spaces-2"},
+		"Project_Web_App/default.tfstate":  {Path: "Project_Web_App/default.tfstate", Key: "Project_Web_App", Workspace: "default"},
+	}
+
+	for path, expected := range gcsPaths {
+		if object, ok := parseGcsPath(path); !ok || object != expected {
+			t.Fatalf("expected %+v for %s, got %+v", expected, path, object)
+		}
+	}
+
+	for _, path := range []string{"Project_Web_App/Spaces-2.tflock", "Project_Web_App/.tfstate", "Project_Web_App.tfstate", "octoterra-archive/20240102T030405Z/Project_Web_App/Spaces-2.tfstate", "state/Spaces-2.tfstate"} {
+		if _, ok := parseGcsPath(path); ok {
+			t.Fatalf("expected %s to be ignored", path)
+		}
+	}
+
+	if object, ok := parsePostgresState("project_web_app", "Spaces-2"); !ok || object != (Object{Path: "project_web_app/Spaces-2", Key: "project_web_app", Workspace: "Spaces-2"}) {
+		t.Fatalf("unexpected PostgreSQL state %+v", object)
+	}
+
+	for _, schemaAndWorkspace := range [][]string{{"public", "Spaces-2"}, {"project_web_app", "octoterra-archive/20240102T030405Z/Spaces-2"}, {"project_web_app", ""}} {
+		if _, ok := parsePostgresState(schemaAndWorkspace[0], schemaAndWorkspace[1]); ok {
+			t.Fatalf("expected %v to be ignored", schemaAndWorkspace)
+		}
+	}
+
+	for _, path := range []string{"terraform.tfstateenv:Spaces-2", "Project_Web_Appenv:", "octoterra-archive/20240102T030405Z/Project_Web_Appenv:Spaces-2"} {
+		if _, ok := parseAzurePath(path); ok {
+			t.Fatalf("expected %s to be ignored", path)
+		}
+	}
+}
+
+func TestForWorkspace(t *testing.T) {
+	objects := []Object{
+		{Path: "env:/Spaces-2/Project_Web_App", Key: "Project_Web_App", Workspace: "Spaces-2"},
+		{Path: "env:/Spaces-3/Project_Web_App", Key: "Project_Web_App", Workspace: "Spaces-3"},
+	}
+
+	filtered := ForWorkspace(objects, "Spaces-3")
+	if len(filtered) != 1 || filtered[0] != objects[1] {
+		t.Fatalf("expected only the state in the Spaces-3 workspace, got %+v", filtered)
+	}
+
+	if formatted := (Object{Key: "project_web_app", Workspace: "Spaces-2", Size: 100}).String(); formatted != "Spaces-2 / project_web_app (100 B)" {
+		t.Fatalf("expected the modified time to be omitted when it is not known, got %s", formatted)
+	}
+
+	sizes := map[int64]string{100: "100 B", 2048: "2.0 KB", 3 * 1024 * 1024: "3.0 MB"}
+	for size, expected := range sizes {
+		if formatted := formatSize(size); formatted != expected {
+			t.Fatalf("expected %s for %d, got %s", expected, size, formatted)
+		}
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		switch {
+		case r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+	<Name>state</Name>
+	<IsTruncated>false</IsTruncated>
+	<Contents><Key>env:/Spaces-3/Project_Web_App</Key><Size>200</Size><LastModified>2024-01-02T03:04:05.000Z</LastModified></Contents>
+	<Contents><Key>env:/Spaces-2/Project_Web_App</Key><Size>100</Size><LastModified>2024-01-02T03:04:05.000Z</LastModified></Contents>
+	<Contents><Key>octoterra-archive/20240101T000000Z/env:/Spaces-2/Project_Web_App</Key><Size>100</Size><LastModified>2024-01-01T00:00:00.000Z</LastModified></Contents>
+	<Contents><Key>unrelated.txt</Key><Size>1</Size><LastModified>2024-01-01T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`))
+		case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte(`<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	store, err := NewS3Store(state.State{
+		AwsAccessKey:      "minio",
+		AwsSecretKey:      "minio123",
+		AwsS3Bucket:       "state",
+		AwsS3BucketRegion: "us-east-1",
+		AwsS3Endpoint:     server.URL,
+		AwsS3UsePathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 2 || objects[0].Workspace != "Spaces-2" || objects[0].Size != 100 || objects[1].Workspace != "Spaces-3" {
+		t.Fatalf("expected the state files sorted by workspace, got %+v", objects)
+	}
+
+	if err := store.Archive(objects[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 3 || !strings.HasPrefix(requests[1], "PUT /state/octoterra-archive/") || requests[2] != "DELETE /state/env:/Spaces-2/Project_Web_App" {
+		t.Fatalf("expected the state to be copied and deleted, got %v", requests)
+	}
+}
+
+func TestGcsStore(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("pageToken") == "":
+			_, _ = w.Write([]byte(`{"nextPageToken": "page2", "items": [
+				{"name": "Project_Web_App/Spaces-3.tfstate", "size": "200", "updated": "2024-01-02T03:04:05.000Z"},
+				{"name": "Project_Web_App/Spaces-3.tflock", "size": "10", "updated": "2024-01-02T03:04:05.000Z"}
+			]}`))
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"items": [
+				{"name": "Project_Web_App/Spaces-2.tfstate", "size": "100", "updated": "2024-01-02T03:04:05.000Z"},
+				{"name": "octoterra-archive/20240101T000000Z/Project_Web_App/Spaces-2.tfstate", "size": "100", "updated": "2024-01-01T00:00:00.000Z"}
+			]}`))
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	store := gcsStore{client: server.Client(), baseUrl: server.URL, bucket: "state"}
+
+	objects, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(objects) != 2 || objects[0].Workspace != "Spaces-2" || objects[0].Size != 100 || objects[0].LastModified.IsZero() || objects[1].Workspace != "Spaces-3" {
+		t.Fatalf("expected the state files from both pages sorted by workspace, got %+v", objects)
+	}
+
+	if err := store.Archive(objects[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 4 ||
+		!strings.HasPrefix(requests[2], "POST /b/state/o/Project_Web_App%2FSpaces-2.tfstate/copyTo/b/state/o/octoterra-archive%2F") ||
+		requests[3] != "DELETE /b/state/o/Project_Web_App%2FSpaces-2.tfstate" {
+		t.Fatalf("expected the state to be copied and deleted, got %v", requests)
+	}
+}
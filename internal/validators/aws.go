@@ -1,15 +1,22 @@
 package validators
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"os"
 	"strings"
 	"time"
 )
 
+// DefaultRoleSessionName is the session name used when assuming a role if no session name is defined
+const DefaultRoleSessionName = "octoterrawiz"
+
 type CustomCredentials struct {
 	State state.State
 }
@@ -26,11 +33,15 @@ func (c CustomCredentials) Retrieve(ctx context.Context) (aws.Credentials, error
 	}, nil
 }
 
+// ValidateAWS confirms the credentials are valid with the AWS security token service.
+// S3 compatible storage services rarely implement the security token service, so when a custom endpoint
+// is defined the credentials are validated by TestS3Bucket instead.
 func ValidateAWS(state state.State) error {
-	cfg, err := config.LoadDefaultConfig(
-		context.Background(),
-		config.WithCredentialsProvider(CustomCredentials{state}),
-		config.WithRegion(strings.TrimSpace(state.AwsS3BucketRegion)))
+	if strings.TrimSpace(state.AwsS3Endpoint) != "" {
+		return nil
+	}
+
+	cfg, err := awsConfig(state)
 	if err != nil {
 		return err
 	}
@@ -44,3 +55,69 @@ func ValidateAWS(state state.State) error {
 
 	return nil
 }
+
+// awsConfig builds the AWS configuration from the state, trusting the certificate authorities in the
+// custom CA bundle if one is defined.
+// The base credentials come from the named profile, which supports SSO profiles, or the access key.
+// If neither is defined, the default credential chain is used. When a role ARN is defined, the base
+// credentials are used to assume the role, or the web identity token is exchanged for the role credentials.
+func awsConfig(state state.State) (aws.Config, error) {
+	options := []func(*config.LoadOptions) error{
+		config.WithRegion(strings.TrimSpace(state.AwsS3BucketRegion)),
+	}
+
+	if profile := strings.TrimSpace(state.AwsProfile); profile != "" {
+		options = append(options, config.WithSharedConfigProfile(profile))
+	} else if strings.TrimSpace(state.AwsAccessKey) != "" {
+		options = append(options, config.WithCredentialsProvider(CustomCredentials{state}))
+	}
+
+	if caBundlePath := strings.TrimSpace(state.AwsCaBundle); caBundlePath != "" {
+		caBundle, err := os.ReadFile(caBundlePath)
+		if err != nil {
+			return aws.Config{}, errors.Join(errors.New("failed to read the CA bundle "+caBundlePath), err)
+		}
+
+		options = append(options, config.WithCustomCABundle(bytes.NewReader(caBundle)))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	roleArn := strings.TrimSpace(state.AwsRoleArn)
+	if roleArn == "" {
+		return cfg, nil
+	}
+
+	sessionName := strings.TrimSpace(state.AwsRoleSessionName)
+	if sessionName == "" {
+		sessionName = DefaultRoleSessionName
+	}
+
+	simpleTokenService := sts.NewFromConfig(cfg)
+
+	if tokenFile := strings.TrimSpace(state.AwsWebIdentityTokenFile); tokenFile != "" {
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(
+			simpleTokenService,
+			roleArn,
+			stscreds.IdentityTokenFile(tokenFile),
+			func(options *stscreds.WebIdentityRoleOptions) {
+				options.RoleSessionName = sessionName
+			}))
+		return cfg, nil
+	}
+
+	cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(
+		simpleTokenService,
+		roleArn,
+		func(options *stscreds.AssumeRoleOptions) {
+			options.RoleSessionName = sessionName
+			if externalId := strings.TrimSpace(state.AwsRoleExternalId); externalId != "" {
+				options.ExternalID = aws.String(externalId)
+			}
+		}))
+
+	return cfg, nil
+}
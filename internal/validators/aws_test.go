@@ -1,8 +1,13 @@
 package validators
 
 import (
+	"fmt"
 	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -40,3 +45,99 @@ func TestAWSCredsExists(t *testing.T) {
 		t.Fatalf("Error checking aws creds: %v", err)
 	}
 }
+
+// fakeTokenService mimics the AWS security token service, issuing role credentials and returning the
+// caller identity only to requests signed with those credentials.
+func fakeTokenService(t *testing.T, baseAccessKey string, externalId string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+
+		credentials := `<Credentials><AccessKeyId>ASIAASSUMED</AccessKeyId><SecretAccessKey>secret</SecretAccessKey>` +
+			`<SessionToken>token</SessionToken><Expiration>2099-01-01T00:00:00Z</Expiration></Credentials>`
+
+		switch r.Form.Get("Action") {
+		case "AssumeRole":
+			if !strings.Contains(r.Header.Get("Authorization"), "Credential="+baseAccessKey+"/") ||
+				r.Form.Get("ExternalId") != externalId ||
+				r.Form.Get("RoleSessionName") != "migration" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			fmt.Fprint(w, `<AssumeRoleResponse><AssumeRoleResult>`+credentials+`</AssumeRoleResult></AssumeRoleResponse>`)
+		case "AssumeRoleWithWebIdentity":
+			if r.Form.Get("WebIdentityToken") != "web-identity-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult>`+credentials+`</AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`)
+		case "GetCallerIdentity":
+			if !strings.Contains(r.Header.Get("Authorization"), "Credential=ASIAASSUMED/") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			fmt.Fprint(w, `<GetCallerIdentityResponse><GetCallerIdentityResult><Account>123456789012</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func TestAWSAssumeRole(t *testing.T) {
+	server := fakeTokenService(t, "AKIAPROFILE", "external-id")
+	defer server.Close()
+
+	configFile := filepath.Join(t.TempDir(), "config")
+	profile := "[profile migration]\naws_access_key_id = AKIAPROFILE\naws_secret_access_key = secret\n"
+	if err := os.WriteFile(configFile, []byte(profile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	testState := state.State{
+		AwsS3BucketRegion:  "us-east-1",
+		AwsProfile:         "migration",
+		AwsRoleArn:         "arn:aws:iam::123456789012:role/terraform-state",
+		AwsRoleSessionName: "migration",
+		AwsRoleExternalId:  "external-id",
+	}
+
+	if err := ValidateAWS(testState); err != nil {
+		t.Fatalf("Error assuming the role: %v", err)
+	}
+
+	testState.AwsRoleExternalId = "wrong"
+	if err := ValidateAWS(testState); err == nil {
+		t.Fatal("expected an error for an invalid external ID")
+	}
+}
+
+func TestAWSWebIdentity(t *testing.T) {
+	server := fakeTokenService(t, "", "")
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("web-identity-token"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+
+	testState := state.State{
+		AwsS3BucketRegion:       "us-east-1",
+		AwsRoleArn:              "arn:aws:iam::123456789012:role/terraform-state",
+		AwsRoleSessionName:      "migration",
+		AwsWebIdentityTokenFile: tokenFile,
+	}
+
+	if err := ValidateAWS(testState); err != nil {
+		t.Fatalf("Error exchanging the web identity token: %v", err)
+	}
+}
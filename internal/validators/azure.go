@@ -4,20 +4,96 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
 	"io"
+	"os"
+	"strings"
 )
 
-func AzureResourceGroupExists(tenantID, clientID, subscriptionId, clientSecret, resourceGroupName string) (bool, error) {
-	cred, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+// The methods used to authenticate with Azure
+const (
+	AzureAuthClientSecret    = "ClientSecret"
+	AzureAuthCertificate     = "Certificate"
+	AzureAuthOidc            = "OIDC"
+	AzureAuthManagedIdentity = "ManagedIdentity"
+)
+
+var AzureAuthTypes = []string{AzureAuthClientSecret, AzureAuthCertificate, AzureAuthOidc, AzureAuthManagedIdentity}
+
+// AzureAuthType returns the authentication method saved in the state, defaulting to a client secret
+// for states created before other methods were supported.
+func AzureAuthType(state state.State) string {
+	for _, authType := range AzureAuthTypes {
+		if strings.EqualFold(strings.TrimSpace(state.AzureAuthType), authType) {
+			return authType
+		}
+	}
+
+	return AzureAuthClientSecret
+}
+
+// azureCredential builds the credential matching the authentication method in the state.
+func azureCredential(state state.State) (azcore.TokenCredential, error) {
+	switch AzureAuthType(state) {
+	case AzureAuthCertificate:
+		certData, err := os.ReadFile(state.AzureCertificatePath)
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to read the certificate "+state.AzureCertificatePath), err)
+		}
+
+		certs, key, err := azidentity.ParseCertificates(certData, []byte(state.AzureCertificatePassword))
+		if err != nil {
+			return nil, errors.Join(errors.New("failed to parse the certificate "+state.AzureCertificatePath), err)
+		}
+
+		return azidentity.NewClientCertificateCredential(state.AzureTenantId, state.AzureApplicationId, certs, key, nil)
+	case AzureAuthOidc:
+		// The token file is read each time a token is requested, as federated tokens are usually short-lived
+		// and refreshed by the platform that issued them.
+		tokenFile := state.AzureFederatedTokenFile
+		return azidentity.NewClientAssertionCredential(state.AzureTenantId, state.AzureApplicationId, func(ctx context.Context) (string, error) {
+			token, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return "", errors.Join(errors.New("failed to read the federated token "+tokenFile), err)
+			}
+
+			return strings.TrimSpace(string(token)), nil
+		}, nil)
+	case AzureAuthManagedIdentity:
+		options := azidentity.ManagedIdentityCredentialOptions{}
+		// A client ID selects a user-assigned identity. The system-assigned identity is used otherwise.
+		if strings.TrimSpace(state.AzureApplicationId) != "" {
+			options.ID = azidentity.ClientID(strings.TrimSpace(state.AzureApplicationId))
+		}
+
+		return azidentity.NewManagedIdentityCredential(&options)
+	default:
+		return azidentity.NewClientSecretCredential(state.AzureTenantId, state.AzureApplicationId, state.AzurePassword, nil)
+	}
+}
+
+// NewAzureBlobClient returns a client for the storage account holding the Terraform state
+func NewAzureBlobClient(state state.State) (*azblob.Client, error) {
+	cred, err := azureCredential(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential: %w", err)
+	}
+
+	return azblob.NewClient(fmt.Sprintf("https://%s.blob.core.windows.net/", state.AzureStorageAccountName), cred, nil)
+}
+
+func AzureResourceGroupExists(state state.State) (bool, error) {
+	cred, err := azureCredential(state)
 	if err != nil {
 		return false, fmt.Errorf("failed to create credential: %w", err)
 	}
 
-	rgClient, err := armresources.NewResourceGroupsClient(subscriptionId, cred, nil)
+	rgClient, err := armresources.NewResourceGroupsClient(state.AzureSubscriptionId, cred, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to create resource group client: %w", err)
 	}
@@ -31,7 +107,7 @@ func AzureResourceGroupExists(tenantID, clientID, subscriptionId, clientSecret,
 		}
 
 		for _, rg := range results.Value {
-			if rg.Name != nil && *rg.Name == resourceGroupName {
+			if rg.Name != nil && *rg.Name == state.AzureResourceGroupName {
 				return true, nil
 			}
 		}
@@ -40,15 +116,14 @@ func AzureResourceGroupExists(tenantID, clientID, subscriptionId, clientSecret,
 	return false, nil
 }
 
-func AzureContainerExists(tenantID, clientID, clientSecret, accountName, containerName string) (bool, error) {
-	// Create a credential using the service principal
-	cred, err := azidentity.NewClientSecretCredential(tenantID, clientID, clientSecret, nil)
+func AzureContainerExists(state state.State) (bool, error) {
+	cred, err := azureCredential(state)
 	if err != nil {
 		return false, fmt.Errorf("failed to create credential: %w", err)
 	}
 
 	// Create a service client
-	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", accountName)
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", state.AzureStorageAccountName)
 	serviceClient, err := azblob.NewClient(serviceURL, cred, nil)
 	if err != nil {
 		return false, fmt.Errorf("failed to create service client: %w", err)
@@ -64,7 +139,7 @@ func AzureContainerExists(tenantID, clientID, clientSecret, accountName, contain
 
 		if pagerErr != nil {
 			var authErr *azidentity.AuthenticationFailedError
-			if errors.As(pagerErr, &authErr) {
+			if errors.As(pagerErr, &authErr) && authErr.RawResponse != nil {
 				bytes, err := io.ReadAll(authErr.RawResponse.Body)
 				if err != nil {
 					return false, pagerErr
@@ -76,7 +151,7 @@ func AzureContainerExists(tenantID, clientID, clientSecret, accountName, contain
 		}
 
 		for _, container := range results.ContainerItems {
-			if container.Name != nil && *container.Name == containerName {
+			if container.Name != nil && *container.Name == state.AzureContainerName {
 				return true, nil
 			}
 		}
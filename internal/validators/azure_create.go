@@ -0,0 +1,174 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"strings"
+)
+
+// azureStorageApiVersion is the version of the Microsoft.Storage API used to manage storage accounts through
+// the generic resources client
+const azureStorageApiVersion = "2023-01-01"
+
+const azureStorageNamespace = "Microsoft.Storage"
+
+// MissingAzureResources returns a description of the resource group, storage account, and container
+// that do not exist. An empty list means all the resources exist.
+func MissingAzureResources(state state.State) ([]string, error) {
+	rgClient, resourcesClient, err := azureResourceClients(state)
+	if err != nil {
+		return nil, err
+	}
+
+	resourceGroup := "resource group " + state.AzureResourceGroupName
+	storageAccount := "storage account " + state.AzureStorageAccountName
+	container := "container " + state.AzureContainerName
+
+	rgExists, err := rgClient.CheckExistence(context.Background(), state.AzureResourceGroupName, nil)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to check if the resource group "+state.AzureResourceGroupName+" exists"), err)
+	}
+
+	if !rgExists.Success {
+		return []string{resourceGroup, storageAccount, container}, nil
+	}
+
+	accountExists, err := resourcesClient.CheckExistence(context.Background(), state.AzureResourceGroupName, azureStorageNamespace, "", "storageAccounts", state.AzureStorageAccountName, azureStorageApiVersion, nil)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to check if the storage account "+state.AzureStorageAccountName+" exists"), err)
+	}
+
+	if !accountExists.Success {
+		return []string{storageAccount, container}, nil
+	}
+
+	containerExists, err := resourcesClient.CheckExistence(context.Background(), state.AzureResourceGroupName, azureStorageNamespace, blobServicePath(state), "containers", state.AzureContainerName, azureStorageApiVersion, nil)
+	if err != nil {
+		return nil, errors.Join(errors.New("failed to check if the container "+state.AzureContainerName+" exists"), err)
+	}
+
+	if !containerExists.Success {
+		return []string{container}, nil
+	}
+
+	return []string{}, nil
+}
+
+// CreateAzureStorage creates any missing resource group, storage account, and container used to save the
+// Terraform state. The storage account requires TLS 1.2, blocks public access to blobs, and enables blob
+// versioning and soft delete so previous versions of the state can be recovered.
+func CreateAzureStorage(state state.State) error {
+	rgClient, resourcesClient, err := azureResourceClients(state)
+	if err != nil {
+		return err
+	}
+
+	location := strings.TrimSpace(state.AzureLocation)
+
+	rgExists, err := rgClient.CheckExistence(context.Background(), state.AzureResourceGroupName, nil)
+	if err != nil {
+		return errors.Join(errors.New("failed to check if the resource group "+state.AzureResourceGroupName+" exists"), err)
+	}
+
+	if rgExists.Success {
+		// Resources are created in the same location as an existing resource group unless a location is defined
+		if location == "" {
+			resourceGroup, err := rgClient.Get(context.Background(), state.AzureResourceGroupName, nil)
+			if err != nil {
+				return errors.Join(errors.New("failed to get the resource group "+state.AzureResourceGroupName), err)
+			}
+
+			if resourceGroup.Location != nil {
+				location = *resourceGroup.Location
+			}
+		}
+	} else {
+		if location == "" {
+			return errors.New("a location is required to create the resource group " + state.AzureResourceGroupName)
+		}
+
+		if _, err := rgClient.CreateOrUpdate(context.Background(), state.AzureResourceGroupName, armresources.ResourceGroup{
+			Location: to.Ptr(location),
+		}, nil); err != nil {
+			return errors.Join(errors.New("failed to create the resource group "+state.AzureResourceGroupName), err)
+		}
+	}
+
+	accountExists, err := resourcesClient.CheckExistence(context.Background(), state.AzureResourceGroupName, azureStorageNamespace, "", "storageAccounts", state.AzureStorageAccountName, azureStorageApiVersion, nil)
+	if err != nil {
+		return errors.Join(errors.New("failed to check if the storage account "+state.AzureStorageAccountName+" exists"), err)
+	}
+
+	if !accountExists.Success {
+		if err := createAzureResource(resourcesClient, state, "", "storageAccounts", state.AzureStorageAccountName, armresources.GenericResource{
+			Location: to.Ptr(location),
+			Kind:     to.Ptr("StorageV2"),
+			SKU:      &armresources.SKU{Name: to.Ptr("Standard_LRS")},
+			Properties: map[string]any{
+				"minimumTlsVersion":        "TLS1_2",
+				"supportsHttpsTrafficOnly": true,
+				"allowBlobPublicAccess":    false,
+			},
+		}); err != nil {
+			return errors.Join(errors.New("failed to create the storage account "+state.AzureStorageAccountName), err)
+		}
+
+		if err := createAzureResource(resourcesClient, state, "storageAccounts/"+state.AzureStorageAccountName, "blobServices", "default", armresources.GenericResource{
+			Properties: map[string]any{
+				"isVersioningEnabled": true,
+				"deleteRetentionPolicy": map[string]any{
+					"enabled": true,
+					"days":    7,
+				},
+			},
+		}); err != nil {
+			return errors.Join(errors.New("failed to enable versioning on the storage account "+state.AzureStorageAccountName), err)
+		}
+	}
+
+	if err := createAzureResource(resourcesClient, state, blobServicePath(state), "containers", state.AzureContainerName, armresources.GenericResource{
+		Properties: map[string]any{
+			"publicAccess": "None",
+		},
+	}); err != nil {
+		return errors.Join(errors.New("failed to create the container "+state.AzureContainerName), err)
+	}
+
+	return nil
+}
+
+func azureResourceClients(state state.State) (*armresources.ResourceGroupsClient, *armresources.Client, error) {
+	cred, err := azureCredential(state)
+	if err != nil {
+		return nil, nil, errors.Join(errors.New("failed to create credential"), err)
+	}
+
+	rgClient, err := armresources.NewResourceGroupsClient(state.AzureSubscriptionId, cred, nil)
+	if err != nil {
+		return nil, nil, errors.Join(errors.New("failed to create resource group client"), err)
+	}
+
+	resourcesClient, err := armresources.NewClient(state.AzureSubscriptionId, cred, nil)
+	if err != nil {
+		return nil, nil, errors.Join(errors.New("failed to create resources client"), err)
+	}
+
+	return rgClient, resourcesClient, nil
+}
+
+func createAzureResource(client *armresources.Client, state state.State, parentPath string, resourceType string, name string, resource armresources.GenericResource) error {
+	poller, err := client.BeginCreateOrUpdate(context.Background(), state.AzureResourceGroupName, azureStorageNamespace, parentPath, resourceType, name, azureStorageApiVersion, resource, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = poller.PollUntilDone(context.Background(), nil)
+	return err
+}
+
+func blobServicePath(state state.State) string {
+	return "storageAccounts/" + state.AzureStorageAccountName + "/blobServices/default"
+}
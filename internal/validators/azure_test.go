@@ -1,18 +1,30 @@
 package validators
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
 )
 
 func TestAzureContainerExists(t *testing.T) {
-	tenantID := os.Getenv("AZURE_TENANT_ID")
-	clientID := os.Getenv("AZURE_CLIENT_ID")
-	clientSecret := os.Getenv("AZURE_CLIENT_SECRET")
-	accountName := os.Getenv("OCTOTERRAWIZ_AZURE_STORAGE_ACCOUNT")
-	containerName := os.Getenv("OCTOTERRAWIZ_AZURE_CONTAINER")
-
-	exists, err := AzureContainerExists(tenantID, clientID, clientSecret, accountName, containerName)
+	exists, err := AzureContainerExists(state.State{
+		AzureTenantId:           os.Getenv("AZURE_TENANT_ID"),
+		AzureApplicationId:      os.Getenv("AZURE_CLIENT_ID"),
+		AzurePassword:           os.Getenv("AZURE_CLIENT_SECRET"),
+		AzureStorageAccountName: os.Getenv("OCTOTERRAWIZ_AZURE_STORAGE_ACCOUNT"),
+		AzureContainerName:      os.Getenv("OCTOTERRAWIZ_AZURE_CONTAINER"),
+	})
 	if err != nil {
 		t.Fatalf("Error checking if container exists: %v", err)
 	}
@@ -21,3 +33,81 @@ func TestAzureContainerExists(t *testing.T) {
 		t.Errorf("Expected container to exist, but it does not.")
 	}
 }
+
+func TestAzureCredential(t *testing.T) {
+	const tenantId = "00000000-0000-0000-0000-000000000001"
+	const clientId = "00000000-0000-0000-0000-000000000002"
+
+	certificatePath := filepath.Join(t.TempDir(), "client.pem")
+	if err := os.WriteFile(certificatePath, selfSignedCertificate(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty or unknown auth type falls back to a client secret
+	credentials := map[string]any{
+		"":                       &azidentity.ClientSecretCredential{},
+		"unknown":                &azidentity.ClientSecretCredential{},
+		AzureAuthClientSecret:    &azidentity.ClientSecretCredential{},
+		AzureAuthCertificate:     &azidentity.ClientCertificateCredential{},
+		AzureAuthOidc:            &azidentity.ClientAssertionCredential{},
+		AzureAuthManagedIdentity: &azidentity.ManagedIdentityCredential{},
+		" managedidentity ":      &azidentity.ManagedIdentityCredential{},
+	}
+
+	for authType, expected := range credentials {
+		cred, err := azureCredential(state.State{
+			AzureAuthType:           authType,
+			AzureTenantId:           tenantId,
+			AzureApplicationId:      clientId,
+			AzurePassword:           "secret",
+			AzureCertificatePath:    certificatePath,
+			AzureFederatedTokenFile: filepath.Join(t.TempDir(), "token"),
+		})
+
+		if err != nil {
+			t.Fatalf("failed to create the credential for %q: %v", authType, err)
+		}
+
+		if fmt.Sprintf("%T", cred) != fmt.Sprintf("%T", expected) {
+			t.Fatalf("expected a %T for %q, got %T", expected, authType, cred)
+		}
+	}
+
+	if _, err := azureCredential(state.State{
+		AzureAuthType:        AzureAuthCertificate,
+		AzureTenantId:        tenantId,
+		AzureApplicationId:   clientId,
+		AzureCertificatePath: filepath.Join(t.TempDir(), "missing.pem"),
+	}); err == nil {
+		t.Fatal("expected an error for a missing certificate")
+	}
+}
+
+// selfSignedCertificate returns a PEM file holding a certificate and its unencrypted private key
+func selfSignedCertificate(t *testing.T) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "octoterrawiz"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return append(
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})...)
+}
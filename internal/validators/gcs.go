@@ -0,0 +1,72 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// GoogleStorageUrl is the base URL of the Google Cloud Storage JSON API
+var GoogleStorageUrl = "https://storage.googleapis.com/storage/v1"
+
+// googleStorageScope allows the state files in the bucket to be read, archived, and deleted
+const googleStorageScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// NewGoogleStorageClient returns an HTTP client that authenticates requests to the Google Cloud Storage JSON API
+// with the JSON credentials
+func NewGoogleStorageClient(state state.State) (*http.Client, error) {
+	credentials, err := google.CredentialsFromJSON(context.Background(), []byte(strings.TrimSpace(state.GoogleCloudCredentials)), googleStorageScope)
+
+	if err != nil {
+		return nil, errors.Join(errors.New("the credentials must be a Google Cloud JSON key"), err)
+	}
+
+	// The client exchanges the credentials for an access token, and adds it to each request
+	return oauth2.NewClient(context.Background(), credentials.TokenSource), nil
+}
+
+// TestGoogleCloudBucket uses the JSON credentials to read the metadata of the bucket
+func TestGoogleCloudBucket(state state.State) error {
+	client, err := NewGoogleStorageClient(state)
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", GoogleStorageUrl+"/b/"+url.PathEscape(strings.TrimSpace(state.GoogleCloudBucket)), nil)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return errors.Join(errors.New("failed to query the bucket "+state.GoogleCloudBucket), err)
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return errors.New("the bucket " + state.GoogleCloudBucket + " does not exist")
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("failed to query the bucket %s, status code %d: %s", state.GoogleCloudBucket, res.StatusCode, string(body))
+	}
+
+	return nil
+}
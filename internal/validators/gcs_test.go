@@ -0,0 +1,79 @@
+package validators
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+)
+
+func TestGoogleCloudBucketExists(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			parts := strings.Split(r.FormValue("assertion"), ".")
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if err := rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hash[:], signature); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token": "token"}`))
+		case "/b/state-bucket":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name": "state-bucket"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defaultStorageUrl := GoogleStorageUrl
+	GoogleStorageUrl = server.URL
+	defer func() { GoogleStorageUrl = defaultStorageUrl }()
+
+	credentials, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "terraform@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes})),
+		"token_uri":    server.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := TestGoogleCloudBucket(state.State{GoogleCloudBucket: "state-bucket", GoogleCloudCredentials: string(credentials)}); err != nil {
+		t.Fatalf("expected the bucket to be found: %v", err)
+	}
+
+	if err := TestGoogleCloudBucket(state.State{GoogleCloudBucket: "missing-bucket", GoogleCloudCredentials: string(credentials)}); err == nil {
+		t.Fatal("expected an error for a missing bucket")
+	}
+
+	if err := TestGoogleCloudBucket(state.State{GoogleCloudBucket: "state-bucket", GoogleCloudCredentials: "not a key"}); err == nil {
+		t.Fatal("expected an error for invalid credentials")
+	}
+}
@@ -0,0 +1,49 @@
+package validators
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+)
+
+// TestHttpBackend requests a state from the HTTP backend to confirm the address and credentials are correct.
+// The state does not exist before the first migration, so a missing state is not an error.
+func TestHttpBackend(state state.State) error {
+	address := strings.TrimRight(strings.TrimSpace(state.HttpAddress), "/")
+
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		return errors.New("the address " + address + " must start with http:// or https://")
+	}
+
+	req, err := http.NewRequest("GET", address+"/octoterrawiz_validation", nil)
+
+	if err != nil {
+		return err
+	}
+
+	if state.HttpUsername != "" || state.HttpPassword != "" {
+		req.SetBasicAuth(strings.TrimSpace(state.HttpUsername), state.HttpPassword)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return errors.Join(errors.New("failed to connect to "+address), err)
+	}
+
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("the credentials were rejected by %s, status code %d", address, res.StatusCode)
+	case res.StatusCode == http.StatusNotFound:
+		return nil
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return fmt.Errorf("unexpected status code %d returned by %s", res.StatusCode, address)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package validators
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+)
+
+func TestHttpBackendCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "terraform" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if err := TestHttpBackend(state.State{HttpAddress: server.URL + "/state/", HttpUsername: "terraform", HttpPassword: "secret"}); err != nil {
+		t.Fatalf("expected a missing state to be valid: %v", err)
+	}
+
+	if err := TestHttpBackend(state.State{HttpAddress: server.URL, HttpUsername: "terraform", HttpPassword: "wrong"}); err == nil {
+		t.Fatal("expected an error for invalid credentials")
+	}
+
+	if err := TestHttpBackend(state.State{HttpAddress: "state.example.com"}); err == nil {
+		t.Fatal("expected an error for an address without a scheme")
+	}
+}
@@ -12,9 +12,8 @@ func ValidateDatabase(state state.State) error {
 	ctx, stop := context.WithCancel(context.Background())
 	defer stop()
 
-	db, err := sensitivevariables.GetDatabaseConnection(state.DatabaseServer, state.DatabasePort, state.DatabaseName, state.DatabaseUser, state.DatabasePass, ctx)
+	db, err := sensitivevariables.GetDatabaseConnection(sensitivevariables.ConnectionFromState(state), ctx)
 	if err != nil {
-		log.Fatal(err)
 		return err
 	}
 	defer func() {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
@@ -14,15 +13,12 @@ import (
 )
 
 func TestS3Bucket(state state.State) error {
-	cfg, err := config.LoadDefaultConfig(
-		context.Background(),
-		config.WithCredentialsProvider(CustomCredentials{state}),
-		config.WithRegion(state.AwsS3BucketRegion))
+	cfg, err := awsConfig(state)
 	if err != nil {
 		return err
 	}
 
-	s3Client := s3.NewFromConfig(cfg)
+	s3Client := s3.NewFromConfig(cfg, s3Options(state))
 
 	if _, err := bucketExists(s3Client, strings.TrimSpace(state.AwsS3Bucket)); err != nil {
 		return err
@@ -31,6 +27,29 @@ func TestS3Bucket(state state.State) error {
 	return nil
 }
 
+// NewS3Client returns a client for the bucket holding the Terraform state
+func NewS3Client(state state.State) (*s3.Client, error) {
+	cfg, err := awsConfig(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return s3.NewFromConfig(cfg, s3Options(state)), nil
+}
+
+// s3Options directs the client to a custom endpoint, like a MinIO server, and enables path-style addressing.
+// Path-style addressing places the bucket name in the path of the URL rather than the host name, which
+// S3 compatible storage services often require.
+func s3Options(state state.State) func(*s3.Options) {
+	return func(options *s3.Options) {
+		if endpoint := strings.TrimSpace(state.AwsS3Endpoint); endpoint != "" {
+			options.BaseEndpoint = aws.String(endpoint)
+		}
+
+		options.UsePathStyle = state.AwsS3UsePathStyle
+	}
+}
+
 func bucketExists(s3Client *s3.Client, bucketName string) (bool, error) {
 	_, err := s3Client.HeadBucket(context.TODO(), &s3.HeadBucketInput{
 		Bucket: aws.String(bucketName),
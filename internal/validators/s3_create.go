@@ -0,0 +1,98 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"strings"
+)
+
+// S3BucketExists returns true if the bucket exists, and false if it does not. An error is returned if the
+// bucket can not be accessed.
+func S3BucketExists(state state.State) (bool, error) {
+	cfg, err := awsConfig(state)
+	if err != nil {
+		return false, err
+	}
+
+	return bucketExists(s3.NewFromConfig(cfg, s3Options(state)), strings.TrimSpace(state.AwsS3Bucket))
+}
+
+// CreateS3Bucket creates the bucket used to save the Terraform state. Versioning is enabled so previous
+// versions of the state can be recovered, objects are encrypted with S3 managed keys, and all public access
+// is blocked. S3 compatible storage services rarely support encryption defaults or public access blocks,
+// so only versioning is configured when a custom endpoint is defined.
+func CreateS3Bucket(state state.State) error {
+	cfg, err := awsConfig(state)
+	if err != nil {
+		return err
+	}
+
+	s3Client := s3.NewFromConfig(cfg, s3Options(state))
+	bucket := aws.String(strings.TrimSpace(state.AwsS3Bucket))
+	customEndpoint := strings.TrimSpace(state.AwsS3Endpoint) != ""
+
+	input := s3.CreateBucketInput{
+		Bucket: bucket,
+	}
+
+	// Buckets in us-east-1 must not define a location constraint
+	if region := strings.TrimSpace(state.AwsS3BucketRegion); region != "" && region != "us-east-1" && !customEndpoint {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(region),
+		}
+	}
+
+	if _, err := s3Client.CreateBucket(context.Background(), &input); err != nil {
+		var alreadyOwned *types.BucketAlreadyOwnedByYou
+		if !errors.As(err, &alreadyOwned) {
+			return errors.Join(errors.New("failed to create the bucket "+*bucket), err)
+		}
+	}
+
+	if _, err := s3Client.PutBucketVersioning(context.Background(), &s3.PutBucketVersioningInput{
+		Bucket: bucket,
+		VersioningConfiguration: &types.VersioningConfiguration{
+			Status: types.BucketVersioningStatusEnabled,
+		},
+	}); err != nil {
+		return errors.Join(errors.New("failed to enable versioning on the bucket "+*bucket), err)
+	}
+
+	if customEndpoint {
+		return nil
+	}
+
+	if _, err := s3Client.PutBucketEncryption(context.Background(), &s3.PutBucketEncryptionInput{
+		Bucket: bucket,
+		ServerSideEncryptionConfiguration: &types.ServerSideEncryptionConfiguration{
+			Rules: []types.ServerSideEncryptionRule{
+				{
+					ApplyServerSideEncryptionByDefault: &types.ServerSideEncryptionByDefault{
+						SSEAlgorithm: types.ServerSideEncryptionAes256,
+					},
+					BucketKeyEnabled: aws.Bool(true),
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Join(errors.New("failed to enable encryption on the bucket "+*bucket), err)
+	}
+
+	if _, err := s3Client.PutPublicAccessBlock(context.Background(), &s3.PutPublicAccessBlockInput{
+		Bucket: bucket,
+		PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(true),
+			BlockPublicPolicy:     aws.Bool(true),
+			IgnorePublicAcls:      aws.Bool(true),
+			RestrictPublicBuckets: aws.Bool(true),
+		},
+	}); err != nil {
+		return errors.Join(errors.New("failed to block public access to the bucket "+*bucket), err)
+	}
+
+	return nil
+}
@@ -1,8 +1,13 @@
 package validators
 
 import (
+	"encoding/pem"
 	"github.com/mcasperson/OctoterraWizard/internal/state"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -42,3 +47,92 @@ func TestS3BucketExists(t *testing.T) {
 		t.Fatalf("Error checking if bucket exists: %v", err)
 	}
 }
+
+func TestS3BucketCustomEndpoint(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Path-style addressing places the bucket name in the path
+		if r.Method != http.MethodHead || r.URL.Path != "/my-bucket" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caBundle, certificate, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testState := state.State{
+		AwsAccessKey:      "minio",
+		AwsSecretKey:      "minio123",
+		AwsS3Bucket:       "my-bucket",
+		AwsS3BucketRegion: "us-east-1",
+		AwsS3Endpoint:     server.URL,
+		AwsS3UsePathStyle: true,
+		AwsCaBundle:       caBundle,
+	}
+
+	if err := ValidateAWS(testState); err != nil {
+		t.Fatalf("expected the token service to be skipped for a custom endpoint: %v", err)
+	}
+
+	if err := TestS3Bucket(testState); err != nil {
+		t.Fatalf("Error checking if bucket exists: %v", err)
+	}
+
+	testState.AwsCaBundle = ""
+	if err := TestS3Bucket(testState); err == nil {
+		t.Fatal("expected an error when the server certificate is not trusted")
+	}
+}
+
+func TestCreateS3BucketCustomEndpoint(t *testing.T) {
+	requests := []string{}
+	created := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+
+		if r.Method == http.MethodHead && !created {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if r.Method == http.MethodPut && r.URL.RawQuery == "" {
+			created = true
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	testState := state.State{
+		AwsAccessKey:      "minio",
+		AwsSecretKey:      "minio123",
+		AwsS3Bucket:       "new-bucket",
+		AwsS3BucketRegion: "us-west-2",
+		AwsS3Endpoint:     server.URL,
+		AwsS3UsePathStyle: true,
+	}
+
+	if exists, err := S3BucketExists(testState); err != nil || exists {
+		t.Fatalf("expected the bucket to be missing: %v", err)
+	}
+
+	if err := CreateS3Bucket(testState); err != nil {
+		t.Fatalf("failed to create the bucket: %v", err)
+	}
+
+	if exists, err := S3BucketExists(testState); err != nil || !exists {
+		t.Fatalf("expected the bucket to exist: %v", err)
+	}
+
+	// Encryption and public access blocks are not configured for S3 compatible storage
+	expected := []string{"HEAD /new-bucket?", "PUT /new-bucket?", "PUT /new-bucket?versioning=", "HEAD /new-bucket?"}
+	if strings.Join(requests, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected requests %v, got %v", expected, requests)
+	}
+}
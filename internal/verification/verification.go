@@ -0,0 +1,297 @@
+package verification
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The kinds of resources compared by the verification report
+const (
+	KindEnvironment        = "Environment"
+	KindLifecycle          = "Lifecycle"
+	KindFeed               = "Feed"
+	KindAccount            = "Account"
+	KindLibraryVariableSet = "Library Variable Set"
+	KindProject            = "Project"
+	KindRunbook            = "Runbook"
+	KindTenant             = "Tenant"
+	KindTarget             = "Target"
+	KindVariable           = "Variable"
+)
+
+// The status of a resource in the verification report
+const (
+	StatusMissing   = "Missing"
+	StatusExtra     = "Extra"
+	StatusDifferent = "Different"
+)
+
+// Resource is a resource identified by its kind and name. Resources are matched between spaces by name rather than
+// ID, so any properties must also be described by name.
+type Resource struct {
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// Inventory is the collection of resources found in a space
+type Inventory struct {
+	resources map[string]Resource
+}
+
+// NewInventory creates an empty inventory
+func NewInventory() *Inventory {
+	return &Inventory{resources: map[string]Resource{}}
+}
+
+// Add adds a resource to the inventory. Resources with a duplicate kind and name, such as variables with the same
+// name and scope, have a counter appended to the name so they can still be matched by their order.
+func (i *Inventory) Add(kind string, name string, properties map[string]string) {
+	uniqueName := name
+	for count := 2; i.has(kind, uniqueName); count++ {
+		uniqueName = fmt.Sprintf("%s (%d)", name, count)
+	}
+
+	i.resources[key(kind, uniqueName)] = Resource{Kind: kind, Name: uniqueName, Properties: properties}
+}
+
+// Len returns the number of resources in the inventory
+func (i *Inventory) Len() int {
+	return len(i.resources)
+}
+
+func (i *Inventory) has(kind string, name string) bool {
+	_, ok := i.resources[key(kind, name)]
+	return ok
+}
+
+func key(kind string, name string) string {
+	return kind + "\x00" + name
+}
+
+// PropertyDifference is a property whose value differs between the source and destination resources
+type PropertyDifference struct {
+	Property    string `json:"property"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// Difference is a resource that is missing from the destination, only exists in the destination, or has different
+// properties in each space
+type Difference struct {
+	Kind       string               `json:"kind"`
+	Name       string               `json:"name"`
+	Status     string               `json:"status"`
+	Properties []PropertyDifference `json:"properties,omitempty"`
+}
+
+// KindSummary counts the resources of one kind in the report
+type KindSummary struct {
+	Kind        string `json:"kind"`
+	Source      int    `json:"source"`
+	Destination int    `json:"destination"`
+	Matched     int    `json:"matched"`
+	Missing     int    `json:"missing"`
+	Extra       int    `json:"extra"`
+	Different   int    `json:"different"`
+}
+
+// Report is the result of comparing the source and destination spaces
+type Report struct {
+	SourceSpace      string        `json:"sourceSpace"`
+	DestinationSpace string        `json:"destinationSpace"`
+	Created          time.Time     `json:"created"`
+	Summary          []KindSummary `json:"summary"`
+	Differences      []Difference  `json:"differences"`
+}
+
+// Compare compares the source and destination inventories. Differences are sorted by kind, status and name.
+func Compare(sourceSpace string, source *Inventory, destinationSpace string, destination *Inventory) Report {
+	report := Report{
+		SourceSpace:      sourceSpace,
+		DestinationSpace: destinationSpace,
+		Created:          time.Now().UTC(),
+		Differences:      []Difference{},
+	}
+
+	summaries := map[string]*KindSummary{}
+	summary := func(kind string) *KindSummary {
+		if _, ok := summaries[kind]; !ok {
+			summaries[kind] = &KindSummary{Kind: kind}
+		}
+		return summaries[kind]
+	}
+
+	for resourceKey, sourceResource := range source.resources {
+		kindSummary := summary(sourceResource.Kind)
+		kindSummary.Source++
+
+		destinationResource, ok := destination.resources[resourceKey]
+
+		if !ok {
+			kindSummary.Missing++
+			report.Differences = append(report.Differences, Difference{
+				Kind:   sourceResource.Kind,
+				Name:   sourceResource.Name,
+				Status: StatusMissing,
+			})
+			continue
+		}
+
+		if properties := compareProperties(sourceResource.Properties, destinationResource.Properties); len(properties) != 0 {
+			kindSummary.Different++
+			report.Differences = append(report.Differences, Difference{
+				Kind:       sourceResource.Kind,
+				Name:       sourceResource.Name,
+				Status:     StatusDifferent,
+				Properties: properties,
+			})
+			continue
+		}
+
+		kindSummary.Matched++
+	}
+
+	for resourceKey, destinationResource := range destination.resources {
+		kindSummary := summary(destinationResource.Kind)
+		kindSummary.Destination++
+
+		if _, ok := source.resources[resourceKey]; !ok {
+			kindSummary.Extra++
+			report.Differences = append(report.Differences, Difference{
+				Kind:   destinationResource.Kind,
+				Name:   destinationResource.Name,
+				Status: StatusExtra,
+			})
+		}
+	}
+
+	for _, kind := range slices.Sorted(maps.Keys(summaries)) {
+		report.Summary = append(report.Summary, *summaries[kind])
+	}
+
+	slices.SortFunc(report.Differences, func(a, b Difference) int {
+		if a.Kind != b.Kind {
+			return strings.Compare(a.Kind, b.Kind)
+		}
+		if a.Status != b.Status {
+			return strings.Compare(a.Status, b.Status)
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return report
+}
+
+func compareProperties(source map[string]string, destination map[string]string) []PropertyDifference {
+	names := map[string]bool{}
+	for name := range source {
+		names[name] = true
+	}
+	for name := range destination {
+		names[name] = true
+	}
+
+	differences := []PropertyDifference{}
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		if source[name] != destination[name] {
+			differences = append(differences, PropertyDifference{
+				Property:    name,
+				Source:      source[name],
+				Destination: destination[name],
+			})
+		}
+	}
+
+	return differences
+}
+
+// Count returns the number of differences with the given status
+func (r Report) Count(status string) int {
+	count := 0
+	for _, difference := range r.Differences {
+		if difference.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// String returns a short plain text description of the report
+func (r Report) String() string {
+	if len(r.Differences) == 0 {
+		return "No differences were found between the source and destination spaces."
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("%d missing, %d extra and %d different resources were found.\n",
+		r.Count(StatusMissing), r.Count(StatusExtra), r.Count(StatusDifferent)))
+
+	for _, difference := range r.Differences {
+		builder.WriteString(fmt.Sprintf("%s %s: %s\n", difference.Status, difference.Kind, difference.Name))
+		for _, property := range difference.Properties {
+			builder.WriteString(fmt.Sprintf("    %s: %q in the source, %q in the destination\n",
+				property.Property, property.Source, property.Destination))
+		}
+	}
+
+	return builder.String()
+}
+
+// WriteJSON writes the report as JSON
+func (r Report) WriteJSON(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Migration verification report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.Missing { color: #b00020; }
+.Extra { color: #b06000; }
+.Different { color: #00559b; }
+</style>
+</head>
+<body>
+<h1>Migration verification report</h1>
+<p>Source space {{.SourceSpace}} compared to destination space {{.DestinationSpace}} at {{.Created.Format "2006-01-02 15:04:05 MST"}}.</p>
+<h2>Summary</h2>
+<table>
+<tr><th>Kind</th><th>Source</th><th>Destination</th><th>Matched</th><th>Missing</th><th>Extra</th><th>Different</th></tr>
+{{range .Summary}}<tr><td>{{.Kind}}</td><td>{{.Source}}</td><td>{{.Destination}}</td><td>{{.Matched}}</td><td>{{.Missing}}</td><td>{{.Extra}}</td><td>{{.Different}}</td></tr>
+{{end}}</table>
+<h2>Differences</h2>
+{{if .Differences}}<table>
+<tr><th>Status</th><th>Kind</th><th>Name</th><th>Details</th></tr>
+{{range .Differences}}<tr class="{{.Status}}"><td>{{.Status}}</td><td>{{.Kind}}</td><td>{{.Name}}</td><td>{{range .Properties}}{{.Property}}: "{{.Source}}" in the source, "{{.Destination}}" in the destination<br>{{end}}</td></tr>
+{{end}}</table>{{else}}<p>No differences were found.</p>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a standalone HTML page
+func (r Report) WriteHTML(writer io.Writer) error {
+	return htmlReport.Execute(writer, r)
+}
+
+// Write writes the report as JSON if the path has a .json extension, and as HTML otherwise
+func (r Report) Write(path string, writer io.Writer) error {
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		return r.WriteJSON(writer)
+	}
+
+	return r.WriteHTML(writer)
+}
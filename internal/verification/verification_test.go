@@ -0,0 +1,80 @@
+package verification
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	source := NewInventory()
+	source.Add(KindEnvironment, "Development", nil)
+	source.Add(KindEnvironment, "Production", nil)
+	source.Add(KindProject, "Web App", map[string]string{"Project Group": "Default", "Lifecycle": "Default Lifecycle"})
+	source.Add(KindVariable, "Web App: Port", map[string]string{"Value": "80"})
+	source.Add(KindVariable, "Web App: Port", map[string]string{"Value": "8080"})
+
+	destination := NewInventory()
+	destination.Add(KindEnvironment, "Development", nil)
+	destination.Add(KindEnvironment, "Test", nil)
+	destination.Add(KindProject, "Web App", map[string]string{"Project Group": "Default", "Lifecycle": "Simple"})
+	destination.Add(KindVariable, "Web App: Port", map[string]string{"Value": "80"})
+	destination.Add(KindVariable, "Web App: Port", map[string]string{"Value": "8080"})
+
+	report := Compare("Spaces-1", source, "Spaces-2", destination)
+
+	if len(report.Differences) != 3 {
+		t.Fatalf("expected 3 differences, got %+v", report.Differences)
+	}
+
+	if extra := report.Differences[0]; extra.Kind != KindEnvironment || extra.Name != "Test" || extra.Status != StatusExtra {
+		t.Fatalf("unexpected difference %+v", report.Differences[0])
+	}
+
+	if missing := report.Differences[1]; missing.Kind != KindEnvironment || missing.Name != "Production" || missing.Status != StatusMissing {
+		t.Fatalf("unexpected difference %+v", report.Differences[1])
+	}
+
+	different := report.Differences[2]
+	if different.Status != StatusDifferent || len(different.Properties) != 1 ||
+		different.Properties[0] != (PropertyDifference{Property: "Lifecycle", Source: "Default Lifecycle", Destination: "Simple"}) {
+		t.Fatalf("unexpected difference %+v", different)
+	}
+
+	for _, summary := range report.Summary {
+		if summary.Kind == KindVariable && (summary.Matched != 2 || summary.Source != 2 || summary.Destination != 2) {
+			t.Fatalf("expected duplicate variables to be matched, got %+v", summary)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	source := NewInventory()
+	source.Add(KindTenant, "<Customer>", nil)
+
+	report := Compare("Spaces-1", source, "Spaces-2", NewInventory())
+
+	jsonOutput := bytes.Buffer{}
+	if err := report.WriteJSON(&jsonOutput); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed := Report{}
+	if err := json.Unmarshal(jsonOutput.Bytes(), &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed.Differences) != 1 || parsed.Differences[0].Name != "<Customer>" {
+		t.Fatalf("unexpected JSON report %s", jsonOutput.String())
+	}
+
+	htmlOutput := bytes.Buffer{}
+	if err := report.WriteHTML(&htmlOutput); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(htmlOutput.String(), "&lt;Customer&gt;") {
+		t.Fatalf("expected the resource name to be escaped in the HTML report")
+	}
+}
@@ -0,0 +1,83 @@
+package workerpool
+
+import (
+	"sync"
+)
+
+// DefaultMaxInFlight is the number of items processed at once when no limit is defined
+const DefaultMaxInFlight = 10
+
+// Progress counts the items processed by the pool
+type Progress struct {
+	Total     int
+	Running   int
+	Succeeded int
+	Failed    int
+}
+
+// Complete returns the number of items that have finished processing
+func (p Progress) Complete() int {
+	return p.Succeeded + p.Failed
+}
+
+// Outcome is the result of processing an item
+type Outcome[T any] struct {
+	Item  T
+	Error error
+}
+
+// Run processes the items with at most maxInFlight items being processed at once. A maxInFlight less than 1 uses
+// DefaultMaxInFlight. The progress callback is called each time an item starts or finishes, and calls are never made
+// concurrently. The outcomes are returned in the same order as the items.
+func Run[T any](items []T, maxInFlight int, work func(item T) error, progress func(progress Progress)) []Outcome[T] {
+	if maxInFlight < 1 {
+		maxInFlight = DefaultMaxInFlight
+	}
+
+	outcomes := make([]Outcome[T], len(items))
+	current := Progress{Total: len(items)}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxInFlight)
+
+	update := func(change func(progress *Progress)) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		change(&current)
+
+		if progress != nil {
+			progress(current)
+		}
+	}
+
+	for index, item := range items {
+		slots <- struct{}{}
+		wg.Add(1)
+
+		update(func(progress *Progress) {
+			progress.Running++
+		})
+
+		go func(index int, item T) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := work(item)
+			outcomes[index] = Outcome[T]{Item: item, Error: err}
+
+			update(func(progress *Progress) {
+				progress.Running--
+				if err != nil {
+					progress.Failed++
+				} else {
+					progress.Succeeded++
+				}
+			})
+		}(index, item)
+	}
+
+	wg.Wait()
+
+	return outcomes
+}
@@ -0,0 +1,72 @@
+package workerpool
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunLimitsItemsInFlight(t *testing.T) {
+	var inFlight, maxSeen atomic.Int32
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	outcomes := Run(items, 3, func(item int) error {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			seen := maxSeen.Load()
+			if current <= seen || maxSeen.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		if item%4 == 0 {
+			return errors.New("failed")
+		}
+
+		return nil
+	}, func(progress Progress) {
+		if progress.Running > 3 {
+			t.Errorf("expected no more than 3 items in flight, got %d", progress.Running)
+		}
+	})
+
+	if maxSeen.Load() > 3 {
+		t.Fatalf("expected no more than 3 items in flight, got %d", maxSeen.Load())
+	}
+
+	for index, outcome := range outcomes {
+		if outcome.Item != items[index] {
+			t.Fatalf("expected outcome %d to be for item %d", index, items[index])
+		}
+
+		if (outcome.Error != nil) != (outcome.Item%4 == 0) {
+			t.Fatalf("unexpected error for item %d: %v", outcome.Item, outcome.Error)
+		}
+	}
+}
+
+func TestRunReportsFinalProgress(t *testing.T) {
+	var last Progress
+
+	Run([]string{"a", "b", "c"}, 0, func(item string) error {
+		if item == "b" {
+			return errors.New("failed")
+		}
+		return nil
+	}, func(progress Progress) {
+		last = progress
+	})
+
+	if last != (Progress{Total: 3, Running: 0, Succeeded: 2, Failed: 1}) {
+		t.Fatalf("unexpected final progress %+v", last)
+	}
+
+	if last.Complete() != 3 {
+		t.Fatalf("expected 3 complete items, got %d", last.Complete())
+	}
+}
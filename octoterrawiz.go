@@ -1,13 +1,21 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
+	"github.com/mcasperson/OctoterraWizard/internal/config"
+	"github.com/mcasperson/OctoterraWizard/internal/headless"
+	"github.com/mcasperson/OctoterraWizard/internal/infrastructure"
+	"github.com/mcasperson/OctoterraWizard/internal/projectfilter"
 	"github.com/mcasperson/OctoterraWizard/internal/state"
 	"github.com/mcasperson/OctoterraWizard/internal/steps"
 	"github.com/mcasperson/OctoterraWizard/internal/wizard"
+	"github.com/mcasperson/OctoterraWizard/internal/workerpool"
 	"image/color"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -32,10 +40,212 @@ func (m myTheme) Icon(name fyne.ThemeIconName) fyne.Resource {
 }
 
 func main() {
+	initialState := getDefaultState()
+
+	headlessMode := flag.Bool("headless", strings.ToLower(os.Getenv("OCTOTERRAWIZ_HEADLESS")) == "true", "Run the migration without displaying the wizard")
+	resume := flag.Bool("resume", strings.ToLower(os.Getenv("OCTOTERRAWIZ_RESUME")) == "true", "Resume a previous headless migration, skipping the steps and projects that completed successfully")
+	planMode := flag.Bool("plan", strings.ToLower(os.Getenv("OCTOTERRAWIZ_PLAN")) == "true", "Report the changes the migration would make to the source space and exit without making them. Implies headless mode")
+	configFile := flag.String("config", os.Getenv("OCTOTERRAWIZ_CONFIG_FILE"), "A YAML or JSON migration file used to populate the settings")
+	verificationReport := flag.String("verification-report", os.Getenv("OCTOTERRAWIZ_VERIFICATION_REPORT"), "Compare the source and destination spaces after a headless migration and save the report to this HTML or JSON file")
+	stateAction := flag.String("terraform-state", os.Getenv("OCTOTERRAWIZ_TERRAFORM_STATE"), "One of \"list\", \"archive\", or \"delete\". Lists the Terraform state saved by previous migrations, or archives or deletes the state of the destination space, and exits")
+	uploadSecrets := flag.String("upload-secrets", os.Getenv("OCTOTERRAWIZ_UPLOAD_SECRETS"), "One of \"source\" or \"destination\". Uploads the values in the encrypted secrets file to the space, and exits")
+	unspreadVariables := flag.Bool("unspread-variables", strings.ToLower(os.Getenv("OCTOTERRAWIZ_UNSPREAD_VARIABLES")) == "true", "Restore the names and scopes of the sensitive variables spread by a previous migration, and exit")
+	runbookEnvironment := flag.String("runbook-environment", os.Getenv("OCTOTERRAWIZ_RUNBOOK_ENVIRONMENT"), "The environment used to run the migration runbooks. Defaults to the first environment in the source space")
+	bindStateFlags(flag.CommandLine, &initialState)
+	flag.Parse()
+
+	if *configFile != "" {
+		fileState, err := loadConfigFile(*configFile, initialState)
+
+		if err != nil {
+			fmt.Println("🔴 " + err.Error())
+			os.Exit(1)
+		}
+
+		initialState = fileState
+	}
+
+	if *stateAction != "" {
+		if err := headless.ManageState(initialState, *stateAction); err != nil {
+			fmt.Println("🔴 " + err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *uploadSecrets != "" {
+		if err := headless.UploadSecrets(initialState, *uploadSecrets); err != nil {
+			fmt.Println("🔴 " + err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *unspreadVariables {
+		if err := headless.UnspreadVariables(initialState); err != nil {
+			fmt.Println("🔴 " + err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *planMode {
+		if err := headless.Plan(initialState); err != nil {
+			fmt.Println("🔴 " + err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *headlessMode {
+		if err := headless.Run(initialState, *runbookEnvironment, *resume); err != nil {
+			fmt.Println("🔴 " + err.Error())
+			os.Exit(1)
+		}
+
+		if *verificationReport != "" {
+			if err := headless.Verify(initialState, *verificationReport); err != nil {
+				fmt.Println("🔴 " + err.Error())
+				os.Exit(1)
+			}
+		}
+		return
+	}
 
 	wiz := wizard.NewWizard("Octoterra Wizard (" + Version + ")")
 	wiz.App.Settings().SetTheme(&myTheme{})
 
+	wiz.ShowWizardStep(steps.WelcomeStep{
+		Wizard:   *wiz,
+		BaseStep: steps.BaseStep{State: initialState},
+	})
+	wiz.Window.ShowAndRun()
+}
+
+// loadConfigFile overlays the migration file onto the state. Flags that were explicitly
+// passed on the command line take precedence over the values in the file.
+func loadConfigFile(path string, existing state.State) (state.State, error) {
+	migrationConfig, err := config.Load(path)
+
+	if err != nil {
+		return state.State{}, err
+	}
+
+	// The flags are bound to the fields of the state, so capture the explicit flag values and reapply them
+	explicitFlags := map[string]string{}
+	flag.Visit(func(f *flag.Flag) {
+		explicitFlags[f.Name] = f.Value.String()
+	})
+
+	fileState, err := migrationConfig.Apply(existing)
+
+	if err != nil {
+		return state.State{}, err
+	}
+
+	fileFlags := flag.NewFlagSet("config", flag.ContinueOnError)
+	bindStateFlags(fileFlags, &fileState)
+	for name, value := range explicitFlags {
+		if fileFlags.Lookup(name) != nil {
+			if err := fileFlags.Set(name, value); err != nil {
+				return state.State{}, err
+			}
+		}
+	}
+
+	return fileState, nil
+}
+
+// bindStateFlags defines the command line flags that override the fields of the state
+func bindStateFlags(flags *flag.FlagSet, s *state.State) {
+	flags.StringVar(&s.BackendType, "backend-type", s.BackendType, "One of \"AWS S3\", \"Azure Storage\", \"Google Cloud Storage\", \"HTTP\", or \"PostgreSQL\"")
+	flags.StringVar(&s.Server, "source-server", s.Server, "The URL of the Octopus server to export from")
+	flags.StringVar(&s.ApiKey, "source-api-key", s.ApiKey, "The API key used to connect to the source server")
+	flags.StringVar(&s.Space, "source-space-id", s.Space, "The ID of the space to export")
+	flags.StringVar(&s.DestinationServer, "destination-server", s.DestinationServer, "The URL of the Octopus server to import to")
+	flags.StringVar(&s.DestinationApiKey, "destination-api-key", s.DestinationApiKey, "The API key used to connect to the destination server")
+	flags.StringVar(&s.DestinationSpace, "destination-space-id", s.DestinationSpace, "The ID of the space to import to")
+	flags.StringVar(&s.AwsAccessKey, "aws-access-key", s.AwsAccessKey, "The AWS access key")
+	flags.StringVar(&s.AwsSecretKey, "aws-secret-key", s.AwsSecretKey, "The AWS secret key")
+	flags.StringVar(&s.AwsS3Bucket, "aws-s3-bucket", s.AwsS3Bucket, "The name of the S3 bucket holding the Terraform state")
+	flags.StringVar(&s.AwsS3BucketRegion, "aws-s3-bucket-region", s.AwsS3BucketRegion, "The region of the S3 bucket holding the Terraform state")
+	flags.StringVar(&s.AwsS3Endpoint, "aws-s3-endpoint", s.AwsS3Endpoint, "The URL of an S3 compatible storage service, like MinIO, holding the Terraform state")
+	flags.BoolVar(&s.AwsS3UsePathStyle, "aws-s3-use-path-style", s.AwsS3UsePathStyle, "Use path-style addressing to access the S3 bucket")
+	flags.StringVar(&s.AwsCaBundle, "aws-ca-bundle", s.AwsCaBundle, "The path to a PEM file with the certificate authorities trusted when accessing the S3 bucket")
+	flags.StringVar(&s.AwsProfile, "aws-profile", s.AwsProfile, "The name of the AWS profile used to access the S3 bucket instead of an access key")
+	flags.StringVar(&s.AwsRoleArn, "aws-role-arn", s.AwsRoleArn, "The ARN of an AWS role assumed to access the S3 bucket")
+	flags.StringVar(&s.AwsRoleSessionName, "aws-role-session-name", s.AwsRoleSessionName, "The session name used when assuming the AWS role")
+	flags.StringVar(&s.AwsRoleExternalId, "aws-role-external-id", s.AwsRoleExternalId, "The external ID used when assuming the AWS role")
+	flags.StringVar(&s.AwsWebIdentityTokenFile, "aws-web-identity-token-file", s.AwsWebIdentityTokenFile, "The path to a web identity token exchanged for the credentials of the AWS role")
+	flags.StringVar(&s.AzureResourceGroupName, "azure-resource-group", s.AzureResourceGroupName, "The name of the Azure resource group holding the Terraform state")
+	flags.StringVar(&s.AzureStorageAccountName, "azure-storage-account", s.AzureStorageAccountName, "The name of the Azure storage account holding the Terraform state")
+	flags.StringVar(&s.AzureContainerName, "azure-container", s.AzureContainerName, "The name of the Azure storage container holding the Terraform state")
+	flags.StringVar(&s.AzureSubscriptionId, "azure-subscription-id", s.AzureSubscriptionId, "The Azure subscription ID")
+	flags.StringVar(&s.AzureTenantId, "azure-tenant-id", s.AzureTenantId, "The Azure tenant ID")
+	flags.StringVar(&s.AzureApplicationId, "azure-client-id", s.AzureApplicationId, "The Azure application (client) ID")
+	flags.StringVar(&s.AzurePassword, "azure-client-secret", s.AzurePassword, "The Azure application secret")
+	flags.StringVar(&s.AzureAuthType, "azure-auth-type", s.AzureAuthType, "One of \"ClientSecret\", \"Certificate\", \"OIDC\", or \"ManagedIdentity\"")
+	flags.StringVar(&s.AzureCertificatePath, "azure-client-certificate-path", s.AzureCertificatePath, "The path to the PEM or PKCS12 certificate used to authenticate the Azure application")
+	flags.StringVar(&s.AzureCertificatePassword, "azure-client-certificate-password", s.AzureCertificatePassword, "The password of the Azure application certificate")
+	flags.StringVar(&s.AzureFederatedTokenFile, "azure-federated-token-file", s.AzureFederatedTokenFile, "The path to a federated OIDC token exchanged for an Azure access token")
+	flags.StringVar(&s.AzureLocation, "azure-location", s.AzureLocation, "The Azure location used when creating the resource group and storage account")
+	flags.BoolVar(&s.CreateBackendStorage, "create-backend-storage", s.CreateBackendStorage, "Create the S3 bucket, or the Azure resource group, storage account, and container, if they do not exist")
+	flags.StringVar(&s.GoogleCloudBucket, "google-cloud-bucket", s.GoogleCloudBucket, "The name of the Google Cloud Storage bucket holding the Terraform state")
+	flags.StringVar(&s.GoogleCloudCredentials, "google-cloud-credentials", s.GoogleCloudCredentials, "The JSON key of the Google Cloud service account used to access the bucket")
+	flags.StringVar(&s.HttpAddress, "http-address", s.HttpAddress, "The base URL of the HTTP backend holding the Terraform state")
+	flags.StringVar(&s.HttpUsername, "http-username", s.HttpUsername, "The username used to access the HTTP backend")
+	flags.StringVar(&s.HttpPassword, "http-password", s.HttpPassword, "The password used to access the HTTP backend")
+	flags.StringVar(&s.PostgresConnectionString, "postgres-connection-string", s.PostgresConnectionString, "The connection string of the PostgreSQL database holding the Terraform state")
+	flags.StringVar(&s.DatabaseServer, "database-server", s.DatabaseServer, "The Octopus database server hostname or IP. Sensitive values are not extracted if this is empty")
+	flags.StringVar(&s.DatabasePort, "database-port", s.DatabasePort, "The database port")
+	flags.StringVar(&s.DatabaseName, "database-name", s.DatabaseName, "The database name")
+	flags.StringVar(&s.DatabaseUser, "database-user", s.DatabaseUser, "The database username")
+	flags.StringVar(&s.DatabasePass, "database-pass", s.DatabasePass, "The database password")
+	flags.StringVar(&s.DatabaseMasterKey, "database-masterkey", s.DatabaseMasterKey, "The Octopus master key")
+	flags.StringVar(&s.DatabaseAuthType, "database-auth-type", s.DatabaseAuthType, "The database authentication method. One of SqlServer, Windows, AzureServicePrincipal, AzureManagedIdentity, or AzureInteractive")
+	flags.StringVar(&s.DatabaseInstance, "database-instance", s.DatabaseInstance, "The named instance of the database server")
+	flags.StringVar(&s.DatabaseEncrypt, "database-encrypt", s.DatabaseEncrypt, "The database encryption mode. One of true, false, strict, or disable")
+	flags.BoolVar(&s.DatabaseTrustServerCertificate, "database-trust-server-certificate", s.DatabaseTrustServerCertificate, "Trust the database server certificate without validating it")
+	flags.StringVar(&s.DatabaseAzureTenantId, "database-azure-tenant-id", s.DatabaseAzureTenantId, "The Azure tenant ID of the service principal used to connect to Azure SQL")
+	flags.StringVar(&s.DatabaseAzureClientId, "database-azure-client-id", s.DatabaseAzureClientId, "The Azure client ID of the service principal, managed identity, or application used to connect to Azure SQL")
+	flags.StringVar(&s.SecretsFile, "secrets-file", s.SecretsFile, "Save the sensitive values to this encrypted file instead of a library variable set in the source space")
+	flags.StringVar(&s.SecretsPassphrase, "secrets-passphrase", s.SecretsPassphrase, "The passphrase used to encrypt the secrets file")
+	flags.BoolVar(&s.ContinueOnExtractionError, "continue-on-extraction-error", s.ContinueOnExtractionError, "Skip sensitive values that can not be decrypted rather than failing the extraction")
+	flags.BoolVar(&s.PromptForDelete, "prompt-for-delete", s.PromptForDelete, "Prompt before deleting resources. Prompts are automatically confirmed in headless mode")
+	flags.BoolVar(&s.UseContainerImages, "use-container-images", s.UseContainerImages, "Use container images to run the Terraform steps")
+	flags.BoolVar(&s.ExcludeAllLibraryVariableSets, "exclude-all-library-variable-sets", s.ExcludeAllLibraryVariableSets, "Exclude all library variable sets from the export")
+	flags.BoolVar(&s.EnableVariableSpreading, "enable-variable-spreading", s.EnableVariableSpreading, "Spread sensitive variables before exporting the space")
+	flags.BoolVar(&s.EnableProjectRenaming, "enable-project-renaming", s.EnableProjectRenaming, "Use a prompted variable for the destination project name")
+	flags.IntVar(&s.MaxConcurrentRunbooks, "max-concurrent-runbooks", s.MaxConcurrentRunbooks, "The maximum number of project runbooks run at once")
+	flags.IntVar(&s.TaskTimeoutMinutes, "task-timeout-minutes", s.TaskTimeoutMinutes, "The number of minutes to wait for a runbook task to complete")
+	flags.Var(listValue{&s.IncludeProjectGroups}, "include-project-groups", "A comma separated list of project group names or IDs whose projects are migrated")
+	flags.Var(listValue{&s.ExcludeProjectGroups}, "exclude-project-groups", "A comma separated list of project group names or IDs whose projects are not migrated")
+	flags.StringVar(&s.IncludeProjectNamePattern, "include-project-names", s.IncludeProjectNamePattern, "A regular expression matching the names of projects that are migrated")
+	flags.StringVar(&s.ExcludeProjectNamePattern, "exclude-project-names", s.ExcludeProjectNamePattern, "A regular expression matching the names of projects that are not migrated")
+	flags.Var(listValue{&s.IncludeProjectIds}, "include-project-ids", "A comma separated list of the IDs of projects that are migrated")
+	flags.Var(listValue{&s.ExcludeProjectIds}, "exclude-project-ids", "A comma separated list of the IDs of projects that are not migrated")
+}
+
+// listValue is a flag bound to a comma separated list
+type listValue struct {
+	list *[]string
+}
+
+func (l listValue) String() string {
+	if l.list == nil {
+		return ""
+	}
+
+	return projectfilter.JoinList(*l.list)
+}
+
+func (l listValue) Set(value string) error {
+	*l.list = projectfilter.SplitList(value)
+	return nil
+}
+
+// getDefaultState builds the initial state from environment variables
+func getDefaultState() state.State {
 	defaultSourceServer := os.Getenv("OCTOTERRAWIZ_SOURCE_SERVER")
 	if defaultSourceServer == "" {
 		defaultSourceServer = os.Getenv("OCTOPUS_CLI_SERVER")
@@ -66,41 +276,92 @@ func main() {
 		defaultDestinationServerSpace = "Spaces-1"
 	}
 
-	wiz.ShowWizardStep(steps.WelcomeStep{
-		Wizard: *wiz,
-		BaseStep: steps.BaseStep{State: state.State{
-			BackendType:                   os.Getenv("OCTOTERRAWIZ_BACKEND_TYPE"),
-			Server:                        defaultSourceServer,
-			ServerExternal:                "",
-			ApiKey:                        defaultSourceServerApi,
-			Space:                         defaultSourceServerSpace,
-			DestinationServer:             defaultDestinationServer,
-			DestinationServerExternal:     "",
-			DestinationApiKey:             defaultDestinationServerApi,
-			DestinationSpace:              defaultDestinationServerSpace,
-			AwsAccessKey:                  os.Getenv("AWS_ACCESS_KEY_ID"),
-			AwsSecretKey:                  os.Getenv("AWS_SECRET_ACCESS_KEY"),
-			AwsS3Bucket:                   os.Getenv("AWS_DEFAULT_BUCKET"),
-			AwsS3BucketRegion:             os.Getenv("AWS_DEFAULT_REGION"),
-			PromptForDelete:               strings.ToLower(os.Getenv("OCTOTERRAWIZ_PROMPT_FOR_DELETE")) == "true",
-			UseContainerImages:            strings.ToLower(os.Getenv("OCTOTERRAWIZ_USE_CONTAINER_IMAGES")) == "true",
-			AzureResourceGroupName:        os.Getenv("OCTOTERRAWIZ_AZURE_RESOURCE_GROUP"),
-			AzureStorageAccountName:       os.Getenv("OCTOTERRAWIZ_AZURE_STORAGE_ACCOUNT"),
-			AzureContainerName:            os.Getenv("OCTOTERRAWIZ_AZURE_CONTAINER"),
-			AzureSubscriptionId:           os.Getenv("AZURE_SUBSCRIPTION_ID"),
-			AzureTenantId:                 os.Getenv("AZURE_TENANT_ID"),
-			AzureApplicationId:            os.Getenv("AZURE_CLIENT_ID"),
-			AzurePassword:                 os.Getenv("AZURE_CLIENT_SECRET"),
-			ExcludeAllLibraryVariableSets: strings.ToLower(os.Getenv("OCTOTERRAWIZ_EXCLUDE_ALL_LIBRARY_VARIABLE_SETS")) == "true",
-			EnableVariableSpreading:       false,
-			DatabaseServer:                os.Getenv("OCTOTERRAWIZ_DATABASE_SERVER"),
-			DatabaseUser:                  os.Getenv("OCTOTERRAWIZ_DATABASE_USER"),
-			DatabasePass:                  os.Getenv("OCTOTERRAWIZ_DATABASE_PASS"),
-			DatabasePort:                  os.Getenv("OCTOTERRAWIZ_DATABASE_PORT"),
-			DatabaseName:                  os.Getenv("OCTOTERRAWIZ_DATABASE_NAME"),
-			DatabaseMasterKey:             os.Getenv("OCTOTERRAWIZ_DATABASE_MASTERKEY"),
-			EnableProjectRenaming:         strings.ToLower(os.Getenv("OCTOTERRAWIZ_ENABLE_PROJECT_RENAMING")) == "true",
-		}},
-	})
-	wiz.Window.ShowAndRun()
+	return state.State{
+		BackendType:                    os.Getenv("OCTOTERRAWIZ_BACKEND_TYPE"),
+		Server:                         defaultSourceServer,
+		ServerExternal:                 "",
+		ApiKey:                         defaultSourceServerApi,
+		Space:                          defaultSourceServerSpace,
+		DestinationServer:              defaultDestinationServer,
+		DestinationServerExternal:      "",
+		DestinationApiKey:              defaultDestinationServerApi,
+		DestinationSpace:               defaultDestinationServerSpace,
+		AwsAccessKey:                   os.Getenv("AWS_ACCESS_KEY_ID"),
+		AwsSecretKey:                   os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		AwsS3Bucket:                    os.Getenv("AWS_DEFAULT_BUCKET"),
+		AwsS3BucketRegion:              os.Getenv("AWS_DEFAULT_REGION"),
+		AwsS3Endpoint:                  os.Getenv("AWS_ENDPOINT_URL_S3"),
+		AwsS3UsePathStyle:              strings.ToLower(os.Getenv("OCTOTERRAWIZ_AWS_S3_USE_PATH_STYLE")) == "true",
+		AwsCaBundle:                    os.Getenv("AWS_CA_BUNDLE"),
+		AwsProfile:                     os.Getenv("AWS_PROFILE"),
+		AwsRoleArn:                     os.Getenv("AWS_ROLE_ARN"),
+		AwsRoleSessionName:             os.Getenv("AWS_ROLE_SESSION_NAME"),
+		AwsRoleExternalId:              os.Getenv("OCTOTERRAWIZ_AWS_ROLE_EXTERNAL_ID"),
+		AwsWebIdentityTokenFile:        os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"),
+		PromptForDelete:                strings.ToLower(os.Getenv("OCTOTERRAWIZ_PROMPT_FOR_DELETE")) == "true",
+		UseContainerImages:             strings.ToLower(os.Getenv("OCTOTERRAWIZ_USE_CONTAINER_IMAGES")) == "true",
+		AzureResourceGroupName:         os.Getenv("OCTOTERRAWIZ_AZURE_RESOURCE_GROUP"),
+		AzureStorageAccountName:        os.Getenv("OCTOTERRAWIZ_AZURE_STORAGE_ACCOUNT"),
+		AzureContainerName:             os.Getenv("OCTOTERRAWIZ_AZURE_CONTAINER"),
+		AzureSubscriptionId:            os.Getenv("AZURE_SUBSCRIPTION_ID"),
+		AzureTenantId:                  os.Getenv("AZURE_TENANT_ID"),
+		AzureApplicationId:             os.Getenv("AZURE_CLIENT_ID"),
+		AzurePassword:                  os.Getenv("AZURE_CLIENT_SECRET"),
+		AzureAuthType:                  os.Getenv("OCTOTERRAWIZ_AZURE_AUTH_TYPE"),
+		AzureCertificatePath:           os.Getenv("AZURE_CLIENT_CERTIFICATE_PATH"),
+		AzureCertificatePassword:       os.Getenv("AZURE_CLIENT_CERTIFICATE_PASSWORD"),
+		AzureFederatedTokenFile:        os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		AzureLocation:                  os.Getenv("OCTOTERRAWIZ_AZURE_LOCATION"),
+		CreateBackendStorage:           strings.ToLower(os.Getenv("OCTOTERRAWIZ_CREATE_BACKEND_STORAGE")) == "true",
+		GoogleCloudBucket:              os.Getenv("OCTOTERRAWIZ_GOOGLE_CLOUD_BUCKET"),
+		GoogleCloudCredentials:         os.Getenv("GOOGLE_CREDENTIALS"),
+		HttpAddress:                    os.Getenv("OCTOTERRAWIZ_HTTP_ADDRESS"),
+		HttpUsername:                   os.Getenv("TF_HTTP_USERNAME"),
+		HttpPassword:                   os.Getenv("TF_HTTP_PASSWORD"),
+		PostgresConnectionString:       os.Getenv("PG_CONN_STR"),
+		ExcludeAllLibraryVariableSets:  strings.ToLower(os.Getenv("OCTOTERRAWIZ_EXCLUDE_ALL_LIBRARY_VARIABLE_SETS")) == "true",
+		EnableVariableSpreading:        false,
+		DatabaseServer:                 os.Getenv("OCTOTERRAWIZ_DATABASE_SERVER"),
+		DatabaseUser:                   os.Getenv("OCTOTERRAWIZ_DATABASE_USER"),
+		DatabasePass:                   os.Getenv("OCTOTERRAWIZ_DATABASE_PASS"),
+		DatabasePort:                   os.Getenv("OCTOTERRAWIZ_DATABASE_PORT"),
+		DatabaseName:                   os.Getenv("OCTOTERRAWIZ_DATABASE_NAME"),
+		DatabaseMasterKey:              os.Getenv("OCTOTERRAWIZ_DATABASE_MASTERKEY"),
+		DatabaseAuthType:               os.Getenv("OCTOTERRAWIZ_DATABASE_AUTH_TYPE"),
+		DatabaseInstance:               os.Getenv("OCTOTERRAWIZ_DATABASE_INSTANCE"),
+		DatabaseEncrypt:                os.Getenv("OCTOTERRAWIZ_DATABASE_ENCRYPT"),
+		DatabaseTrustServerCertificate: strings.ToLower(os.Getenv("OCTOTERRAWIZ_DATABASE_TRUST_SERVER_CERTIFICATE")) == "true",
+		DatabaseAzureTenantId:          os.Getenv("OCTOTERRAWIZ_DATABASE_AZURE_TENANT_ID"),
+		DatabaseAzureClientId:          os.Getenv("OCTOTERRAWIZ_DATABASE_AZURE_CLIENT_ID"),
+		SecretsFile:                    os.Getenv("OCTOTERRAWIZ_SECRETS_FILE"),
+		SecretsPassphrase:              os.Getenv("OCTOTERRAWIZ_SECRETS_PASSPHRASE"),
+		ContinueOnExtractionError:      strings.ToLower(os.Getenv("OCTOTERRAWIZ_CONTINUE_ON_EXTRACTION_ERROR")) == "true",
+		EnableProjectRenaming:          strings.ToLower(os.Getenv("OCTOTERRAWIZ_ENABLE_PROJECT_RENAMING")) == "true",
+		MaxConcurrentRunbooks:          getMaxConcurrentRunbooks(),
+		TaskTimeoutMinutes:             getTaskTimeoutMinutes(),
+		IncludeProjectGroups:           projectfilter.SplitList(os.Getenv("OCTOTERRAWIZ_INCLUDE_PROJECT_GROUPS")),
+		ExcludeProjectGroups:           projectfilter.SplitList(os.Getenv("OCTOTERRAWIZ_EXCLUDE_PROJECT_GROUPS")),
+		IncludeProjectNamePattern:      os.Getenv("OCTOTERRAWIZ_INCLUDE_PROJECT_NAMES"),
+		ExcludeProjectNamePattern:      os.Getenv("OCTOTERRAWIZ_EXCLUDE_PROJECT_NAMES"),
+		IncludeProjectIds:              projectfilter.SplitList(os.Getenv("OCTOTERRAWIZ_INCLUDE_PROJECT_IDS")),
+		ExcludeProjectIds:              projectfilter.SplitList(os.Getenv("OCTOTERRAWIZ_EXCLUDE_PROJECT_IDS")),
+	}
+}
+
+// getMaxConcurrentRunbooks reads the maximum number of concurrent runbook runs from the environment
+func getMaxConcurrentRunbooks() int {
+	if maxConcurrentRunbooks, err := strconv.Atoi(os.Getenv("OCTOTERRAWIZ_MAX_CONCURRENT_RUNBOOKS")); err == nil && maxConcurrentRunbooks > 0 {
+		return maxConcurrentRunbooks
+	}
+
+	return workerpool.DefaultMaxInFlight
+}
+
+// getTaskTimeoutMinutes reads the number of minutes to wait for a task to complete from the environment
+func getTaskTimeoutMinutes() int {
+	if taskTimeoutMinutes, err := strconv.Atoi(os.Getenv("OCTOTERRAWIZ_TASK_TIMEOUT_MINUTES")); err == nil && taskTimeoutMinutes > 0 {
+		return taskTimeoutMinutes
+	}
+
+	return int(infrastructure.DefaultTaskTimeout.Minutes())
 }
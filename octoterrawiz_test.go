@@ -204,6 +204,120 @@ func TestSpreadVariables(t *testing.T) {
 	})
 }
 
+// TestUnspreadVariables spreads the variables in a library variable set and then undoes the spreading, verifying the
+// variable set is returned to its original state
+func TestUnspreadVariables(t *testing.T) {
+	testFramework := test.OctopusContainerTest{}
+	testFramework.ArrangeTest(t, func(t *testing.T, container *test.OctopusContainer, client *client.Client) error {
+		// Act
+		newSpaceId, err := testFramework.Act(
+			t,
+			container,
+			filepath.Join("terraform"),
+			"2-simpleexample",
+			[]string{})
+
+		if err != nil {
+			return err
+		}
+
+		newSpaceClient, err := octoclient.CreateClient(container.URI, newSpaceId, test.ApiKey)
+
+		if err != nil {
+			return err
+		}
+
+		step := steps.SpreadVariablesStep{
+			BaseStep: steps.BaseStep{State: state.State{
+				BackendType: "AWS S3",
+				Server:      container.URI,
+				ApiKey:      test.ApiKey,
+				Space:       newSpaceId,
+			}},
+		}
+
+		lvs, err := newSpaceClient.LibraryVariableSets.GetAll()
+
+		if err != nil {
+			t.Fatalf("Error getting library variable sets: %v", err)
+		}
+
+		originalVariables, err := variables.GetVariableSet(newSpaceClient, newSpaceClient.GetSpaceID(), lvs[0].VariableSetID)
+
+		if err != nil {
+			t.Fatalf("Error getting library variable sets: %v", err)
+		}
+
+		if err := step.Execute(); err != nil {
+			t.Fatalf("Error executing step: %v", err)
+		}
+
+		report, err := step.ExecuteUnspread()
+
+		if err != nil {
+			t.Fatalf("Error undoing the step: %v", err)
+		}
+
+		// The 4 sensitive variables called "Test.SecretVariable" were spread and must be restored
+		if len(report.Results) != 4 || len(report.Failures()) != 0 {
+			t.Fatalf("Expected 4 restored variables, got %v", report.String())
+		}
+
+		lvsVariable, err := variables.GetVariableSet(newSpaceClient, newSpaceClient.GetSpaceID(), lvs[0].VariableSetID)
+
+		if err != nil {
+			t.Fatalf("Error getting library variable sets: %v", err)
+		}
+
+		// The reference variables must have been deleted
+		if len(lvsVariable.Variables) != len(originalVariables.Variables) {
+			t.Fatalf("Expected %v variables, got %v", len(originalVariables.Variables), len(lvsVariable.Variables))
+		}
+
+		if lo.ContainsBy(lvsVariable.Variables, func(item *variables.Variable) bool {
+			return item.Value != nil && strings.HasPrefix(*item.Value, "#{")
+		}) {
+			t.Fatalf("No reference variables should remain")
+		}
+
+		// Every variable must have the original name, scope, and sensitivity
+		for _, original := range originalVariables.Variables {
+			restored, found := lo.Find(lvsVariable.Variables, func(item *variables.Variable) bool {
+				return item.ID == original.ID
+			})
+
+			if !found {
+				t.Fatalf("Expected the variable %v to be restored", original.Name)
+			}
+
+			if restored.Name != original.Name {
+				t.Fatalf("Expected the variable %v to be called %v", restored.Name, original.Name)
+			}
+
+			if restored.IsSensitive != original.IsSensitive || restored.Type != original.Type {
+				t.Fatalf("Expected the variable %v to have the original sensitivity", original.Name)
+			}
+
+			if !sameScope(restored.Scope, original.Scope) {
+				t.Fatalf("Expected the variable %v to have the original scope", original.Name)
+			}
+		}
+
+		// Undoing again must not change anything
+		report, err = step.ExecuteUnspread()
+
+		if err != nil {
+			t.Fatalf("Error undoing the step: %v", err)
+		}
+
+		if len(report.Results) != 0 {
+			t.Fatalf("Expected no variables to be restored, got %v", report.String())
+		}
+
+		return nil
+	})
+}
+
 func TestProjectSpreadVariables(t *testing.T) {
 	projectName, err := GenerateRandomString(10)
 
@@ -591,3 +705,13 @@ func GenerateRandomString(length int) (string, error) {
 	}
 	return string(result), nil
 }
+
+func sameScope(scope1 variables.VariableScope, scope2 variables.VariableScope) bool {
+	return lo.ElementsMatch(scope1.Environments, scope2.Environments) &&
+		lo.ElementsMatch(scope1.Roles, scope2.Roles) &&
+		lo.ElementsMatch(scope1.Machines, scope2.Machines) &&
+		lo.ElementsMatch(scope1.Channels, scope2.Channels) &&
+		lo.ElementsMatch(scope1.Actions, scope2.Actions) &&
+		lo.ElementsMatch(scope1.TenantTags, scope2.TenantTags) &&
+		lo.ElementsMatch(scope1.ProcessOwners, scope2.ProcessOwners)
+}